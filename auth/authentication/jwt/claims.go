@@ -0,0 +1,61 @@
+package jwt
+
+import (
+	"encoding/json"
+	"slices"
+)
+
+// Claims is implemented by the payload of a token. Custom claims embed
+// RegisteredClaims, e.g.
+//
+//	type UserClaims struct {
+//		jwt.RegisteredClaims
+//		Roles []string `json:"roles"`
+//	}
+type Claims interface {
+	Registered() RegisteredClaims
+}
+
+// RegisteredClaims are the registered claims of RFC 7519.
+type RegisteredClaims struct {
+	Iss string   `json:"iss,omitempty"` // 签发者
+	Sub string   `json:"sub,omitempty"` // 用户标识
+	Aud Audience `json:"aud,omitempty"` // 接收方
+	Exp int64    `json:"exp,omitempty"` // 过期时间
+	Nbf int64    `json:"nbf,omitempty"` // 生效时间
+	Iat int64    `json:"iat,omitempty"` // 签发时间
+	Jti string   `json:"jti,omitempty"` // 令牌标识
+}
+
+// Registered implements Claims.
+func (c RegisteredClaims) Registered() RegisteredClaims {
+	return c
+}
+
+// Payload is the default claims of a token.
+type Payload = RegisteredClaims
+
+// Audience is the "aud" claim, a single string or an array of strings.
+type Audience []string
+
+// Contains reports whether aud is one of the audiences.
+func (a Audience) Contains(aud string) bool {
+	return slices.Contains(a, aud)
+}
+
+// MarshalJSON encodes a single audience as a string.
+func (a Audience) MarshalJSON() ([]byte, error) {
+	if len(a) == 1 {
+		return json.Marshal(a[0])
+	}
+	return json.Marshal([]string(a))
+}
+
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	var aud string
+	if err := json.Unmarshal(data, &aud); err == nil {
+		*a = Audience{aud}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(a))
+}
@@ -0,0 +1,88 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"math/big"
+)
+
+var _ SigningMethod = (*MethodECDSA)(nil)
+
+// MethodECDSA signs with ECDSA, the signature is the fixed size R || S.
+// It signs with a *ecdsa.PrivateKey and verifies with a *ecdsa.PublicKey
+// on the curve of the method.
+type MethodECDSA struct {
+	Name string
+	Hash crypto.Hash
+	// KeySize is the size of the curve in bytes.
+	KeySize int
+}
+
+// NewMethodES256 creates a new ECDSA signing method using P-256 and SHA-256.
+func NewMethodES256() SigningMethod {
+	return &MethodECDSA{Name: "ES256", Hash: crypto.SHA256, KeySize: 32}
+}
+
+// NewMethodES384 creates a new ECDSA signing method using P-384 and SHA-384.
+func NewMethodES384() SigningMethod {
+	return &MethodECDSA{Name: "ES384", Hash: crypto.SHA384, KeySize: 48}
+}
+
+// NewMethodES512 creates a new ECDSA signing method using P-521 and SHA-512.
+func NewMethodES512() SigningMethod {
+	return &MethodECDSA{Name: "ES512", Hash: crypto.SHA512, KeySize: 66}
+}
+
+// Sign implements SigningMethod, the key is a *ecdsa.PrivateKey.
+func (m *MethodECDSA) Sign(signingString string, key any) ([]byte, error) {
+	priv, ok := key.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, ErrInvalidKeyType
+	}
+	if priv == nil || priv.Curve == nil || priv.D == nil || (priv.Curve.Params().BitSize+7)/8 != m.KeySize {
+		return nil, ErrInvalidKey
+	}
+	if !m.Hash.Available() {
+		return nil, ErrHashUnavailable
+	}
+	h := m.Hash.New()
+	h.Write([]byte(signingString))
+	r, s, err := ecdsa.Sign(rand.Reader, priv, h.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+	sig := make([]byte, 2*m.KeySize)
+	r.FillBytes(sig[:m.KeySize])
+	s.FillBytes(sig[m.KeySize:])
+	return sig, nil
+}
+
+// Verify implements SigningMethod, the key is a *ecdsa.PublicKey.
+func (m *MethodECDSA) Verify(signingString string, sig []byte, key any) error {
+	pub, ok := key.(*ecdsa.PublicKey)
+	if !ok {
+		return ErrInvalidKeyType
+	}
+	if pub == nil || pub.Curve == nil || pub.X == nil || pub.Y == nil || (pub.Curve.Params().BitSize+7)/8 != m.KeySize {
+		return ErrInvalidKey
+	}
+	if len(sig) != 2*m.KeySize {
+		return ErrSignatureInvalid
+	}
+	if !m.Hash.Available() {
+		return ErrHashUnavailable
+	}
+	h := m.Hash.New()
+	h.Write([]byte(signingString))
+	r := new(big.Int).SetBytes(sig[:m.KeySize])
+	s := new(big.Int).SetBytes(sig[m.KeySize:])
+	if !ecdsa.Verify(pub, h.Sum(nil), r, s) {
+		return ErrSignatureInvalid
+	}
+	return nil
+}
+
+func (m *MethodECDSA) Alg() string {
+	return m.Name
+}
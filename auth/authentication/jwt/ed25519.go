@@ -0,0 +1,47 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+)
+
+var _ SigningMethod = (*MethodEd25519)(nil)
+
+// MethodEd25519 signs with Ed25519. It signs with an ed25519.PrivateKey and
+// verifies with an ed25519.PublicKey.
+type MethodEd25519 struct{}
+
+// NewMethodEdDSA creates a new EdDSA signing method using Ed25519.
+func NewMethodEdDSA() SigningMethod {
+	return &MethodEd25519{}
+}
+
+// Sign implements SigningMethod, the key is an ed25519.PrivateKey.
+func (m *MethodEd25519) Sign(signingString string, key any) ([]byte, error) {
+	priv, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, ErrInvalidKeyType
+	}
+	if len(priv) != ed25519.PrivateKeySize {
+		return nil, ErrInvalidKey
+	}
+	return ed25519.Sign(priv, []byte(signingString)), nil
+}
+
+// Verify implements SigningMethod, the key is an ed25519.PublicKey.
+func (m *MethodEd25519) Verify(signingString string, sig []byte, key any) error {
+	pub, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return ErrInvalidKeyType
+	}
+	if len(pub) != ed25519.PublicKeySize {
+		return ErrInvalidKey
+	}
+	if !ed25519.Verify(pub, []byte(signingString), sig) {
+		return ErrSignatureInvalid
+	}
+	return nil
+}
+
+func (m *MethodEd25519) Alg() string {
+	return "EdDSA"
+}
@@ -9,4 +9,15 @@ var (
 	ErrMethodNotFound      = errors.New("signing method not found")
 	ErrHashUnavailable     = errors.New("the requested hash function is unavailable")
 	ErrSignatureInvalid    = errors.New("signature is invalid")
+	ErrInvalidKey          = errors.New("key is invalid")
+	ErrInvalidKeyType      = errors.New("key is of invalid type")
+	ErrKeyNotFound         = errors.New("key not found")
+	ErrMethodNotAllowed    = errors.New("signing method not allowed")
+
+	ErrTokenExpired          = errors.New("token is expired")
+	ErrExpirationRequired    = errors.New("token has no expiration")
+	ErrTokenNotValidYet      = errors.New("token is not valid yet")
+	ErrTokenUsedBeforeIssued = errors.New("token used before issued")
+	ErrAudienceInvalid       = errors.New("token has invalid audience")
+	ErrIssuerInvalid         = errors.New("token has invalid issuer")
 )
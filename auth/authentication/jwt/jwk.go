@@ -0,0 +1,130 @@
+package jwt
+
+import (
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"math/big"
+
+	"github.com/yates-z/easel/utils/crypto/sm/sm2"
+)
+
+// JWK is a JSON Web Key of RFC 7517 holding a public key.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	// N and E are the modulus and exponent of an RSA key.
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// Crv, X and Y are the curve and point of an EC or OKP key.
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKSet is a JSON Web Key Set.
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// NewJWK encodes the public key kid used by alg. A *sm2.PublicKey is encoded
+// as an EC key on the "SM2" curve.
+func NewJWK[K PublicKey](kid, alg string, key K) (JWK, error) {
+	return newJWK(kid, alg, key)
+}
+
+func newJWK(kid, alg string, key any) (JWK, error) {
+	k := JWK{Kid: kid, Alg: alg, Use: "sig"}
+	switch pub := key.(type) {
+	case *rsa.PublicKey:
+		if pub == nil || pub.N == nil {
+			return JWK{}, ErrInvalidKey
+		}
+		k.Kty = "RSA"
+		k.N = Base64URLEncode(pub.N.Bytes())
+		k.E = Base64URLEncode(big.NewInt(int64(pub.E)).Bytes())
+	case *ecdsa.PublicKey:
+		if pub == nil || pub.Curve == nil {
+			return JWK{}, ErrInvalidKey
+		}
+		ecdhKey, err := pub.ECDH()
+		if err != nil {
+			return JWK{}, ErrInvalidKey
+		}
+		data := ecdhKey.Bytes()
+		size := (len(data) - 1) / 2
+		k.Kty = "EC"
+		k.Crv = pub.Curve.Params().Name
+		k.X = Base64URLEncode(data[1 : 1+size])
+		k.Y = Base64URLEncode(data[1+size:])
+	case ed25519.PublicKey:
+		k.Kty = "OKP"
+		k.Crv = "Ed25519"
+		k.X = Base64URLEncode(pub)
+	case *sm2.PublicKey:
+		if pub == nil || pub.X == nil || pub.Y == nil {
+			return JWK{}, ErrInvalidKey
+		}
+		data := pub.GetRawBytes()
+		k.Kty = "EC"
+		k.Crv = "SM2"
+		k.X = Base64URLEncode(data[:sm2.KeyBytes])
+		k.Y = Base64URLEncode(data[sm2.KeyBytes:])
+	default:
+		return JWK{}, ErrInvalidKeyType
+	}
+	return k, nil
+}
+
+// Key decodes the public key.
+func (k JWK) Key() (any, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err1 := Base64URLDecode(k.N)
+		e, err2 := Base64URLDecode(k.E)
+		if err1 != nil || err2 != nil || len(e) == 0 || len(e) > 4 {
+			return nil, ErrInvalidKey
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		x, err1 := Base64URLDecode(k.X)
+		y, err2 := Base64URLDecode(k.Y)
+		if err1 != nil || err2 != nil || len(x) != len(y) {
+			return nil, ErrInvalidKey
+		}
+		if k.Crv == "SM2" {
+			return sm2.RawBytesToPublicKey(append(x, y...))
+		}
+		var (
+			curve     elliptic.Curve
+			ecdhCurve ecdh.Curve
+		)
+		switch k.Crv {
+		case "P-256":
+			curve, ecdhCurve = elliptic.P256(), ecdh.P256()
+		case "P-384":
+			curve, ecdhCurve = elliptic.P384(), ecdh.P384()
+		case "P-521":
+			curve, ecdhCurve = elliptic.P521(), ecdh.P521()
+		default:
+			return nil, ErrInvalidKey
+		}
+		// ecdh checks the point is on the curve.
+		if _, err := ecdhCurve.NewPublicKey(append(append([]byte{4}, x...), y...)); err != nil {
+			return nil, ErrInvalidKey
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	case "OKP":
+		x, err := Base64URLDecode(k.X)
+		if err != nil || k.Crv != "Ed25519" || len(x) != ed25519.PublicKeySize {
+			return nil, ErrInvalidKey
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, ErrInvalidKeyType
+	}
+}
@@ -0,0 +1,61 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/yates-z/easel/utils/crypto/sm/sm2"
+)
+
+func TestJWK(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	edPub, _, _ := ed25519.GenerateKey(rand.Reader)
+	_, smPub, _ := sm2.GenerateKey(rand.Reader)
+
+	for _, key := range []any{&rsaKey.PublicKey, &ecKey.PublicKey, edPub, smPub} {
+		k, err := newJWK("kid", "", key)
+		if err != nil {
+			t.Fatalf("%T: %v", key, err)
+		}
+		data, err := json.Marshal(k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decoded JWK
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatal(err)
+		}
+		got, err := decoded.Key()
+		if err != nil {
+			t.Fatalf("%T: %v", key, err)
+		}
+		switch want := key.(type) {
+		case *sm2.PublicKey:
+			if got.(*sm2.PublicKey).X.Cmp(want.X) != 0 || got.(*sm2.PublicKey).Y.Cmp(want.Y) != 0 {
+				t.Fatalf("%T: key changed after a round trip", key)
+			}
+		default:
+			if !reflect.DeepEqual(got, key) {
+				t.Fatalf("%T: key changed after a round trip", key)
+			}
+		}
+	}
+
+	if _, err := newJWK("kid", "HS256", []byte("secret")); !errors.Is(err, ErrInvalidKeyType) {
+		t.Fatalf("expected ErrInvalidKeyType, got %v", err)
+	}
+	// a point not on the curve.
+	k, _ := NewJWK("kid", "", &ecKey.PublicKey)
+	k.Y = k.X
+	if _, err := k.Key(); !errors.Is(err, ErrInvalidKey) {
+		t.Fatalf("expected ErrInvalidKey, got %v", err)
+	}
+}
@@ -0,0 +1,33 @@
+package jwks
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/yates-z/easel/auth/authentication/jwt"
+	"github.com/yates-z/easel/transport/http/server"
+)
+
+// DefaultPath is the well-known path of a JWKS.
+const DefaultPath = "/.well-known/jwks.json"
+
+// maxAge is how long clients may cache the key set. A Resolver fetches the
+// set again when a token is signed by an unknown key, so new keys are picked
+// up before it elapses.
+const maxAge = 5 * time.Minute
+
+// Handler serves the public keys of keys as a JWKS. Keys added to and
+// removed from the set are served at once, so a key being rotated in is
+// published before it signs tokens, and the old key until it is removed.
+func Handler(keys *jwt.KeySet) server.HandlerFunc {
+	return func(ctx *server.Context) error {
+		ctx.SetHeader("Cache-Control", "public, max-age="+strconv.Itoa(int(maxAge.Seconds())))
+		return ctx.JSON(http.StatusOK, keys.JWKS())
+	}
+}
+
+// Register serves the public keys of keys at DefaultPath.
+func Register(r server.IRoute, keys *jwt.KeySet) *server.Route {
+	return r.GET(DefaultPath, Handler(keys))
+}
@@ -0,0 +1,110 @@
+package jwks
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/yates-z/easel/auth/authentication/jwt"
+	"github.com/yates-z/easel/transport/http/server"
+)
+
+func newIssuer(t *testing.T, keys *jwt.KeySet) (url string, fetches *atomic.Int32) {
+	s := server.NewServer()
+	fetches = new(atomic.Int32)
+	Register(s, keys)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		s.Handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(ts.Close)
+	return ts.URL + DefaultPath, fetches
+}
+
+func sign[K jwt.SigningKey](t *testing.T, method func() jwt.SigningMethod, kid string, key K) string {
+	token := jwt.NewToken(method, jwt.Payload{Sub: "svc", Exp: time.Now().Add(time.Hour).Unix()})
+	token.Header.Kid = kid
+	raw, err := jwt.Sign(token, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func TestResolver(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edPub, edPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := jwt.NewKeySet()
+	jwt.AddKey(keys, "rsa", "RS256", &rsaKey.PublicKey)
+	jwt.AddKey(keys, "ec", "ES256", &ecKey.PublicKey)
+	// secrets are never published.
+	jwt.AddKey(keys, "hmac", "HS256", []byte("secret"))
+	url, fetches := newIssuer(t, keys)
+
+	r := NewResolver(url, MinRefreshInterval(0))
+	for _, raw := range []string{sign(t, jwt.NewMethodRS256, "rsa", rsaKey), sign(t, jwt.NewMethodES256, "ec", ecKey)} {
+		if _, err := jwt.Parse[jwt.Payload](raw, r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := fetches.Load(); n != 1 {
+		t.Fatalf("expected the key set to be fetched once, got %d", n)
+	}
+	if _, err := jwt.Parse[jwt.Payload](sign(t, jwt.NewMethodHS256, "hmac", []byte("secret")), r); !errors.Is(err, jwt.ErrKeyNotFound) {
+		t.Fatalf("expected ErrKeyNotFound, got %v", err)
+	}
+
+	// a rotated key is fetched on its first use.
+	jwt.AddKey(keys, "ed", "EdDSA", edPub)
+	if _, err := jwt.Parse[jwt.Payload](sign(t, jwt.NewMethodEdDSA, "ed", edPriv), r); err != nil {
+		t.Fatal(err)
+	}
+	// a key is only used by its signing method.
+	if _, err := jwt.Parse[jwt.Payload](sign(t, jwt.NewMethodEdDSA, "rsa", edPriv), r); !errors.Is(err, jwt.ErrMethodNotAllowed) {
+		t.Fatalf("expected ErrMethodNotAllowed, got %v", err)
+	}
+}
+
+func TestResolverRateLimit(t *testing.T) {
+	_, edPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := jwt.NewKeySet()
+	url, fetches := newIssuer(t, keys)
+	r := NewResolver(url, MinRefreshInterval(time.Hour))
+	for range 10 {
+		if _, err := jwt.Parse[jwt.Payload](sign(t, jwt.NewMethodEdDSA, "unknown", edPriv), r); !errors.Is(err, jwt.ErrKeyNotFound) {
+			t.Fatalf("expected ErrKeyNotFound, got %v", err)
+		}
+	}
+	if n := fetches.Load(); n != 1 {
+		t.Fatalf("expected unknown keys to be fetched once, got %d", n)
+	}
+}
+
+func TestResolverUnavailable(t *testing.T) {
+	ts := httptest.NewServer(http.NotFoundHandler())
+	defer ts.Close()
+	if _, err := NewResolver(ts.URL).ResolveKey(jwt.Header{Kid: "a"}); err == nil || errors.Is(err, jwt.ErrKeyNotFound) {
+		t.Fatalf("expected a fetch error, got %v", err)
+	}
+}
@@ -0,0 +1,161 @@
+package jwks
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/yates-z/easel/auth/authentication/jwt"
+)
+
+var _ jwt.KeyResolver = (*Resolver)(nil)
+
+type ResolverOption func(*Resolver)
+
+// HTTPClient with the client fetching the key set.
+func HTTPClient(c *http.Client) ResolverOption {
+	return func(r *Resolver) {
+		r.client = c
+	}
+}
+
+// CacheTTL with how long the fetched keys are used before the set is fetched
+// again, one hour by default.
+func CacheTTL(d time.Duration) ResolverOption {
+	return func(r *Resolver) {
+		r.ttl = d
+	}
+}
+
+// MinRefreshInterval with the minimum interval between two fetches, 30
+// seconds by default. It bounds the fetches caused by tokens with unknown
+// key ids.
+func MinRefreshInterval(d time.Duration) ResolverOption {
+	return func(r *Resolver) {
+		r.minInterval = d
+	}
+}
+
+// Resolver resolves the keys verifying tokens from a remote JWKS. The keys
+// are cached, and fetched again when they expire or a token is signed by an
+// unknown key, at most once per MinRefreshInterval.
+type Resolver struct {
+	url         string
+	client      *http.Client
+	ttl         time.Duration
+	minInterval time.Duration
+
+	keys atomic.Pointer[keySet]
+	// mu serializes the fetches.
+	mu        sync.Mutex
+	lastFetch time.Time
+}
+
+type keySet struct {
+	keys    map[string]entry
+	expires time.Time
+}
+
+type entry struct {
+	alg string
+	key any
+}
+
+// NewResolver creates a Resolver fetching the JWKS at url.
+func NewResolver(url string, opts ...ResolverOption) *Resolver {
+	r := &Resolver{
+		url:         url,
+		client:      &http.Client{Timeout: 10 * time.Second},
+		ttl:         time.Hour,
+		minInterval: 30 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// ResolveKey implements jwt.KeyResolver.
+func (r *Resolver) ResolveKey(header jwt.Header) (any, error) {
+	if set := r.keys.Load(); set != nil && time.Now().Before(set.expires) {
+		if e, ok := set.keys[header.Kid]; ok {
+			return e.resolve(header)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// the set may have been fetched while waiting.
+	set := r.keys.Load()
+	if set == nil || !time.Now().Before(set.expires) || !set.has(header.Kid) {
+		if time.Since(r.lastFetch) >= r.minInterval {
+			if err := r.refresh(); err != nil && set == nil {
+				return nil, err
+			}
+			set = r.keys.Load()
+		}
+	}
+	// stale keys are used if the set can't be fetched.
+	if e, ok := set.lookup(header.Kid); ok {
+		return e.resolve(header)
+	}
+	return nil, jwt.ErrKeyNotFound
+}
+
+// Refresh fetches the key set.
+func (r *Resolver) Refresh() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.refresh()
+}
+
+func (r *Resolver) refresh() error {
+	r.lastFetch = time.Now()
+	resp, err := r.client.Get(r.url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("jwks: fetching %s: %s", r.url, resp.Status)
+	}
+	var set jwt.JWKSet
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return fmt.Errorf("jwks: decoding %s: %w", r.url, err)
+	}
+	keys := make(map[string]entry, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		// keys of unsupported types are skipped.
+		if key, err := k.Key(); err == nil {
+			keys[k.Kid] = entry{alg: k.Alg, key: key}
+		}
+	}
+	r.keys.Store(&keySet{keys: keys, expires: time.Now().Add(r.ttl)})
+	return nil
+}
+
+func (s *keySet) has(kid string) bool {
+	_, ok := s.lookup(kid)
+	return ok
+}
+
+func (s *keySet) lookup(kid string) (entry, bool) {
+	if s == nil {
+		return entry{}, false
+	}
+	e, ok := s.keys[kid]
+	return e, ok
+}
+
+func (e entry) resolve(header jwt.Header) (any, error) {
+	if e.alg != "" && e.alg != header.Alg {
+		return nil, jwt.ErrMethodNotAllowed
+	}
+	return e.key, nil
+}
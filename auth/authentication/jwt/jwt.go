@@ -3,51 +3,51 @@ package jwt
 import (
 	"encoding/json"
 	"strings"
-	"time"
 )
 
 // Header definition.
 type Header struct {
 	Alg string `json:"alg"`
 	Typ string `json:"typ"`
+	// Kid is the id of the key signing the token, see KeySet.
+	Kid string `json:"kid,omitempty"`
 }
 
-// Payload definition.
-type Payload struct {
-	Sub string `json:"sub"` // 用户标识
-	Iat int64  `json:"iat"` // 签发时间
-	Exp int64  `json:"exp"` // 过期时间
-	Iss string `json:"iss"` // 签发者
-}
-
-// Token is a JWT token.
-type Token struct {
+// Token is a JWT token with claims C.
+type Token[C Claims] struct {
 	// Raw is the raw token.
 	Raw string
 	// Header is the first part of the token.
 	Header Header
-	// Payload is the second part of the token.
-	Payload Payload
+	// Claims is the second part of the token.
+	Claims C
 	// Signature is the third part of the token.
 	Signature []byte
 	// Method is the signing method used.
 	Method SigningMethod
 }
 
-func NewToken(m func() SigningMethod, payload Payload) *Token {
+// NewToken creates a token signed by the method m.
+func NewToken[C Claims](m func() SigningMethod, claims C) *Token[C] {
 	method := m()
-	return &Token{
+	return &Token[C]{
 		Header: Header{
 			Alg: method.Alg(),
 			Typ: "JWT",
 		},
-		Payload: payload,
-		Method:  method,
+		Claims: claims,
+		Method: method,
 	}
 }
 
-// FromToken generates a Token from a string.
-func FromToken(token string) (*Token, error) {
+// FromToken generates a Token with the default claims from a string, without
+// verifying it.
+func FromToken(token string) (*Token[Payload], error) {
+	return ParseUnverified[Payload](token)
+}
+
+// ParseUnverified generates a Token from a string, without verifying it.
+func ParseUnverified[C Claims](token string) (*Token[C], error) {
 	parts := strings.Split(token, ".")
 	if len(parts) != 3 {
 		return nil, ErrTokenInvalid
@@ -76,8 +76,8 @@ func FromToken(token string) (*Token, error) {
 	if err != nil {
 		return nil, ErrTokenPayloadInvalid
 	}
-	payload := Payload{}
-	err = json.Unmarshal(payloadJSON, &payload)
+	var claims C
+	err = json.Unmarshal(payloadJSON, &claims)
 	if err != nil {
 		return nil, ErrTokenPayloadInvalid
 	}
@@ -87,24 +87,52 @@ func FromToken(token string) (*Token, error) {
 		return nil, ErrSignatureInvalid
 	}
 
-	return &Token{
+	return &Token[C]{
 		Raw:       token,
 		Header:    header,
-		Payload:   payload,
+		Claims:    claims,
 		Signature: signature,
 		Method:    method,
 	}, nil
 }
 
-// Generate generates a new JWT token.
-func (t *Token) Generate(key []byte) (string, error) {
+// Parse generates a Token from a string, and validates it with the key
+// resolved by keys.
+func Parse[C Claims](token string, keys KeyResolver, opts ...ValidateOption) (*Token[C], error) {
+	t, err := ParseUnverified[C](token)
+	if err != nil {
+		return nil, err
+	}
+	key, err := keys.ResolveKey(t.Header)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.validate(key, opts...); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Sign signs t with key, the SigningKey of its method, and returns the
+// token, also set into t.Raw.
+func Sign[C Claims, K SigningKey](t *Token[C], key K) (string, error) {
+	return t.sign(key)
+}
+
+// Verify verifies t with key, the VerifyingKey of its method.
+// Use Validate to know why a token is invalid.
+func Verify[C Claims, K VerifyingKey](t *Token[C], key K) bool {
+	return t.validate(key) == nil
+}
+
+func (t *Token[C]) sign(key any) (string, error) {
 
 	// 编码 Header 和 Payload
 	headerJSON, err := json.Marshal(t.Header)
 	if err != nil {
 		return "", err
 	}
-	payloadJSON, err := json.Marshal(t.Payload)
+	payloadJSON, err := json.Marshal(t.Claims)
 	if err != nil {
 		return "", err
 	}
@@ -122,19 +150,3 @@ func (t *Token) Generate(key []byte) (string, error) {
 	t.Raw = headerEncoded + "." + payloadEncoded + "." + signatureEncoded
 	return t.Raw, nil
 }
-
-// Verify verifies the JWT token.
-func (t *Token) Verify(key []byte) bool {
-	parts := strings.Split(t.Raw, ".")
-
-	err := t.Method.Verify(parts[0]+"."+parts[1], t.Signature, key)
-	if err != nil {
-		return false
-	}
-
-	if time.Now().Unix() > t.Payload.Exp {
-		return false
-	}
-
-	return true
-}
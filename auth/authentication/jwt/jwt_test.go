@@ -18,7 +18,7 @@ func Test_JWT(t *testing.T) {
 
 	// 生成 JWT
 	token := NewToken(NewMethodHS256, payload)
-	tokenStr, err := token.Generate(key)
+	tokenStr, err := Sign(token, key)
 	if err != nil {
 		fmt.Println("Failed to generate JWT:", err)
 		return
@@ -30,9 +30,9 @@ func Test_JWT(t *testing.T) {
 	if err != nil {
 		fmt.Println("Parse JWT token failed:", err)
 	}
-	valid := token.Verify(key)
+	valid := Verify(token, key)
 	if valid {
-		fmt.Println("JWT is valid. Payload:", token.Payload)
+		fmt.Println("JWT is valid. Payload:", token.Claims)
 	} else {
 		fmt.Println("Invalid JWT")
 	}
@@ -0,0 +1,106 @@
+package jwt
+
+import (
+	"maps"
+	"slices"
+	"sync"
+)
+
+// KeyResolver resolves the key verifying a token from its header.
+type KeyResolver interface {
+	ResolveKey(header Header) (any, error)
+}
+
+// KeyFunc is an adapter to use a function as a KeyResolver.
+type KeyFunc func(header Header) (any, error)
+
+func (f KeyFunc) ResolveKey(header Header) (any, error) {
+	return f(header)
+}
+
+// StaticKey returns a KeyResolver always resolving key.
+func StaticKey[K VerifyingKey](key K) KeyResolver {
+	return KeyFunc(func(Header) (any, error) {
+		return key, nil
+	})
+}
+
+// KeySet resolves the verification key of a token by its "kid" header.
+//
+// Keys are rotated by adding the new key, signing new tokens with its id,
+// and removing the old key once the tokens it signed have expired.
+type KeySet struct {
+	mu   sync.RWMutex
+	keys map[string]keyEntry
+}
+
+type keyEntry struct {
+	alg string
+	key any
+}
+
+// NewKeySet creates an empty KeySet.
+func NewKeySet() *KeySet {
+	return &KeySet{keys: make(map[string]keyEntry)}
+}
+
+// AddKey adds the verification key kid to s, used by tokens signed by alg.
+// An empty alg accepts any signing method the key type fits.
+func AddKey[K VerifyingKey](s *KeySet, kid, alg string, key K) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys[kid] = keyEntry{alg: alg, key: key}
+}
+
+// Remove removes the key kid.
+func (s *KeySet) Remove(kid string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.keys, kid)
+}
+
+// Key returns the key kid and its signing method.
+func (s *KeySet) Key(kid string) (alg string, key any, ok bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	e, ok := s.keys[kid]
+	return e.alg, e.key, ok
+}
+
+// IDs returns the ids of the keys.
+func (s *KeySet) IDs() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ids := make([]string, 0, len(s.keys))
+	for kid := range s.keys {
+		ids = append(ids, kid)
+	}
+	return ids
+}
+
+// JWKS returns the public keys of the set, the other keys, e.g. HMAC
+// secrets, are never published.
+func (s *KeySet) JWKS() JWKSet {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	set := JWKSet{Keys: []JWK{}}
+	for _, kid := range slices.Sorted(maps.Keys(s.keys)) {
+		e := s.keys[kid]
+		if k, err := newJWK(kid, e.alg, e.key); err == nil {
+			set.Keys = append(set.Keys, k)
+		}
+	}
+	return set
+}
+
+// ResolveKey implements KeyResolver.
+func (s *KeySet) ResolveKey(header Header) (any, error) {
+	alg, key, ok := s.Key(header.Kid)
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+	if alg != "" && alg != header.Alg {
+		return nil, ErrMethodNotAllowed
+	}
+	return key, nil
+}
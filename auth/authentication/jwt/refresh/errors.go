@@ -0,0 +1,9 @@
+package refresh
+
+import "errors"
+
+var (
+	ErrTokenRevoked = errors.New("token is revoked")
+	ErrTokenReused  = errors.New("refresh token is reused, its family is revoked")
+	ErrTokenType    = errors.New("token is of invalid type")
+)
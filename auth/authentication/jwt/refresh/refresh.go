@@ -0,0 +1,250 @@
+package refresh
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yates-z/easel/auth/authentication/jwt"
+	"github.com/yates-z/easel/core/cache"
+)
+
+const (
+	TypeAccess  = "access"
+	TypeRefresh = "refresh"
+)
+
+// Claims are the claims of the tokens issued by a Service.
+type Claims struct {
+	jwt.RegisteredClaims
+	// Family is the id shared by the tokens issued from the same login.
+	Family string `json:"fam"`
+	// Type is TypeAccess or TypeRefresh.
+	Type string `json:"typ"`
+}
+
+// Pair is an access token and the refresh token renewing it.
+type Pair struct {
+	AccessToken      string    `json:"access_token"`
+	RefreshToken     string    `json:"refresh_token"`
+	AccessExpiresAt  time.Time `json:"access_expires_at"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+}
+
+type Option func(*Service)
+
+// AccessTTL with the lifetime of access tokens, 15 minutes by default.
+func AccessTTL(d time.Duration) Option {
+	return func(s *Service) {
+		s.accessTTL = d
+	}
+}
+
+// RefreshTTL with the lifetime of refresh tokens, 7 days by default.
+func RefreshTTL(d time.Duration) Option {
+	return func(s *Service) {
+		s.refreshTTL = d
+	}
+}
+
+// Issuer with the "iss" claim of the tokens, which is validated too.
+func Issuer(iss string) Option {
+	return func(s *Service) {
+		s.issuer = iss
+	}
+}
+
+// Audience with the "aud" claim of the tokens, which is validated too.
+func Audience(aud string) Option {
+	return func(s *Service) {
+		s.audience = aud
+	}
+}
+
+// KeyID with the "kid" header of the tokens.
+func KeyID(kid string) Option {
+	return func(s *Service) {
+		s.kid = kid
+	}
+}
+
+// Denylist with the cache storing the revoked token ids and families until
+// they expire. The default is an in-memory cache of a million entries, a
+// shared backend is required when the service runs on several instances.
+// The cache must not evict entries before they expire.
+func Denylist(c cache.Cache[string, int64]) Option {
+	return func(s *Service) {
+		s.denylist = c
+	}
+}
+
+// Service issues access and refresh token pairs.
+//
+// Refresh tokens are rotated: each one is used once to get a new pair. A
+// refresh token used twice has leaked, so its whole family, all the tokens
+// issued from the same login, is revoked.
+type Service struct {
+	method     func() jwt.SigningMethod
+	signToken  func(*jwt.Token[Claims]) (string, error)
+	keys       jwt.KeyResolver
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+	issuer     string
+	audience   string
+	kid        string
+	denylist   cache.Cache[string, int64]
+	now        func() time.Time
+}
+
+// NewService creates a Service signing tokens with method and signKey, and
+// verifying them with the key resolved by keys, e.g. jwt.StaticKey(secret).
+func NewService[K jwt.SigningKey](method func() jwt.SigningMethod, signKey K, keys jwt.KeyResolver, opts ...Option) *Service {
+	s := &Service{
+		method: method,
+		signToken: func(t *jwt.Token[Claims]) (string, error) {
+			return jwt.Sign(t, signKey)
+		},
+		keys:       keys,
+		accessTTL:  15 * time.Minute,
+		refreshTTL: 7 * 24 * time.Hour,
+		now:        time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.denylist == nil {
+		s.denylist = cache.NewMemCache[string, int64](32, 1<<20, time.Minute)
+	}
+	return s
+}
+
+// Issue issues a pair of a new family for subject, e.g. after a login.
+func (s *Service) Issue(subject string) (*Pair, error) {
+	return s.issue(subject, uuid.NewString())
+}
+
+// Refresh rotates refreshToken: it can't be used again and a new pair of
+// the same family is issued. It returns ErrTokenReused, and revokes the
+// family, if refreshToken was already used.
+func (s *Service) Refresh(refreshToken string) (*Pair, error) {
+	claims, err := s.parse(refreshToken, TypeRefresh)
+	if err != nil {
+		return nil, err
+	}
+	// mark the token used, at most one concurrent refresh succeeds.
+	_, used, err := s.denylist.GetOrSet(jtiKey(claims.Jti), claims.Exp, s.remaining(claims.Exp))
+	if err != nil {
+		return nil, err
+	}
+	if used {
+		if err := s.RevokeFamily(claims.Family); err != nil {
+			return nil, err
+		}
+		return nil, ErrTokenReused
+	}
+	return s.issue(claims.Sub, claims.Family)
+}
+
+// Verify verifies accessToken, and returns its claims.
+func (s *Service) Verify(accessToken string) (*Claims, error) {
+	return s.parse(accessToken, TypeAccess)
+}
+
+// Revoke revokes a token, access or refresh, until it expires.
+func (s *Service) Revoke(token string) error {
+	t, err := jwt.Parse[Claims](token, s.keys, s.validateOptions()...)
+	if err != nil {
+		return err
+	}
+	return s.denylist.Set(jtiKey(t.Claims.Jti), t.Claims.Exp, s.remaining(t.Claims.Exp))
+}
+
+// RevokeFamily revokes all the tokens issued from the same login, e.g. on
+// logout.
+func (s *Service) RevokeFamily(family string) error {
+	// no token of the family outlives a refresh token issued now.
+	exp := s.now().Add(s.refreshTTL)
+	return s.denylist.Set(familyKey(family), exp.Unix(), s.refreshTTL)
+}
+
+func (s *Service) issue(subject, family string) (*Pair, error) {
+	now := s.now()
+	pair := &Pair{
+		AccessExpiresAt:  now.Add(s.accessTTL),
+		RefreshExpiresAt: now.Add(s.refreshTTL),
+	}
+	var err error
+	if pair.AccessToken, err = s.sign(subject, family, TypeAccess, now, pair.AccessExpiresAt); err != nil {
+		return nil, err
+	}
+	if pair.RefreshToken, err = s.sign(subject, family, TypeRefresh, now, pair.RefreshExpiresAt); err != nil {
+		return nil, err
+	}
+	return pair, nil
+}
+
+func (s *Service) sign(subject, family, typ string, now, exp time.Time) (string, error) {
+	claims := Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Iss: s.issuer,
+			Sub: subject,
+			Exp: exp.Unix(),
+			Iat: now.Unix(),
+			Jti: uuid.NewString(),
+		},
+		Family: family,
+		Type:   typ,
+	}
+	if s.audience != "" {
+		claims.Aud = jwt.Audience{s.audience}
+	}
+	token := jwt.NewToken(s.method, claims)
+	token.Header.Kid = s.kid
+	return s.signToken(token)
+}
+
+func (s *Service) parse(raw, typ string) (*Claims, error) {
+	t, err := jwt.Parse[Claims](raw, s.keys, s.validateOptions()...)
+	if err != nil {
+		return nil, err
+	}
+	claims := &t.Claims
+	if claims.Type != typ {
+		return nil, ErrTokenType
+	}
+	if s.denylist.HasKey(familyKey(claims.Family)) {
+		return nil, ErrTokenRevoked
+	}
+	// a used refresh token is checked by Refresh, to detect the reuse.
+	if typ == TypeAccess && s.denylist.HasKey(jtiKey(claims.Jti)) {
+		return nil, ErrTokenRevoked
+	}
+	return claims, nil
+}
+
+func (s *Service) validateOptions() []jwt.ValidateOption {
+	opts := []jwt.ValidateOption{
+		jwt.WithMethods(s.method().Alg()),
+		jwt.WithTimeFunc(s.now),
+	}
+	if s.issuer != "" {
+		opts = append(opts, jwt.WithIssuer(s.issuer))
+	}
+	if s.audience != "" {
+		opts = append(opts, jwt.WithAudience(s.audience))
+	}
+	return opts
+}
+
+// remaining returns the time left until exp, the ttl of a denylist entry.
+func (s *Service) remaining(exp int64) time.Duration {
+	// at least a second, a zero ttl never expires.
+	return max(time.Unix(exp, 0).Sub(s.now()), time.Second)
+}
+
+func jtiKey(jti string) string {
+	return "jti:" + jti
+}
+
+func familyKey(family string) string {
+	return "fam:" + family
+}
@@ -0,0 +1,139 @@
+package refresh
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/yates-z/easel/auth/authentication/jwt"
+	"github.com/yates-z/easel/core/cache"
+)
+
+var secret = []byte("secret")
+
+func newService(opts ...Option) *Service {
+	denylist := cache.NewMemCache[string, int64](4, 1024, time.Minute)
+	return NewService(jwt.NewMethodHS256, secret, jwt.StaticKey(secret), append([]Option{Denylist(denylist), Issuer("easel")}, opts...)...)
+}
+
+func TestRotation(t *testing.T) {
+	s := newService()
+	first, err := s.Issue("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := s.Verify(first.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Sub != "alice" || claims.Iss != "easel" {
+		t.Fatalf("unexpected claims %+v", claims)
+	}
+	if _, err := s.Verify(first.RefreshToken); !errors.Is(err, ErrTokenType) {
+		t.Fatalf("a refresh token is not an access token, got %v", err)
+	}
+	if _, err := s.Refresh(first.AccessToken); !errors.Is(err, ErrTokenType) {
+		t.Fatalf("an access token is not a refresh token, got %v", err)
+	}
+
+	second, err := s.Refresh(first.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Verify(second.AccessToken); err != nil {
+		t.Fatal(err)
+	}
+
+	// the first refresh token leaked and is reused: the family is revoked.
+	if _, err := s.Refresh(first.RefreshToken); !errors.Is(err, ErrTokenReused) {
+		t.Fatalf("expected ErrTokenReused, got %v", err)
+	}
+	if _, err := s.Refresh(second.RefreshToken); !errors.Is(err, ErrTokenRevoked) {
+		t.Fatalf("expected the family to be revoked, got %v", err)
+	}
+	for _, access := range []string{first.AccessToken, second.AccessToken} {
+		if _, err := s.Verify(access); !errors.Is(err, ErrTokenRevoked) {
+			t.Fatalf("expected the family to be revoked, got %v", err)
+		}
+	}
+
+	// other families are not affected.
+	other, err := s.Issue("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Refresh(other.RefreshToken); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConcurrentRefresh(t *testing.T) {
+	s := newService()
+	pair, err := s.Issue("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		wg        sync.WaitGroup
+		succeeded atomic.Int32
+	)
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := s.Refresh(pair.RefreshToken); err == nil {
+				succeeded.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := succeeded.Load(); n != 1 {
+		t.Fatalf("expected one refresh to succeed, got %d", n)
+	}
+}
+
+func TestRevoke(t *testing.T) {
+	s := newService()
+	pair, err := s.Issue("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Revoke(pair.AccessToken); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Verify(pair.AccessToken); !errors.Is(err, ErrTokenRevoked) {
+		t.Fatalf("expected ErrTokenRevoked, got %v", err)
+	}
+	// revoking an access token keeps the session.
+	if _, err := s.Refresh(pair.RefreshToken); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExpired(t *testing.T) {
+	now := time.Now()
+	s := newService(AccessTTL(time.Minute), RefreshTTL(time.Hour))
+	s.now = func() time.Time { return now }
+	pair, err := s.Issue("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(2 * time.Minute)
+	if _, err := s.Verify(pair.AccessToken); !errors.Is(err, jwt.ErrTokenExpired) {
+		t.Fatalf("expected ErrTokenExpired, got %v", err)
+	}
+	if _, err := s.Refresh(pair.RefreshToken); err != nil {
+		t.Fatal(err)
+	}
+	// a token of another issuer.
+	other := NewService(jwt.NewMethodHS256, secret, jwt.StaticKey(secret), Issuer("other"))
+	foreign, err := other.Issue("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Verify(foreign.AccessToken); !errors.Is(err, jwt.ErrIssuerInvalid) {
+		t.Fatalf("expected ErrIssuerInvalid, got %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+)
+
+var _ SigningMethod = (*MethodRSA)(nil)
+
+// MethodRSA signs with RSASSA-PKCS1-v1_5, or RSASSA-PSS if PSS is set.
+// It signs with a *rsa.PrivateKey and verifies with a *rsa.PublicKey.
+type MethodRSA struct {
+	Name string
+	Hash crypto.Hash
+	PSS  bool
+}
+
+// NewMethodRS256 creates a new RSA signing method using SHA-256.
+func NewMethodRS256() SigningMethod {
+	return &MethodRSA{Name: "RS256", Hash: crypto.SHA256}
+}
+
+// NewMethodRS384 creates a new RSA signing method using SHA-384.
+func NewMethodRS384() SigningMethod {
+	return &MethodRSA{Name: "RS384", Hash: crypto.SHA384}
+}
+
+// NewMethodRS512 creates a new RSA signing method using SHA-512.
+func NewMethodRS512() SigningMethod {
+	return &MethodRSA{Name: "RS512", Hash: crypto.SHA512}
+}
+
+// NewMethodPS256 creates a new RSA-PSS signing method using SHA-256.
+func NewMethodPS256() SigningMethod {
+	return &MethodRSA{Name: "PS256", Hash: crypto.SHA256, PSS: true}
+}
+
+// NewMethodPS384 creates a new RSA-PSS signing method using SHA-384.
+func NewMethodPS384() SigningMethod {
+	return &MethodRSA{Name: "PS384", Hash: crypto.SHA384, PSS: true}
+}
+
+// NewMethodPS512 creates a new RSA-PSS signing method using SHA-512.
+func NewMethodPS512() SigningMethod {
+	return &MethodRSA{Name: "PS512", Hash: crypto.SHA512, PSS: true}
+}
+
+// Sign implements SigningMethod, the key is a *rsa.PrivateKey.
+func (m *MethodRSA) Sign(signingString string, key any) ([]byte, error) {
+	priv, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, ErrInvalidKeyType
+	}
+	if priv == nil || priv.N == nil {
+		return nil, ErrInvalidKey
+	}
+	if !m.Hash.Available() {
+		return nil, ErrHashUnavailable
+	}
+	h := m.Hash.New()
+	h.Write([]byte(signingString))
+	if m.PSS {
+		return rsa.SignPSS(rand.Reader, priv, m.Hash, h.Sum(nil), &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+	}
+	return rsa.SignPKCS1v15(rand.Reader, priv, m.Hash, h.Sum(nil))
+}
+
+// Verify implements SigningMethod, the key is a *rsa.PublicKey.
+func (m *MethodRSA) Verify(signingString string, sig []byte, key any) error {
+	pub, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return ErrInvalidKeyType
+	}
+	if pub == nil || pub.N == nil {
+		return ErrInvalidKey
+	}
+	if !m.Hash.Available() {
+		return ErrHashUnavailable
+	}
+	h := m.Hash.New()
+	h.Write([]byte(signingString))
+	var err error
+	if m.PSS {
+		err = rsa.VerifyPSS(pub, m.Hash, h.Sum(nil), sig, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthAuto})
+	} else {
+		err = rsa.VerifyPKCS1v15(pub, m.Hash, h.Sum(nil), sig)
+	}
+	if err != nil {
+		return ErrSignatureInvalid
+	}
+	return nil
+}
+
+func (m *MethodRSA) Alg() string {
+	return m.Name
+}
@@ -2,24 +2,65 @@ package jwt
 
 import (
 	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/hmac"
+	"crypto/rsa"
 	"sync"
+
+	"github.com/yates-z/easel/utils/crypto/sm/sm2"
 )
 
 var signingMethods = map[string]func() SigningMethod{
-	"HS256": NewMethodHS256,
-	"HS384": NewMethodHS384,
-	"HS512": NewMethodHS512,
+	"HS256":  NewMethodHS256,
+	"HS384":  NewMethodHS384,
+	"HS512":  NewMethodHS512,
+	"RS256":  NewMethodRS256,
+	"RS384":  NewMethodRS384,
+	"RS512":  NewMethodRS512,
+	"PS256":  NewMethodPS256,
+	"PS384":  NewMethodPS384,
+	"PS512":  NewMethodPS512,
+	"ES256":  NewMethodES256,
+	"ES384":  NewMethodES384,
+	"ES512":  NewMethodES512,
+	"EdDSA":  NewMethodEdDSA,
+	"SM2SM3": NewMethodSM2SM3,
 }
 var signingMethodLock = new(sync.RWMutex)
 
 // SigningMethod is a method for signing a token.
+//
+// Each method accepts its own key types, e.g. []byte for HMAC and
+// *rsa.PrivateKey / *rsa.PublicKey for RSA, and fails with
+// ErrInvalidKeyType otherwise. So a token can't be verified with a method it
+// was not meant for, e.g. an HS256 token against an RSA public key.
 type SigningMethod interface {
-	Verify(signingString string, sig []byte, key []byte) error
-	Sign(signingString string, key []byte) ([]byte, error)
+	Verify(signingString string, sig []byte, key any) error
+	Sign(signingString string, key any) ([]byte, error)
 	Alg() string
 }
 
+// PrivateKey is the private key of an asymmetric signing method.
+type PrivateKey interface {
+	*rsa.PrivateKey | *ecdsa.PrivateKey | ed25519.PrivateKey | *sm2.PrivateKey
+}
+
+// PublicKey is the public key of an asymmetric signing method.
+type PublicKey interface {
+	*rsa.PublicKey | *ecdsa.PublicKey | ed25519.PublicKey | *sm2.PublicKey
+}
+
+// SigningKey is a key signing tokens, the secret of HMAC or a private key.
+type SigningKey interface {
+	[]byte | PrivateKey
+}
+
+// VerifyingKey is a key verifying tokens, the secret of HMAC or a public key.
+type VerifyingKey interface {
+	[]byte | PublicKey
+}
+
 // RegisterSigningMethod registers a signing method.
 func RegisterSigningMethod(alg string, f func() SigningMethod) {
 	signingMethodLock.Lock()
@@ -70,21 +111,29 @@ func NewMethodHS512() SigningMethod {
 	}
 }
 
-// Sign implements SigningMethod.
-func (m *MethodHMAC) Sign(signingString string, key []byte) ([]byte, error) {
-	h := hmac.New(m.Hash.New, key)
+// Sign implements SigningMethod, the key is a []byte.
+func (m *MethodHMAC) Sign(signingString string, key any) ([]byte, error) {
+	secret, ok := key.([]byte)
+	if !ok {
+		return nil, ErrInvalidKeyType
+	}
+	h := hmac.New(m.Hash.New, secret)
 	h.Write([]byte(signingString))
 	return h.Sum(nil), nil
 }
 
-// Verify implements SigningMethod.
-func (m *MethodHMAC) Verify(signingString string, sig []byte, key []byte) error {
+// Verify implements SigningMethod, the key is a []byte.
+func (m *MethodHMAC) Verify(signingString string, sig []byte, key any) error {
+	secret, ok := key.([]byte)
+	if !ok {
+		return ErrInvalidKeyType
+	}
 	// Can we use the specified hashing method?
 	if !m.Hash.Available() {
 		return ErrHashUnavailable
 	}
 
-	h := hmac.New(m.Hash.New, key)
+	h := hmac.New(m.Hash.New, secret)
 	h.Write([]byte(signingString))
 	if !hmac.Equal(sig, h.Sum(nil)) {
 		return ErrSignatureInvalid
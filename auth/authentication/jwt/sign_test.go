@@ -0,0 +1,115 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/yates-z/easel/utils/crypto/sm/sm2"
+)
+
+func TestSigningMethods(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey := func(c elliptic.Curve) *ecdsa.PrivateKey {
+		key, err := ecdsa.GenerateKey(c, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return key
+	}
+	edPub, edPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	smPriv, smPub, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p256, p384, p521 := ecKey(elliptic.P256()), ecKey(elliptic.P384()), ecKey(elliptic.P521())
+
+	for _, tc := range []struct {
+		alg             string
+		signKey, verKey any
+	}{
+		{"HS256", []byte("secret"), []byte("secret")},
+		{"RS256", rsaKey, &rsaKey.PublicKey},
+		{"RS512", rsaKey, &rsaKey.PublicKey},
+		{"PS256", rsaKey, &rsaKey.PublicKey},
+		{"ES256", p256, &p256.PublicKey},
+		{"ES384", p384, &p384.PublicKey},
+		{"ES512", p521, &p521.PublicKey},
+		{"EdDSA", edPriv, edPub},
+		{"SM2SM3", smPriv, smPub},
+	} {
+		method := func() SigningMethod { return GetSigningMethod(tc.alg) }
+		raw, err := NewToken(method, Payload{Sub: "easel", Exp: time.Now().Add(time.Hour).Unix()}).sign(tc.signKey)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.alg, err)
+		}
+		token, err := FromToken(raw)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.alg, err)
+		}
+		if token.Header.Alg != tc.alg || token.validate(tc.verKey) != nil {
+			t.Fatalf("%s: token does not verify", tc.alg)
+		}
+		// a tampered token.
+		token.Signature[0] ^= 0xff
+		if token.validate(tc.verKey) == nil {
+			t.Fatalf("%s: tampered signature verifies", tc.alg)
+		}
+	}
+}
+
+func TestInvalidKeyType(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// an HS256 token must not verify against an RSA public key.
+	if err := NewMethodHS256().Verify("a.b", nil, &rsaKey.PublicKey); !errors.Is(err, ErrInvalidKeyType) {
+		t.Fatalf("expected ErrInvalidKeyType, got %v", err)
+	}
+	if _, err := NewMethodRS256().Sign("a.b", []byte("secret")); !errors.Is(err, ErrInvalidKeyType) {
+		t.Fatalf("expected ErrInvalidKeyType, got %v", err)
+	}
+	p256, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewMethodES384().Sign("a.b", p256); !errors.Is(err, ErrInvalidKey) {
+		t.Fatalf("expected ErrInvalidKey, got %v", err)
+	}
+}
+
+func TestNilKey(t *testing.T) {
+	for _, tc := range []struct {
+		alg             string
+		signKey, verKey any
+	}{
+		{"RS256", (*rsa.PrivateKey)(nil), (*rsa.PublicKey)(nil)},
+		{"PS256", &rsa.PrivateKey{}, &rsa.PublicKey{}},
+		{"ES256", (*ecdsa.PrivateKey)(nil), (*ecdsa.PublicKey)(nil)},
+		{"ES256", &ecdsa.PrivateKey{}, &ecdsa.PublicKey{}},
+		{"SM2SM3", (*sm2.PrivateKey)(nil), (*sm2.PublicKey)(nil)},
+	} {
+		m := GetSigningMethod(tc.alg)
+		if _, err := m.Sign("a.b", tc.signKey); !errors.Is(err, ErrInvalidKey) {
+			t.Fatalf("%s: expected ErrInvalidKey, got %v", tc.alg, err)
+		}
+		if err := m.Verify("a.b", make([]byte, 64), tc.verKey); !errors.Is(err, ErrInvalidKey) {
+			t.Fatalf("%s: expected ErrInvalidKey, got %v", tc.alg, err)
+		}
+	}
+	if _, err := NewJWK("kid", "RS256", (*rsa.PublicKey)(nil)); !errors.Is(err, ErrInvalidKey) {
+		t.Fatalf("expected ErrInvalidKey, got %v", err)
+	}
+}
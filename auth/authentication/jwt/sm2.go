@@ -0,0 +1,67 @@
+package jwt
+
+import (
+	"math/big"
+
+	"github.com/yates-z/easel/utils/crypto/sm/sm2"
+)
+
+var _ SigningMethod = (*MethodSM2)(nil)
+
+// MethodSM2 signs with SM2 over the SM3 digest, as specified by GM/T 0003,
+// the signature is the fixed size R || S. It signs with a *sm2.PrivateKey
+// and verifies with a *sm2.PublicKey.
+type MethodSM2 struct {
+	Name string
+	// UserID is the signer identity hashed into the digest, the default
+	// "1234567812345678" of GM/T 0009 if nil.
+	UserID []byte
+}
+
+// NewMethodSM2SM3 creates a new SM2 signing method using SM3.
+func NewMethodSM2SM3() SigningMethod {
+	return &MethodSM2{Name: "SM2SM3"}
+}
+
+// Sign implements SigningMethod, the key is a *sm2.PrivateKey.
+func (m *MethodSM2) Sign(signingString string, key any) ([]byte, error) {
+	priv, ok := key.(*sm2.PrivateKey)
+	if !ok {
+		return nil, ErrInvalidKeyType
+	}
+	if priv == nil || priv.D == nil {
+		return nil, ErrInvalidKey
+	}
+	r, s, err := sm2.SignToRS(priv, m.UserID, []byte(signingString))
+	if err != nil {
+		return nil, err
+	}
+	sig := make([]byte, 2*sm2.KeyBytes)
+	r.FillBytes(sig[:sm2.KeyBytes])
+	s.FillBytes(sig[sm2.KeyBytes:])
+	return sig, nil
+}
+
+// Verify implements SigningMethod, the key is a *sm2.PublicKey.
+func (m *MethodSM2) Verify(signingString string, sig []byte, key any) error {
+	pub, ok := key.(*sm2.PublicKey)
+	if !ok {
+		return ErrInvalidKeyType
+	}
+	if pub == nil || pub.X == nil || pub.Y == nil {
+		return ErrInvalidKey
+	}
+	if len(sig) != 2*sm2.KeyBytes {
+		return ErrSignatureInvalid
+	}
+	r := new(big.Int).SetBytes(sig[:sm2.KeyBytes])
+	s := new(big.Int).SetBytes(sig[sm2.KeyBytes:])
+	if !sm2.VerifyByRS(pub, m.UserID, []byte(signingString), r, s) {
+		return ErrSignatureInvalid
+	}
+	return nil
+}
+
+func (m *MethodSM2) Alg() string {
+	return m.Name
+}
@@ -0,0 +1,105 @@
+package jwt
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+type ValidateOption func(*validator)
+
+type validator struct {
+	now        func() time.Time
+	leeway     time.Duration
+	audience   string
+	issuer     string
+	methods    []string
+	requireExp bool
+}
+
+// WithLeeway with the clock skew tolerated when checking exp, nbf and iat.
+func WithLeeway(leeway time.Duration) ValidateOption {
+	return func(v *validator) {
+		v.leeway = leeway
+	}
+}
+
+// WithAudience requires aud to be one of the audiences of the token.
+func WithAudience(aud string) ValidateOption {
+	return func(v *validator) {
+		v.audience = aud
+	}
+}
+
+// WithIssuer requires the token to be issued by iss.
+func WithIssuer(iss string) ValidateOption {
+	return func(v *validator) {
+		v.issuer = iss
+	}
+}
+
+// WithMethods restricts the signing methods accepted, e.g. "RS256".
+func WithMethods(algs ...string) ValidateOption {
+	return func(v *validator) {
+		v.methods = algs
+	}
+}
+
+// WithoutExpiration accepts tokens without the exp claim.
+func WithoutExpiration() ValidateOption {
+	return func(v *validator) {
+		v.requireExp = false
+	}
+}
+
+// WithTimeFunc with the function returning the current time.
+func WithTimeFunc(now func() time.Time) ValidateOption {
+	return func(v *validator) {
+		v.now = now
+	}
+}
+
+// Validate verifies the signature of t with key, the VerifyingKey of
+// the method, then the registered claims. It returns ErrSignatureInvalid,
+// ErrTokenExpired, ErrTokenNotValidYet, ErrTokenUsedBeforeIssued,
+// ErrAudienceInvalid or ErrIssuerInvalid.
+// A token without exp is invalid unless WithoutExpiration is used.
+func Validate[C Claims, K VerifyingKey](t *Token[C], key K, opts ...ValidateOption) error {
+	return t.validate(key, opts...)
+}
+
+func (t *Token[C]) validate(key any, opts ...ValidateOption) error {
+	v := &validator{now: time.Now, requireExp: true}
+	for _, opt := range opts {
+		opt(v)
+	}
+
+	if len(v.methods) > 0 && !slices.Contains(v.methods, t.Header.Alg) {
+		return ErrMethodNotAllowed
+	}
+	i := strings.LastIndexByte(t.Raw, '.')
+	if i < 0 {
+		return ErrTokenInvalid
+	}
+	if err := t.Method.Verify(t.Raw[:i], t.Signature, key); err != nil {
+		return err
+	}
+
+	claims := t.Claims.Registered()
+	now := v.now()
+	switch {
+	case claims.Exp == 0 && v.requireExp:
+		return ErrExpirationRequired
+	case claims.Exp != 0 && now.After(time.Unix(claims.Exp, 0).Add(v.leeway)):
+		return ErrTokenExpired
+	case claims.Nbf != 0 && now.Add(v.leeway).Before(time.Unix(claims.Nbf, 0)):
+		return ErrTokenNotValidYet
+	case claims.Iat != 0 && now.Add(v.leeway).Before(time.Unix(claims.Iat, 0)):
+		return ErrTokenUsedBeforeIssued
+	case v.audience != "" && !claims.Aud.Contains(v.audience):
+		return ErrAudienceInvalid
+	case v.issuer != "" && claims.Iss != v.issuer:
+		return ErrIssuerInvalid
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+type userClaims struct {
+	RegisteredClaims
+	Roles []string `json:"roles"`
+}
+
+func TestCustomClaims(t *testing.T) {
+	key := []byte("secret")
+	raw, err := Sign(NewToken(NewMethodHS256, userClaims{
+		RegisteredClaims: RegisteredClaims{Sub: "alice", Aud: Audience{"api"}, Exp: time.Now().Add(time.Hour).Unix()},
+		Roles:            []string{"admin"},
+	}), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := Parse[userClaims](raw, StaticKey(key), WithAudience("api"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.Claims.Sub != "alice" || len(token.Claims.Roles) != 1 || token.Claims.Roles[0] != "admin" {
+		t.Fatalf("unexpected claims %+v", token.Claims)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	key := []byte("secret")
+	now := time.Unix(1700000000, 0)
+	newToken := func(claims RegisteredClaims) *Token[Payload] {
+		raw, err := Sign(NewToken(NewMethodHS256, claims), key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		token, err := FromToken(raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	exp := now.Add(time.Hour).Unix()
+	for _, tc := range []struct {
+		name   string
+		claims RegisteredClaims
+		opts   []ValidateOption
+		want   error
+	}{
+		{"valid", RegisteredClaims{Exp: exp}, nil, nil},
+		{"expired", RegisteredClaims{Exp: now.Add(-time.Minute).Unix()}, nil, ErrTokenExpired},
+		{"expired within leeway", RegisteredClaims{Exp: now.Add(-time.Minute).Unix()}, []ValidateOption{WithLeeway(2 * time.Minute)}, nil},
+		{"no expiration", RegisteredClaims{}, nil, ErrExpirationRequired},
+		{"no expiration allowed", RegisteredClaims{}, []ValidateOption{WithoutExpiration()}, nil},
+		{"not valid yet", RegisteredClaims{Exp: exp, Nbf: now.Add(time.Minute).Unix()}, nil, ErrTokenNotValidYet},
+		{"nbf within leeway", RegisteredClaims{Exp: exp, Nbf: now.Add(time.Minute).Unix()}, []ValidateOption{WithLeeway(time.Minute)}, nil},
+		{"issued in the future", RegisteredClaims{Exp: exp, Iat: now.Add(time.Minute).Unix()}, nil, ErrTokenUsedBeforeIssued},
+		{"audience", RegisteredClaims{Exp: exp, Aud: Audience{"a", "b"}}, []ValidateOption{WithAudience("b")}, nil},
+		{"wrong audience", RegisteredClaims{Exp: exp, Aud: Audience{"a"}}, []ValidateOption{WithAudience("b")}, ErrAudienceInvalid},
+		{"issuer", RegisteredClaims{Exp: exp, Iss: "easel"}, []ValidateOption{WithIssuer("easel")}, nil},
+		{"wrong issuer", RegisteredClaims{Exp: exp, Iss: "other"}, []ValidateOption{WithIssuer("easel")}, ErrIssuerInvalid},
+		{"method not allowed", RegisteredClaims{Exp: exp}, []ValidateOption{WithMethods("RS256")}, ErrMethodNotAllowed},
+	} {
+		opts := append([]ValidateOption{WithTimeFunc(func() time.Time { return now })}, tc.opts...)
+		if err := Validate(newToken(tc.claims), key, opts...); !errors.Is(err, tc.want) {
+			t.Errorf("%s: Validate() = %v, want %v", tc.name, err, tc.want)
+		}
+	}
+	if err := Validate(newToken(RegisteredClaims{Exp: exp}), []byte("other"), WithTimeFunc(func() time.Time { return now })); !errors.Is(err, ErrSignatureInvalid) {
+		t.Errorf("Validate() with the wrong key = %v, want ErrSignatureInvalid", err)
+	}
+}
+
+func TestAudienceJSON(t *testing.T) {
+	var claims RegisteredClaims
+	token := NewToken(NewMethodHS256, RegisteredClaims{Aud: Audience{"api"}, Exp: 1})
+	raw, err := Sign(token, []byte("k"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := FromToken(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !parsed.Claims.Aud.Contains("api") {
+		t.Fatalf("unexpected audience %v", parsed.Claims.Aud)
+	}
+	for data, want := range map[string]int{`{"aud":"a"}`: 1, `{"aud":["a","b"]}`: 2} {
+		claims = RegisteredClaims{}
+		if err := json.Unmarshal([]byte(data), &claims); err != nil || len(claims.Aud) != want {
+			t.Fatalf("%s: unexpected audience %v, %v", data, claims.Aud, err)
+		}
+	}
+}
+
+func TestKeySet(t *testing.T) {
+	oldPub, oldPriv, _ := ed25519.GenerateKey(rand.Reader)
+	newPub, newPriv, _ := ed25519.GenerateKey(rand.Reader)
+	keys := NewKeySet()
+	AddKey(keys, "old", "EdDSA", oldPub)
+
+	sign := func(kid string, key ed25519.PrivateKey) string {
+		token := NewToken(NewMethodEdDSA, Payload{Sub: "alice", Exp: time.Now().Add(time.Hour).Unix()})
+		token.Header.Kid = kid
+		raw, err := Sign(token, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return raw
+	}
+	live := sign("old", oldPriv)
+
+	// rotate: new tokens are signed by the new key, live tokens still verify.
+	AddKey(keys, "new", "EdDSA", newPub)
+	for _, raw := range []string{live, sign("new", newPriv)} {
+		if _, err := Parse[Payload](raw, keys); err != nil {
+			t.Fatal(err)
+		}
+	}
+	keys.Remove("old")
+	if _, err := Parse[Payload](live, keys); !errors.Is(err, ErrKeyNotFound) {
+		t.Fatalf("expected ErrKeyNotFound, got %v", err)
+	}
+	// a key is only used by its signing method.
+	AddKey(keys, "hmac", "HS256", []byte("secret"))
+	if _, err := Parse[Payload](sign("hmac", newPriv), keys); !errors.Is(err, ErrMethodNotAllowed) {
+		t.Fatalf("expected ErrMethodNotAllowed, got %v", err)
+	}
+}
@@ -0,0 +1,9 @@
+package otp
+
+import "errors"
+
+var (
+	ErrInvalidCode   = errors.New("otp: invalid code")
+	ErrCodeReused    = errors.New("otp: code already used")
+	ErrInvalidSecret = errors.New("otp: invalid secret")
+)
@@ -0,0 +1,43 @@
+package otp
+
+import (
+	"net/url"
+	"strconv"
+)
+
+// HOTP generates and verifies the counter based codes of RFC 4226.
+type HOTP struct {
+	secret []byte
+	opts   *options
+}
+
+// NewHOTP creates an HOTP with a secret shared with the client.
+func NewHOTP(secret []byte, opts ...Option) *HOTP {
+	return &HOTP{secret: secret, opts: newOptions(opts...)}
+}
+
+// Generate returns the code of counter.
+func (h *HOTP) Generate(counter uint64) string {
+	return generate(h.secret, counter, h.opts.digits, h.opts.algorithm)
+}
+
+// Verify checks code against the codes of counter and of the lookAhead
+// following counters, which resynchronizes clients that generated codes
+// without using them. It returns the counter to verify the next code with.
+func (h *HOTP) Verify(code string, counter uint64, lookAhead int) (uint64, error) {
+	if len(code) != h.opts.digits {
+		return counter, ErrInvalidCode
+	}
+	for i := uint64(0); i <= uint64(max(lookAhead, 0)); i++ {
+		if equal(h.Generate(counter+i), code) {
+			return counter + i + 1, nil
+		}
+	}
+	return counter, ErrInvalidCode
+}
+
+// URI returns the otpauth:// URI provisioning the secret and counter in
+// authenticator apps, usually displayed as a QR code.
+func (h *HOTP) URI(issuer, account string, counter uint64) string {
+	return uri("hotp", h.secret, issuer, account, h.opts, url.Values{"counter": {strconv.FormatUint(counter, 10)}})
+}
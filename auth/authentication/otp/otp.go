@@ -0,0 +1,177 @@
+package otp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base32"
+	"encoding/binary"
+	"hash"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yates-z/easel/core/cache"
+)
+
+// Algorithm is the HMAC hash function of the codes.
+type Algorithm string
+
+const (
+	SHA1   Algorithm = "SHA1"
+	SHA256 Algorithm = "SHA256"
+	SHA512 Algorithm = "SHA512"
+)
+
+func (a Algorithm) hash() func() hash.Hash {
+	switch a {
+	case SHA256:
+		return sha256.New
+	case SHA512:
+		return sha512.New
+	default:
+		return sha1.New
+	}
+}
+
+// SecretSize is the size of the secrets generated by NewSecret, the 160 bits
+// recommended by RFC 4226.
+const SecretSize = 20
+
+// NewSecret generates a random secret.
+func NewSecret() ([]byte, error) {
+	secret := make([]byte, SecretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// EncodeSecret encodes a secret in base32, as authenticator apps expect it.
+func EncodeSecret(secret []byte) string {
+	return encoding.EncodeToString(secret)
+}
+
+// DecodeSecret decodes a base32 secret, ignoring case, spaces and padding.
+func DecodeSecret(s string) ([]byte, error) {
+	s = strings.ToUpper(strings.NewReplacer(" ", "", "-", "", "=", "").Replace(s))
+	secret, err := encoding.DecodeString(s)
+	if err != nil || len(secret) == 0 {
+		return nil, ErrInvalidSecret
+	}
+	return secret, nil
+}
+
+type Option func(*options)
+
+type options struct {
+	digits    int
+	algorithm Algorithm
+	period    time.Duration
+	skew      int
+	replay    cache.Cache[string, int64]
+	now       func() time.Time
+}
+
+// Digits with the number of digits of the codes, from 6 to 9, 6 by default.
+func Digits(n int) Option {
+	return func(o *options) {
+		o.digits = min(max(n, 6), 9)
+	}
+}
+
+// WithAlgorithm with the hash function of the codes, SHA1 by default.
+// Most authenticator apps only support SHA1.
+func WithAlgorithm(a Algorithm) Option {
+	return func(o *options) {
+		o.algorithm = a
+	}
+}
+
+// Period with the time step of TOTP codes, 30 seconds by default, in whole
+// seconds and 1 second at least.
+func Period(d time.Duration) Option {
+	return func(o *options) {
+		o.period = max(d.Truncate(time.Second), time.Second)
+	}
+}
+
+// Skew with the number of time steps before and after the current one whose
+// TOTP codes are accepted, 1 by default to tolerate clock drift.
+func Skew(steps int) Option {
+	return func(o *options) {
+		o.skew = steps
+	}
+}
+
+// ReplayCache with the cache storing the time steps of used TOTP codes.
+// The default is an in-memory cache shared by the TOTPs of the process, a
+// shared backend is required when the verifier runs on several instances.
+func ReplayCache(c cache.Cache[string, int64]) Option {
+	return func(o *options) {
+		o.replay = c
+	}
+}
+
+// WithTimeFunc with the function returning the current time, time.Now by default.
+func WithTimeFunc(now func() time.Time) Option {
+	return func(o *options) {
+		o.now = now
+	}
+}
+
+func newOptions(opts ...Option) *options {
+	o := &options{
+		digits:    6,
+		algorithm: SHA1,
+		period:    30 * time.Second,
+		skew:      1,
+		now:       time.Now,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+var powers = [...]uint32{1, 10, 100, 1000, 10000, 100000, 1000000, 10000000, 100000000, 1000000000}
+
+// generate computes the code of counter as specified by RFC 4226.
+func generate(secret []byte, counter uint64, digits int, algorithm Algorithm) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+	mac := hmac.New(algorithm.hash(), secret)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	// dynamic truncation
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:]) & 0x7fffffff
+	code := strconv.FormatUint(uint64(value%powers[digits]), 10)
+	return strings.Repeat("0", digits-len(code)) + code
+}
+
+// equal compares codes in constant time.
+func equal(a, b string) bool {
+	return hmac.Equal([]byte(a), []byte(b))
+}
+
+// uri builds an otpauth:// provisioning URI, as specified by
+// https://github.com/google/google-authenticator/wiki/Key-Uri-Format.
+func uri(typ string, secret []byte, issuer, account string, o *options, params url.Values) string {
+	label := account
+	if issuer != "" {
+		label = issuer + ":" + account
+		params.Set("issuer", issuer)
+	}
+	params.Set("secret", EncodeSecret(secret))
+	params.Set("algorithm", string(o.algorithm))
+	params.Set("digits", strconv.Itoa(o.digits))
+	u := url.URL{Scheme: "otpauth", Host: typ, Path: "/" + label, RawQuery: params.Encode()}
+	return u.String()
+}
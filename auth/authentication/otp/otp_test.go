@@ -0,0 +1,149 @@
+package otp
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHOTP(t *testing.T) {
+	// RFC 4226 appendix D.
+	h := NewHOTP([]byte("12345678901234567890"))
+	for counter, want := range []string{"755224", "287082", "359152", "969429", "338314", "254676", "287922", "162583", "399871", "520489"} {
+		if code := h.Generate(uint64(counter)); code != want {
+			t.Fatalf("counter %d: got %s, want %s", counter, code, want)
+		}
+	}
+
+	next, err := h.Verify("969429", 1, 2)
+	if err != nil || next != 4 {
+		t.Fatalf("unexpected counter %d, error %v", next, err)
+	}
+	if _, err := h.Verify("969429", 4, 2); err != ErrInvalidCode {
+		t.Fatalf("used code accepted: %v", err)
+	}
+	if _, err := h.Verify("338314", 0, 2); err != ErrInvalidCode {
+		t.Fatalf("code beyond the look-ahead accepted: %v", err)
+	}
+}
+
+func TestTOTPVectors(t *testing.T) {
+	// RFC 6238 appendix B.
+	secrets := map[Algorithm]string{
+		SHA1:   "12345678901234567890",
+		SHA256: "12345678901234567890123456789012",
+		SHA512: "1234567890123456789012345678901234567890123456789012345678901234",
+	}
+	for _, tc := range []struct {
+		unix      int64
+		algorithm Algorithm
+		code      string
+	}{
+		{59, SHA1, "94287082"},
+		{59, SHA256, "46119246"},
+		{59, SHA512, "90693936"},
+		{1111111109, SHA1, "07081804"},
+		{1111111111, SHA256, "67062674"},
+		{1234567890, SHA512, "93441116"},
+		{2000000000, SHA1, "69279037"},
+		{20000000000, SHA256, "77737706"},
+	} {
+		totp := NewTOTP([]byte(secrets[tc.algorithm]), Digits(8), WithAlgorithm(tc.algorithm))
+		if code := totp.Generate(time.Unix(tc.unix, 0)); code != tc.code {
+			t.Fatalf("%d %s: got %s, want %s", tc.unix, tc.algorithm, code, tc.code)
+		}
+	}
+}
+
+func TestTOTPVerify(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+	secret, err := NewSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	totp := NewTOTP(secret, WithTimeFunc(func() time.Time { return now }))
+
+	previous := totp.Generate(now.Add(-30 * time.Second))
+	if err := totp.Verify(totp.Generate(now.Add(-90 * time.Second))); err != ErrInvalidCode {
+		t.Fatalf("code outside the skew accepted: %v", err)
+	}
+	if err := totp.Verify(totp.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if err := totp.Verify(totp.Now()); err != ErrCodeReused {
+		t.Fatalf("code replayed: %v", err)
+	}
+	// a code older than the last used one is rejected too.
+	if err := totp.Verify(previous); err != ErrCodeReused {
+		t.Fatalf("previous code accepted after a newer one: %v", err)
+	}
+	now = now.Add(30 * time.Second)
+	if err := totp.Verify(totp.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if err := totp.Verify("12345"); err != ErrInvalidCode {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestURI(t *testing.T) {
+	secret := []byte("12345678901234567890")
+	uri := NewTOTP(secret).URI("Easel", "alice@example.com")
+	want := "otpauth://totp/Easel:alice@example.com?algorithm=SHA1&digits=6&issuer=Easel&period=30&secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	if uri != want {
+		t.Fatalf("got %s, want %s", uri, want)
+	}
+	uri = NewHOTP(secret, Digits(8)).URI("", "alice", 3)
+	if uri != "otpauth://hotp/alice?algorithm=SHA1&counter=3&digits=8&secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ" {
+		t.Fatalf("unexpected uri %s", uri)
+	}
+
+	decoded, err := DecodeSecret("gezd gnbv gy3t qojq gezd gnbv gy3t qojq")
+	if err != nil || string(decoded) != string(secret) {
+		t.Fatalf("unexpected secret %q, error %v", decoded, err)
+	}
+	if _, err := DecodeSecret("1!"); err != ErrInvalidSecret {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, rc, err := NewRecoveryCodes(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != 2 || rc.Remaining() != 2 || len(codes[0]) != recoveryCodeSize+1 {
+		t.Fatalf("unexpected codes %v", codes)
+	}
+	if rc.Use("aaaaa-aaaaa") {
+		t.Fatal("unknown code accepted")
+	}
+	if !rc.Use(strings.ToUpper(codes[1])) {
+		t.Fatal("recovery code rejected")
+	}
+	if rc.Use(codes[1]) || rc.Remaining() != 1 {
+		t.Fatal("recovery code used twice")
+	}
+}
+
+func TestTOTPSharedReplay(t *testing.T) {
+	secret, err := NewSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := NewTOTP(secret).Now()
+	if err := NewTOTP(secret).Verify(code); err != nil {
+		t.Fatal(err)
+	}
+	// another TOTP of the same secret sees the used code
+	if err := NewTOTP(secret).Verify(code); err != ErrCodeReused {
+		t.Fatalf("code replayed: %v", err)
+	}
+}
+
+func TestPeriod(t *testing.T) {
+	totp := NewTOTP([]byte("12345678901234567890"), Period(time.Millisecond))
+	if totp.Generate(time.Unix(59, 0)) != totp.Generate(time.Unix(59, 5e8)) {
+		t.Fatal("the period isn't clamped to a second")
+	}
+}
@@ -0,0 +1,87 @@
+package otp
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"strings"
+)
+
+// recoveryAlphabet has no characters that are easily confused, like 0 and o.
+const recoveryAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+
+// recoveryCodeSize is the number of characters of a recovery code, written
+// in two groups like "k7fq2-mx9ta".
+const recoveryCodeSize = 10
+
+// RecoveryCodes are the hashes of single-use codes authenticating a user who
+// lost the second factor. They are stored instead of the codes, which are
+// only shown to the user when generated. The codes are random, so a fast
+// SHA-256 hash protects them unlike passwords.
+type RecoveryCodes struct {
+	Hashes [][]byte
+}
+
+// NewRecoveryCodes generates n recovery codes, and returns them with their hashes.
+func NewRecoveryCodes(n int) ([]string, *RecoveryCodes, error) {
+	codes := make([]string, n)
+	rc := &RecoveryCodes{Hashes: make([][]byte, n)}
+	for i := range codes {
+		code, err := newRecoveryCode()
+		if err != nil {
+			return nil, nil, err
+		}
+		hash := sha256.Sum256([]byte(normalizeRecoveryCode(code)))
+		codes[i], rc.Hashes[i] = code, hash[:]
+	}
+	return codes, rc, nil
+}
+
+func newRecoveryCode() (string, error) {
+	b := make([]byte, recoveryCodeSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	for i, c := range b {
+		if i == recoveryCodeSize/2 {
+			sb.WriteByte('-')
+		}
+		// the modulo bias of 256 % 31 costs less than a bit of entropy per code.
+		sb.WriteByte(recoveryAlphabet[int(c)%len(recoveryAlphabet)])
+	}
+	return sb.String(), nil
+}
+
+// normalizeRecoveryCode ignores the case, spaces and dashes users type.
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
+
+// Use consumes code: it reports whether code is one of the recovery codes,
+// which is then removed so that it can't be used again. The recovery codes
+// must be saved after a successful Use.
+func (rc *RecoveryCodes) Use(code string) bool {
+	code = normalizeRecoveryCode(code)
+	if len(code) != recoveryCodeSize {
+		return false
+	}
+	sum := sha256.Sum256([]byte(code))
+	used := -1
+	// compares all the hashes, so that the time doesn't tell which one matches
+	for i, hash := range rc.Hashes {
+		if subtle.ConstantTimeCompare(sum[:], hash) == 1 {
+			used = i
+		}
+	}
+	if used < 0 {
+		return false
+	}
+	rc.Hashes = append(rc.Hashes[:used], rc.Hashes[used+1:]...)
+	return true
+}
+
+// Remaining returns the number of unused recovery codes.
+func (rc *RecoveryCodes) Remaining() int {
+	return len(rc.Hashes)
+}
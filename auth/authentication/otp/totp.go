@@ -0,0 +1,92 @@
+package otp
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/yates-z/easel/core/cache"
+)
+
+// TOTP generates and verifies the time based codes of RFC 6238.
+type TOTP struct {
+	HOTP
+	// id identifies the secret in the replay cache without revealing it.
+	id string
+}
+
+// defaultReplay is the replay cache of the TOTPs created without
+// ReplayCache, shared so that a code is accepted once whichever TOTP
+// verifies it.
+var defaultReplay = sync.OnceValue(func() cache.Cache[string, int64] {
+	return cache.NewMemCache[string, int64](16, 1<<16, time.Minute)
+})
+
+// NewTOTP creates a TOTP with a secret shared with the client.
+func NewTOTP(secret []byte, opts ...Option) *TOTP {
+	o := newOptions(opts...)
+	if o.replay == nil {
+		o.replay = defaultReplay()
+	}
+	sum := sha256.Sum256(secret)
+	return &TOTP{HOTP: HOTP{secret: secret, opts: o}, id: hex.EncodeToString(sum[:16])}
+}
+
+// step returns the time step of t.
+func (t *TOTP) step(at time.Time) int64 {
+	return at.Unix() / int64(t.opts.period/time.Second)
+}
+
+// Generate returns the code at a time.
+func (t *TOTP) Generate(at time.Time) string {
+	return t.HOTP.Generate(uint64(t.step(at)))
+}
+
+// Now returns the current code.
+func (t *TOTP) Now() string {
+	return t.Generate(t.opts.now())
+}
+
+// Verify checks code against the codes of the current time step and of the
+// time steps within the skew. A code is accepted once: the codes of its time
+// step and of the previous ones are rejected with ErrCodeReused afterwards.
+func (t *TOTP) Verify(code string) error {
+	if len(code) != t.opts.digits {
+		return ErrInvalidCode
+	}
+	current := t.step(t.opts.now())
+	for i := -t.opts.skew; i <= t.opts.skew; i++ {
+		step := current + int64(i)
+		if step < 0 || !equal(t.HOTP.Generate(uint64(step)), code) {
+			continue
+		}
+		return t.use(step)
+	}
+	return ErrInvalidCode
+}
+
+// use records the time step of an accepted code, until no code of it can be
+// accepted anymore.
+func (t *TOTP) use(step int64) error {
+	// the code of a step is accepted until skew steps after it.
+	ttl := time.Duration(2*t.opts.skew+1) * t.opts.period
+	if last, ok := t.opts.replay.Get(t.id); ok && step <= last {
+		return ErrCodeReused
+	}
+	// GetOrSet rejects concurrent verifications of the same code.
+	if _, loaded, err := t.opts.replay.GetOrSet(t.id+":"+strconv.FormatInt(step, 10), step, ttl); err != nil {
+		return err
+	} else if loaded {
+		return ErrCodeReused
+	}
+	return t.opts.replay.Set(t.id, step, ttl)
+}
+
+// URI returns the otpauth:// URI provisioning the secret in authenticator
+// apps, usually displayed as a QR code.
+func (t *TOTP) URI(issuer, account string) string {
+	return uri("totp", t.secret, issuer, account, t.opts, url.Values{"period": {strconv.Itoa(int(t.opts.period / time.Second))}})
+}
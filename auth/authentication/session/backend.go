@@ -1,6 +1,7 @@
 package session
 
 import (
+	"sync"
 	"time"
 
 	"github.com/yates-z/easel/core/cache"
@@ -18,37 +19,77 @@ type SessionBackend interface {
 	Exists(sessionID string) bool
 }
 
-var _ SessionBackend = (*CacheSessionBackend)(nil)
+// Rotator is implemented by backends able to rotate a session atomically.
+type Rotator interface {
+	// Rotate saves session under its new ID and, at once, replaces the
+	// session oldID by an alias to it, kept for grace, or deletes it if
+	// grace is not positive. It fails with ErrSessionRotated if oldID was
+	// rotated already, so that concurrent rotations can't fork a session.
+	Rotate(oldID string, session *Session, ttl, grace time.Duration) error
+}
+
+var (
+	_ SessionBackend = (*CacheSessionBackend)(nil)
+	_ Rotator        = (*CacheSessionBackend)(nil)
+)
 
 // CacheSessionBackend implements SessionBackend using a cache backend.
+// Sessions are stored encoded by the codec, so every Load returns a copy.
 type CacheSessionBackend struct {
-	cache cache.Cache[string, *Session]
+	cache cache.Cache[string, []byte]
+	codec Codec
+	// mu serializes writes, so that rotations are atomic. It only guards
+	// this process: instances sharing the cache may still race.
+	mu sync.Mutex
+}
+
+// NewSessionBackend creates a CacheSessionBackend storing sessions in c.
+func NewSessionBackend(c cache.Cache[string, []byte], opts ...BackendOption) *CacheSessionBackend {
+	o := newBackendOptions(opts...)
+	return &CacheSessionBackend{cache: c, codec: o.codec}
+}
+
+// NewCacheSessionBackend creates a new CacheSessionBackend instance keeping
+// sessions in memory.
+func NewCacheSessionBackend(numShards, capacity int, cleanupInterval time.Duration, opts ...BackendOption) *CacheSessionBackend {
+	return NewSessionBackend(cache.NewMemCache[string, []byte](numShards, capacity, cleanupInterval), opts...)
 }
 
-// NewCacheSessionBackend creates a new CacheSessionBackend instance.
-func NewCacheSessionBackend(numShards, capacity int, cleanupInterval time.Duration) *CacheSessionBackend {
-	return &CacheSessionBackend{
-		cache: cache.NewMemCache[string, *Session](numShards, capacity, cleanupInterval),
+// NewFileSessionBackend creates a new CacheSessionBackend instance keeping
+// sessions in files under baseDir, so that they survive restarts.
+func NewFileSessionBackend(numShards int, baseDir string, cleanupInterval time.Duration, opts ...BackendOption) (*CacheSessionBackend, error) {
+	c, err := cache.NewFileCache[string, []byte](numShards, baseDir, cleanupInterval)
+	if err != nil {
+		return nil, err
 	}
+	return NewSessionBackend(c, opts...), nil
 }
 
 func (s *CacheSessionBackend) Save(session *Session, ttl time.Duration) error {
 	if session == nil {
 		return ErrSessionIsNil
 	}
+	data, err := s.codec.Marshal(session)
+	if err != nil {
+		return err
+	}
 
-	return s.cache.Set(session.ID, session, ttl)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cache.Set(session.ID, data, ttl)
 }
 
 func (s *CacheSessionBackend) Load(sessionID string) (*Session, error) {
-	session, ok := s.cache.Get(sessionID)
-	if !ok || session == nil {
+	data, ok := s.cache.Get(sessionID)
+	if !ok || data == nil {
 		return nil, ErrSessionNotFound
 	}
-	return session, nil
+	return s.codec.Unmarshal(data)
 }
 
 func (s *CacheSessionBackend) Delete(sessionID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	s.cache.Delete(sessionID)
 	return nil
 }
@@ -56,3 +97,45 @@ func (s *CacheSessionBackend) Delete(sessionID string) error {
 func (s *CacheSessionBackend) Exists(sessionID string) bool {
 	return s.cache.HasKey(sessionID)
 }
+
+func (s *CacheSessionBackend) Rotate(oldID string, session *Session, ttl, grace time.Duration) error {
+	if session == nil {
+		return ErrSessionIsNil
+	}
+	data, err := s.codec.Marshal(session)
+	if err != nil {
+		return err
+	}
+	var aliasData []byte
+	if grace > 0 {
+		if aliasData, err = s.codec.Marshal(alias(oldID, session.ID, grace)); err != nil {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, err := s.Load(oldID)
+	if err != nil {
+		return err
+	}
+	if old.RotatedTo != "" {
+		return ErrSessionRotated
+	}
+	if err := s.cache.Set(session.ID, data, ttl); err != nil {
+		return err
+	}
+	if grace <= 0 {
+		s.cache.Delete(oldID)
+		return nil
+	}
+	return s.cache.Set(oldID, aliasData, grace)
+}
+
+// Stop stops the cleanup of the cache, if it has one.
+func (s *CacheSessionBackend) Stop() {
+	if c, ok := s.cache.(interface{ Stop() }); ok {
+		c.Stop()
+	}
+}
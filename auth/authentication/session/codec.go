@@ -0,0 +1,81 @@
+package session
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"time"
+)
+
+func init() {
+	// the types JSON values decode to, which session data often holds.
+	gob.Register([]interface{}{})
+	gob.Register(map[string]interface{}{})
+	gob.Register(time.Time{})
+}
+
+// Codec serializes sessions, so that backends hold encoded sessions
+// instead of the live data of requests.
+type Codec interface {
+	Marshal(session *Session) ([]byte, error)
+	Unmarshal(data []byte) (*Session, error)
+}
+
+// GobCodec encodes sessions with encoding/gob. It keeps the types of the
+// session data; types other than the basic ones, []any, map[string]any and
+// time.Time must be registered with gob.Register.
+type GobCodec struct{}
+
+func (GobCodec) Marshal(session *Session) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(session); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (GobCodec) Unmarshal(data []byte) (*Session, error) {
+	session := &Session{}
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// JSONCodec encodes sessions with encoding/json. The session data is
+// decoded as JSON values, e.g. numbers become float64 and slices []any.
+type JSONCodec struct{}
+
+func (JSONCodec) Marshal(session *Session) ([]byte, error) {
+	return json.Marshal(session)
+}
+
+func (JSONCodec) Unmarshal(data []byte) (*Session, error) {
+	session := &Session{}
+	if err := json.Unmarshal(data, session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// BackendOption defines a configuration option for session backends.
+type BackendOption func(*backendOptions)
+
+type backendOptions struct {
+	codec Codec
+}
+
+// WithCodec sets the codec serializing sessions, GobCodec by default.
+func WithCodec(codec Codec) BackendOption {
+	return func(o *backendOptions) {
+		o.codec = codec
+	}
+}
+
+func newBackendOptions(opts ...BackendOption) *backendOptions {
+	o := &backendOptions{codec: GobCodec{}}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
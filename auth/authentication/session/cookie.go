@@ -0,0 +1,95 @@
+package session
+
+import (
+	"encoding/base64"
+	"time"
+
+	"github.com/yates-z/easel/utils/crypto/aes"
+)
+
+// MaxCookieSize is the maximum size of a cookie value browsers accept.
+const MaxCookieSize = 4096
+
+// cookieAdditionalData binds sealed cookies to sessions, so that data sealed
+// with the same keys for another use isn't accepted as a session.
+var cookieAdditionalData = []byte("easel-session")
+
+// CookieStore keeps sessions in encrypted cookies instead of on the server.
+// The cookie value is the session sealed with AES-GCM, so that clients can
+// neither read nor forge it; but a session can't be revoked before it expires.
+type CookieStore struct {
+	keyring *aes.Keyring
+	codec   Codec
+	ttl     time.Duration
+}
+
+// NewCookieStore creates a CookieStore sealing sessions with keyring, which
+// expire ttl after they are last saved.
+func NewCookieStore(keyring *aes.Keyring, ttl time.Duration, opts ...BackendOption) *CookieStore {
+	o := newBackendOptions(opts...)
+	return &CookieStore{keyring: keyring, codec: o.codec, ttl: ttl}
+}
+
+// TTL returns the time sessions live after they are last encoded.
+func (s *CookieStore) TTL() time.Duration {
+	return s.ttl
+}
+
+// New creates an empty session.
+func (s *CookieStore) New() *Session {
+	now := time.Now()
+	return &Session{
+		ID:        defaultSessionIDGenerator(),
+		ExpiresAt: now.Add(s.ttl),
+		Data:      make(map[string]interface{}),
+		RotatedAt: now,
+	}
+}
+
+// Encode renews the expiration of session and seals it into a cookie value.
+func (s *CookieStore) Encode(session *Session) (string, error) {
+	if session == nil {
+		return "", ErrSessionIsNil
+	}
+	session.ExpiresAt = time.Now().Add(s.ttl)
+	data, err := s.codec.Marshal(session)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := s.keyring.Seal(data, cookieAdditionalData)
+	if err != nil {
+		return "", err
+	}
+	value := base64.RawURLEncoding.EncodeToString(sealed)
+	if len(value) > MaxCookieSize {
+		return "", ErrCookieTooLarge
+	}
+	return value, nil
+}
+
+// Decode opens a cookie value sealed by Encode under any key of the keyring.
+func (s *CookieStore) Decode(value string) (*Session, error) {
+	sealed, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, ErrInvalidSessionID
+	}
+	data, err := s.keyring.Open(sealed, cookieAdditionalData)
+	if err != nil {
+		return nil, ErrInvalidSessionID
+	}
+	session, err := s.codec.Unmarshal(data)
+	if err != nil {
+		return nil, ErrInvalidSessionID
+	}
+	if time.Now().After(session.ExpiresAt) {
+		return nil, ErrSessionExpired
+	}
+	return session, nil
+}
+
+// Stale reports whether a cookie value was sealed under an older key, and
+// should be encoded again under the current one.
+func (s *CookieStore) Stale(value string) bool {
+	sealed, err := base64.RawURLEncoding.DecodeString(value)
+	return err == nil && !s.keyring.IsPrimary(sealed)
+}
@@ -7,4 +7,6 @@ var (
 	ErrSessionNotFound  = errors.New("session not found")
 	ErrSessionExpired   = errors.New("session expired")
 	ErrInvalidSessionID = errors.New("invalid session ID")
+	ErrSessionRotated   = errors.New("session already rotated")
+	ErrCookieTooLarge   = errors.New("session cookie too large")
 )
@@ -28,6 +28,10 @@ type SessionManager struct {
 	onUpdate func(sessionID string, key string)
 	// Hook for session deletion
 	onDestroy func(sessionID string)
+	// Hook for session rotation
+	onRotate func(oldSessionID, newSessionID string)
+	// Time a rotated session ID still resolves
+	rotationGrace time.Duration
 }
 
 // SessionManagerOption defines a configuration option for SessionManager.
@@ -75,6 +79,23 @@ func WithDestroyHook(hook func(sessionID string)) SessionManagerOption {
 	}
 }
 
+// WithRotateHook sets the on-rotate hook.
+func WithRotateHook(hook func(oldSessionID, newSessionID string)) SessionManagerOption {
+	return func(sm *SessionManager) {
+		sm.onRotate = hook
+	}
+}
+
+// WithRotationGrace sets the time a rotated session ID still resolves to
+// the new session, so that requests in flight with the old ID don't fail.
+// It's 0 by default; keep it to a few seconds, as the old ID stays usable
+// by whoever holds it during the window.
+func WithRotationGrace(grace time.Duration) SessionManagerOption {
+	return func(sm *SessionManager) {
+		sm.rotationGrace = grace
+	}
+}
+
 // NewSessionManager creates a new SessionManager instance.
 func NewSessionManager(backend SessionBackend, opts ...SessionManagerOption) *SessionManager {
 	sm := &SessionManager{
@@ -92,11 +113,12 @@ func NewSessionManager(backend SessionBackend, opts ...SessionManagerOption) *Se
 // CreateSession generates a new session with a signed ID.
 func (sm *SessionManager) CreateSession() (string, error) {
 	sessionID := sm.generateSignedSessionID()
-	expireAt := time.Now().Add(sm.sessionTTL)
+	now := time.Now()
 	session := &Session{
 		ID:        sessionID,
-		ExpiresAt: expireAt,
+		ExpiresAt: now.Add(sm.sessionTTL),
 		Data:      make(map[string]interface{}),
+		RotatedAt: now,
 	}
 	err := sm.backend.Save(session, sm.sessionTTL)
 	if err != nil {
@@ -139,11 +161,25 @@ func (sm *SessionManager) GetSession(sessionID string) (*Session, error) {
 		return nil, err
 	}
 
-	session, err := sm.backend.Load(sessionID)
-	if err != nil {
-		return nil, err
+	return sm.load(sessionID)
+}
+
+// maxRotations bounds the aliases followed when loading a rotated session ID.
+const maxRotations = 4
+
+// load loads a session, following the aliases of rotated session IDs.
+func (sm *SessionManager) load(sessionID string) (*Session, error) {
+	for range maxRotations {
+		session, err := sm.backend.Load(sessionID)
+		if err != nil {
+			return nil, err
+		}
+		if session.RotatedTo == "" {
+			return session, nil
+		}
+		sessionID = session.RotatedTo
 	}
-	return session, nil
+	return nil, ErrSessionNotFound
 }
 
 func (sm *SessionManager) UpdateSession(sessionID string, key string, value interface{}) error {
@@ -159,6 +195,24 @@ func (sm *SessionManager) UpdateSession(sessionID string, key string, value inte
 	return sm.backend.Save(session, sm.sessionTTL)
 }
 
+// SaveSession renews the expiration of a session and saves it, e.g. after its
+// data was changed in place.
+func (sm *SessionManager) SaveSession(session *Session) error {
+	if session == nil {
+		return ErrSessionIsNil
+	}
+	if err := sm.VerifySessionID(session.ID); err != nil {
+		return err
+	}
+	session.ExpiresAt = time.Now().Add(sm.sessionTTL)
+	return sm.backend.Save(session, sm.sessionTTL)
+}
+
+// TTL returns the time sessions live after they are last saved.
+func (sm *SessionManager) TTL() time.Duration {
+	return sm.sessionTTL
+}
+
 func (sm *SessionManager) DestroySession(sessionID string) error {
 	// Verify the session ID
 	if err := sm.VerifySessionID(sessionID); err != nil {
@@ -167,6 +221,12 @@ func (sm *SessionManager) DestroySession(sessionID string) error {
 	if sm.onDestroy != nil {
 		sm.onDestroy(sessionID)
 	}
+	// destroying a rotated ID destroys the session it was rotated to.
+	if session, err := sm.backend.Load(sessionID); err == nil && session.RotatedTo != "" {
+		if err := sm.DestroySession(session.RotatedTo); err != nil {
+			return err
+		}
+	}
 	return sm.backend.Delete(sessionID)
 }
 
@@ -194,6 +254,10 @@ as changing passwords or making payments.
 
 Regular rotation
 Regularly rotate session IDs to reduce the risk of session IDs being brute force cracked or stolen.
+
+The session data is moved to the new ID. If the backend is a Rotator the rotation is atomic;
+otherwise the new session is saved before the old one is removed, so that the session never
+disappears. During the grace window set by WithRotationGrace the old ID resolves to the new session.
 */
 func (sm *SessionManager) RotateSession(oldSessionID string) (string, error) {
 	// Load the existing session
@@ -202,16 +266,34 @@ func (sm *SessionManager) RotateSession(oldSessionID string) (string, error) {
 		return "", err
 	}
 
-	// Create a new session ID
-	newSessionID := sm.generateSignedSessionID()
+	// Copy the session data under a new session ID
+	now := time.Now()
+	rotated := sess.clone(sm.generateSignedSessionID())
+	rotated.ExpiresAt = now.Add(sm.sessionTTL)
+	rotated.RotatedAt = now
 
-	// Save the session data with the new session ID
-	if err := sm.backend.Save(sess, sm.sessionTTL); err != nil {
+	// sess.ID differs from oldSessionID if the latter was rotated already
+	if rotator, ok := sm.backend.(Rotator); ok {
+		err = rotator.Rotate(sess.ID, rotated, sm.sessionTTL, sm.rotationGrace)
+	} else {
+		err = sm.rotate(sess.ID, rotated)
+	}
+	if err != nil {
 		return "", err
 	}
+	if sm.onRotate != nil {
+		sm.onRotate(sess.ID, rotated.ID)
+	}
+	return rotated.ID, nil
+}
 
-	// Delete the old session
-	sm.backend.Delete(oldSessionID)
-
-	return newSessionID, nil
+// rotate rotates a session on backends which are not a Rotator.
+func (sm *SessionManager) rotate(oldSessionID string, rotated *Session) error {
+	if err := sm.backend.Save(rotated, sm.sessionTTL); err != nil {
+		return err
+	}
+	if sm.rotationGrace <= 0 {
+		return sm.backend.Delete(oldSessionID)
+	}
+	return sm.backend.Save(alias(oldSessionID, rotated.ID, sm.rotationGrace), sm.rotationGrace)
 }
@@ -12,4 +12,23 @@ type Session struct {
 	ExpiresAt time.Time
 	// Data stored in the session
 	Data map[string]interface{}
+	// Time the session ID was issued, by creation or rotation
+	RotatedAt time.Time
+	// ID of the session this ID was rotated to. A rotated ID is kept
+	// for the grace window only and resolves to the new session.
+	RotatedTo string
+}
+
+// clone returns a copy of the session under a new ID.
+func (s *Session) clone(id string) *Session {
+	data := make(map[string]interface{}, len(s.Data))
+	for k, v := range s.Data {
+		data[k] = v
+	}
+	return &Session{ID: id, ExpiresAt: s.ExpiresAt, Data: data, RotatedAt: s.RotatedAt}
+}
+
+// alias returns the session kept under a rotated ID for the grace window.
+func alias(id, rotatedTo string, grace time.Duration) *Session {
+	return &Session{ID: id, ExpiresAt: time.Now().Add(grace), RotatedTo: rotatedTo}
 }
@@ -54,3 +54,105 @@ func TestSessionManager(t *testing.T) {
 		fmt.Println("Session destroyed")
 	}
 }
+
+// backend hides the Rotator implementation of the cache backend.
+type backend struct {
+	SessionBackend
+}
+
+func TestRotateSession(t *testing.T) {
+	for name, b := range map[string]SessionBackend{
+		"rotator": NewCacheSessionBackend(1, 10, time.Minute),
+		"generic": backend{NewCacheSessionBackend(1, 10, time.Minute)},
+	} {
+		var rotated [2]string
+		sm := NewSessionManager(b, WithRotateHook(func(oldID, newID string) {
+			rotated = [2]string{oldID, newID}
+		}))
+		oldID, err := sm.CreateSession()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := sm.UpdateSession(oldID, "user", "easel"); err != nil {
+			t.Fatal(err)
+		}
+		newID, err := sm.RotateSession(oldID)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if newID == oldID || rotated != [2]string{oldID, newID} {
+			t.Fatalf("%s: unexpected rotation %v", name, rotated)
+		}
+		sess, err := sm.GetSession(newID)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if sess.ID != newID || sess.Data["user"] != "easel" {
+			t.Fatalf("%s: unexpected session %+v", name, sess)
+		}
+		if _, err := sm.GetSession(oldID); err != ErrSessionNotFound {
+			t.Fatalf("%s: old session ID still resolves: %v", name, err)
+		}
+	}
+}
+
+func TestRotateSessionGrace(t *testing.T) {
+	sm := NewSessionManager(NewCacheSessionBackend(1, 10, time.Minute), WithRotationGrace(50*time.Millisecond))
+	oldID, _ := sm.CreateSession()
+	newID, err := sm.RotateSession(oldID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sess, err := sm.GetSession(oldID)
+	if err != nil || sess.ID != newID {
+		t.Fatalf("old session ID doesn't resolve within the grace window: %v", err)
+	}
+	if err := sm.UpdateSession(oldID, "user", "easel"); err != nil {
+		t.Fatal(err)
+	}
+	if sess, _ := sm.GetSession(newID); sess.Data["user"] != "easel" {
+		t.Fatal("update through the old session ID is lost")
+	}
+
+	// rotating the old ID again rotates the current session.
+	latestID, err := sm.RotateSession(oldID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sess, err := sm.GetSession(oldID); err != nil || sess.ID != latestID {
+		t.Fatalf("old session ID doesn't resolve to the latest session: %v", err)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if _, err := sm.GetSession(oldID); err != ErrSessionNotFound {
+		t.Fatalf("old session ID resolves after the grace window: %v", err)
+	}
+	if _, err := sm.GetSession(latestID); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRotateTwice(t *testing.T) {
+	b := NewCacheSessionBackend(1, 10, time.Minute)
+	sm := NewSessionManager(b)
+	oldID, _ := sm.CreateSession()
+	sess, _ := sm.GetSession(oldID)
+	if err := b.Rotate(oldID, sess.clone(sm.generateSignedSessionID()), time.Minute, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Rotate(oldID, sess.clone(sm.generateSignedSessionID()), time.Minute, time.Minute); err != ErrSessionRotated {
+		t.Fatalf("session rotated twice: %v", err)
+	}
+}
+
+func TestDestroyRotatedSession(t *testing.T) {
+	sm := NewSessionManager(NewCacheSessionBackend(1, 10, time.Minute), WithRotationGrace(time.Minute))
+	oldID, _ := sm.CreateSession()
+	newID, _ := sm.RotateSession(oldID)
+	if err := sm.DestroySession(oldID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sm.GetSession(newID); err != ErrSessionNotFound {
+		t.Fatalf("session survives destroying its rotated ID: %v", err)
+	}
+}
@@ -0,0 +1,102 @@
+package session
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yates-z/easel/utils/crypto/aes"
+)
+
+func TestFileSessionBackend(t *testing.T) {
+	dir := t.TempDir()
+	b, err := NewFileSessionBackend(2, dir, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sm := NewSessionManager(b)
+	id, err := sm.CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sm.UpdateSession(id, "roles", []string{"admin"}); err != nil {
+		t.Fatal(err)
+	}
+	b.Stop()
+
+	// sessions survive a restart.
+	b, err = NewFileSessionBackend(2, dir, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Stop()
+	sess, err := NewSessionManager(b).GetSession(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if roles, ok := sess.Data["roles"].([]string); !ok || len(roles) != 1 || roles[0] != "admin" {
+		t.Fatalf("unexpected session data %v", sess.Data)
+	}
+}
+
+func TestCodec(t *testing.T) {
+	for name, codec := range map[string]Codec{"gob": GobCodec{}, "json": JSONCodec{}} {
+		b := NewCacheSessionBackend(1, 10, time.Minute, WithCodec(codec))
+		sess := &Session{ID: "id", ExpiresAt: time.Now().Add(time.Minute), Data: map[string]interface{}{"user": "easel"}}
+		if err := b.Save(sess, time.Minute); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		// the backend holds a copy, not the live session.
+		sess.Data["user"] = "changed"
+		loaded, err := b.Load("id")
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if loaded.Data["user"] != "easel" || !loaded.ExpiresAt.Equal(sess.ExpiresAt) {
+			t.Fatalf("%s: unexpected session %+v", name, loaded)
+		}
+	}
+}
+
+func TestCookieStore(t *testing.T) {
+	oldKey, newKey := []byte("0123456789abcdef"), []byte("fedcba9876543210")
+	keyring, _ := aes.NewKeyring(oldKey)
+	store := NewCookieStore(keyring, time.Minute)
+
+	sess := store.New()
+	sess.Data["user"] = "easel"
+	value, err := store.Encode(sess)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := store.Decode(value)
+	if err != nil || decoded.ID != sess.ID || decoded.Data["user"] != "easel" {
+		t.Fatalf("unexpected session %+v, error %v", decoded, err)
+	}
+	if store.Stale(value) {
+		t.Fatal("cookie sealed under the primary key is stale")
+	}
+	if _, err := store.Decode(value[:len(value)-2] + "AA"); err != ErrInvalidSessionID {
+		t.Fatalf("tampered cookie decoded: %v", err)
+	}
+
+	// cookies sealed under the previous key are still valid after a rotation.
+	keyring, _ = aes.NewKeyring(newKey, oldKey)
+	rotated := NewCookieStore(keyring, time.Minute)
+	if _, err := rotated.Decode(value); err != nil {
+		t.Fatal(err)
+	}
+	if !rotated.Stale(value) {
+		t.Fatal("cookie sealed under the previous key isn't stale")
+	}
+
+	expired := NewCookieStore(keyring, -time.Minute)
+	value, _ = expired.Encode(sess)
+	if _, err := expired.Decode(value); err != ErrSessionExpired {
+		t.Fatalf("expired cookie decoded: %v", err)
+	}
+
+	sess.Data["blob"] = make([]byte, MaxCookieSize)
+	if _, err := store.Encode(sess); err != ErrCookieTooLarge {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
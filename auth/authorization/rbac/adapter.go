@@ -1,66 +1,389 @@
 package rbac
 
 import (
-	"bufio"
+	"bytes"
 	"encoding/csv"
+	"errors"
+	"io"
+	"io/fs"
 	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
 )
 
+var ErrInvalidRecord = errors.New("rbac: invalid policy record")
+
 // Adapter defines the interface for a persistence adapter.
 type Adapter interface {
-	LoadPolicy() ([]Policy, error)
-	SavePolicy(policies []Policy) error
+	LoadPolicy() ([]Policy, []Grouping, error)
+	SavePolicy(policies []Policy, groupings []Grouping) error
+}
+
+// IncrementalAdapter is an Adapter saving changes without rewriting all the
+// policies.
+type IncrementalAdapter interface {
+	Adapter
+	AddPolicies(policies []Policy, groupings []Grouping) error
+	RemovePolicies(policies []Policy, groupings []Grouping) error
+}
+
+// Watcher reports changes of the policies made outside of an enforcer,
+// e.g. by another process.
+type Watcher interface {
+	// Watch calls update after each change until Close is called.
+	Watch(update func() error) error
+	Close() error
+}
+
+type CSVAdapterOption func(*CSVAdapter)
+
+// PollInterval with the interval the file is checked for changes by Watch,
+// one second by default.
+func PollInterval(d time.Duration) CSVAdapterOption {
+	return func(a *CSVAdapter) {
+		a.interval = d
+	}
 }
 
-// CSVAdapter implements the Adapter interface using a CSV file for persistence.
+// OnWatchError with the function called when the policies can't be
+// reloaded, e.g. when the file is invalid. The update is retried until it
+// succeeds.
+func OnWatchError(f func(error)) CSVAdapterOption {
+	return func(a *CSVAdapter) {
+		a.onError = f
+	}
+}
+
+// CSVAdapter implements the IncrementalAdapter and Watcher interfaces using
+// a CSV file for persistence.
+//
+// Each record is one of:
+//
+//	role, resource, action
+//	p, role, resource, action[, effect[, domain[, condition]]]
+//	g, member, role[, domain]
 type CSVAdapter struct {
 	filePath string
+	interval time.Duration
+	onError  func(error)
+
+	mu sync.Mutex
+	// stat is the file info after the last load or write, so the changes made
+	// by the adapter itself are not reported by Watch.
+	stat  fileStat
+	close chan struct{}
+}
+
+type fileStat struct {
+	modTime time.Time
+	size    int64
 }
 
 // NewCSVAdapter creates a new CSVAdapter with the given file path.
-func NewCSVAdapter(filePath string) *CSVAdapter {
-	return &CSVAdapter{filePath: filePath}
+func NewCSVAdapter(filePath string, opts ...CSVAdapterOption) *CSVAdapter {
+	a := &CSVAdapter{filePath: filePath, interval: time.Second}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
 }
 
-// LoadPolicy loads policies from a CSV file using buffered reading.
-func (a *CSVAdapter) LoadPolicy() ([]Policy, error) {
-	file, err := os.Open(a.filePath)
+// LoadPolicy loads policies from a CSV file.
+func (a *CSVAdapter) LoadPolicy() ([]Policy, []Grouping, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	stat := a.fileStat()
+	data, err := os.ReadFile(a.filePath)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	defer file.Close()
-	// Buffered reader for handling large files.
-	reader := csv.NewReader(bufio.NewReader(file))
-	records, err := reader.ReadAll()
+	var (
+		policies  []Policy
+		groupings []Grouping
+	)
+	err = readRecords(data, func(record []string, _, _ int64) error {
+		p, g, err := parseRecord(record)
+		if p != nil {
+			policies = append(policies, *p)
+		} else if g != nil {
+			groupings = append(groupings, *g)
+		}
+		return err
+	})
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	var policies []Policy
-	for _, record := range records {
-		if len(record) == 3 {
-			policies = append(policies, Policy{
-				Role:     record[0],
-				Resource: record[1],
-				Action:   record[2],
-			})
-		}
+	a.stat = stat
+	return policies, groupings, nil
+}
+
+// SavePolicy saves policies to a CSV file. The file is replaced at once, so
+// readers never see a partial file.
+func (a *CSVAdapter) SavePolicy(policies []Policy, groupings []Grouping) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var buf bytes.Buffer
+	if err := writeRecords(&buf, policies, groupings); err != nil {
+		return err
 	}
-	return policies, nil
+	return a.replace(buf.Bytes())
 }
 
-// SavePolicy saves policies to a CSV file.
-func (a *CSVAdapter) SavePolicy(policies []Policy) error {
-	file, err := os.Create(a.filePath)
+// AddPolicies appends policies and groupings to the CSV file.
+func (a *CSVAdapter) AddPolicies(policies []Policy, groupings []Grouping) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	file, err := os.OpenFile(a.filePath, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0o644)
 	if err != nil {
 		return err
 	}
 	defer file.Close()
-	writer := csv.NewWriter(file)
-	defer writer.Flush()
+
+	var buf bytes.Buffer
+	// the last record may not end with a newline.
+	if info, err := file.Stat(); err != nil {
+		return err
+	} else if info.Size() > 0 {
+		last := make([]byte, 1)
+		if _, err := file.ReadAt(last, info.Size()-1); err != nil {
+			return err
+		}
+		if last[0] != '\n' {
+			buf.WriteByte('\n')
+		}
+	}
+	if err := writeRecords(&buf, policies, groupings); err != nil {
+		return err
+	}
+	// a single write, so the watcher of another process doesn't read half a record.
+	if _, err := file.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	a.stat = a.fileStat()
+	return nil
+}
+
+// RemovePolicies removes policies and groupings from the CSV file. The other
+// records, and the comments before them, are kept as they are.
+func (a *CSVAdapter) RemovePolicies(policies []Policy, groupings []Grouping) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	data, err := os.ReadFile(a.filePath)
+	if err != nil {
+		return err
+	}
+	removed := make(map[any]struct{}, len(policies)+len(groupings))
 	for _, p := range policies {
-		if err := writer.Write([]string{p.Role, p.Resource, p.Action}); err != nil {
+		removed[p] = struct{}{}
+	}
+	for _, g := range groupings {
+		removed[g] = struct{}{}
+	}
+	var (
+		buf  bytes.Buffer
+		last int64
+	)
+	err = readRecords(data, func(record []string, start, end int64) error {
+		p, g, err := parseRecord(record)
+		if err != nil {
 			return err
 		}
+		var key any
+		if p != nil {
+			key = *p
+		} else {
+			key = *g
+		}
+		if _, ok := removed[key]; !ok {
+			buf.Write(data[start:end])
+		}
+		last = end
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	buf.Write(data[last:])
+	return a.replace(buf.Bytes())
+}
+
+// Watch polls the CSV file and calls update when it changes.
+func (a *CSVAdapter) Watch(update func() error) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.close != nil {
+		return errors.New("rbac: csv adapter is already watched")
+	}
+	a.close = make(chan struct{})
+	go a.watch(update, a.close)
+	return nil
+}
+
+// Close stops watching the CSV file.
+func (a *CSVAdapter) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.close != nil {
+		close(a.close)
+		a.close = nil
+	}
+	return nil
+}
+
+func (a *CSVAdapter) watch(update func() error, done chan struct{}) {
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+		a.mu.Lock()
+		changed := a.fileStat() != a.stat
+		a.mu.Unlock()
+		if !changed {
+			continue
+		}
+		// LoadPolicy records the new file info, a failed update is retried.
+		if err := update(); err != nil && a.onError != nil {
+			a.onError(err)
+		}
+	}
+}
+
+func (a *CSVAdapter) fileStat() fileStat {
+	info, err := os.Stat(a.filePath)
+	if err != nil {
+		return fileStat{}
+	}
+	return fileStat{modTime: info.ModTime(), size: info.Size()}
+}
+
+// replace writes data to a temporary file and renames it to the CSV file.
+func (a *CSVAdapter) replace(data []byte) error {
+	mode := fs.FileMode(0o644)
+	if info, err := os.Stat(a.filePath); err == nil {
+		mode = info.Mode().Perm()
+	}
+	file, err := os.CreateTemp(filepath.Dir(a.filePath), filepath.Base(a.filePath)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Chmod(mode); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
 	}
+	if err := os.Rename(file.Name(), a.filePath); err != nil {
+		return err
+	}
+	a.stat = a.fileStat()
 	return nil
 }
+
+// readRecords calls f with each record of data and its byte range, which
+// includes the comments before the record.
+func readRecords(data []byte, f func(record []string, start, end int64) error) error {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+	reader.ReuseRecord = true
+	var start int64
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		end := reader.InputOffset()
+		if err := f(record, start, end); err != nil {
+			return err
+		}
+		start = end
+	}
+}
+
+func parseRecord(record []string) (*Policy, *Grouping, error) {
+	switch {
+	case record[0] == "g" && len(record) >= 3:
+		g := &Grouping{Member: record[1], Role: record[2]}
+		if len(record) > 3 {
+			g.Domain = record[3]
+		}
+		return nil, g, nil
+	case record[0] == "p" && len(record) >= 4:
+		p := &Policy{Role: record[1], Resource: record[2], Action: record[3], Effect: Allow}
+		if len(record) > 4 && record[4] != "" {
+			p.Effect = Effect(strings.ToLower(record[4]))
+		}
+		if len(record) > 5 {
+			p.Domain = record[5]
+		}
+		if len(record) > 6 {
+			p.Condition = record[6]
+		}
+		if p.Effect != Allow && p.Effect != Deny {
+			return nil, nil, ErrInvalidRecord
+		}
+		return p, nil, nil
+	case len(record) == 3:
+		return &Policy{Role: record[0], Resource: record[1], Action: record[2], Effect: Allow}, nil, nil
+	default:
+		return nil, nil, ErrInvalidRecord
+	}
+}
+
+func writeRecords(w io.Writer, policies []Policy, groupings []Grouping) error {
+	writer := csv.NewWriter(w)
+	for _, p := range policies {
+		if err := writer.Write(policyRecord(p)); err != nil {
+			return err
+		}
+	}
+	for _, g := range groupings {
+		if err := writer.Write(groupingRecord(g)); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// policyRecord returns the shortest record of p.
+func policyRecord(p Policy) []string {
+	if p.effect() == Allow && p.Domain == "" && p.Condition == "" && p.Role != "p" && p.Role != "g" {
+		return []string{p.Role, p.Resource, p.Action}
+	}
+	record := []string{"p", p.Role, p.Resource, p.Action, string(p.effect())}
+	if p.Domain != "" || p.Condition != "" {
+		record = append(record, p.Domain)
+	}
+	if p.Condition != "" {
+		record = append(record, p.Condition)
+	}
+	return record
+}
+
+func groupingRecord(g Grouping) []string {
+	record := []string{"g", g.Member, g.Role}
+	if g.Domain != "" {
+		record = append(record, g.Domain)
+	}
+	return record
+}
@@ -0,0 +1,76 @@
+package rbac
+
+import (
+	"context"
+	"strings"
+
+	"github.com/yates-z/easel/logger"
+)
+
+// Decision is the result of an authorization check.
+type Decision struct {
+	Allowed  bool
+	Roles    []string
+	Domain   string
+	Resource string
+	Action   string
+	// Policy is the policy deciding, nil if no policy matched.
+	Policy *Policy
+	// Reason explains the decision.
+	Reason string
+}
+
+// Authorize checks if roles may perform action on resource.
+func (e *Enforcer) Authorize(roles []string, resource, action string) Decision {
+	return e.AuthorizeDomain(roles, "", resource, action)
+}
+
+// AuthorizeDomain checks if roles may perform action on resource in domain.
+func (e *Enforcer) AuthorizeDomain(roles []string, domain, resource, action string) Decision {
+	return e.AuthorizeAttrs(roles, domain, resource, action, Attributes{})
+}
+
+// AuthorizeAttrs checks if roles may perform action on resource in domain,
+// the policy conditions are evaluated over attrs.
+func (e *Enforcer) AuthorizeAttrs(roles []string, domain, resource, action string, attrs Attributes) Decision {
+	d := Decision{Roles: roles, Domain: domain, Resource: resource, Action: action}
+	if len(roles) == 0 {
+		d.Reason = "subject has no roles"
+		return d
+	}
+	var err error
+	d.Policy, err = e.decide(roles, domain, resource, action, &attrs)
+	switch {
+	case d.Policy == nil:
+		d.Reason = "no policy allows roles [" + strings.Join(roles, ",") + "]"
+	case err != nil:
+		d.Reason = "denied by policy " + strings.Join(policyRecord(*d.Policy), ",") + ", its condition failed: " + err.Error()
+	case d.Policy.effect() == Deny:
+		d.Reason = "denied by policy " + strings.Join(policyRecord(*d.Policy), ",")
+	default:
+		d.Allowed = true
+		d.Reason = "allowed by policy " + strings.Join(policyRecord(*d.Policy), ",")
+	}
+	return d
+}
+
+// AuditFunc records an authorization decision.
+type AuditFunc func(ctx context.Context, d Decision)
+
+// LogAudit returns an AuditFunc writing decisions to l.
+func LogAudit(l logger.Logger) AuditFunc {
+	return func(ctx context.Context, d Decision) {
+		fields := []logger.FieldBuilder{
+			logger.String("resource", d.Resource),
+			logger.String("action", d.Action),
+			logger.String("roles", strings.Join(d.Roles, ",")),
+			logger.String("domain", d.Domain),
+			logger.String("reason", d.Reason),
+		}
+		if d.Allowed {
+			l.Context(ctx).Infos("[rbac] allowed", fields...)
+			return
+		}
+		l.Context(ctx).Warns("[rbac] denied", fields...)
+	}
+}
@@ -0,0 +1,44 @@
+package rbac
+
+import "context"
+
+type rolesKey struct{}
+
+// NewContext returns a new Context that carries the roles of the subject.
+func NewContext(ctx context.Context, roles ...string) context.Context {
+	return context.WithValue(ctx, rolesKey{}, roles)
+}
+
+// RolesFromContext returns the roles of the subject stored in ctx.
+func RolesFromContext(ctx context.Context) ([]string, bool) {
+	roles, ok := ctx.Value(rolesKey{}).([]string)
+	return roles, ok
+}
+
+// RoleProvider is implemented by claims carrying the roles of their subject.
+type RoleProvider interface {
+	GetRoles() []string
+}
+
+// RolesOf returns the roles held by v, which is a RoleProvider, a role,
+// or a list of roles as decoded from JSON.
+func RolesOf(v any) []string {
+	switch v := v.(type) {
+	case RoleProvider:
+		return v.GetRoles()
+	case string:
+		return []string{v}
+	case []string:
+		return v
+	case []any:
+		roles := make([]string, 0, len(v))
+		for _, r := range v {
+			if s, ok := r.(string); ok {
+				roles = append(roles, s)
+			}
+		}
+		return roles
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,535 @@
+package rbac
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Attributes are the attributes conditions are evaluated over, they are
+// referenced as subject.name, resource.name and env.name.
+//
+// env.time ("15:04"), env.hour and env.weekday (0 is Sunday) default to the
+// current local time.
+type Attributes struct {
+	Subject  map[string]any
+	Resource map[string]any
+	Env      map[string]any
+}
+
+var (
+	ErrInvalidCondition = errors.New("rbac: invalid condition")
+	ErrConditionEval    = errors.New("rbac: condition can't be evaluated")
+)
+
+// Condition is a compiled condition expression, e.g.
+//
+//	resource.owner == subject.id && env.time >= '09:00' && env.time < '17:00'
+//
+// Expressions support string, number, boolean and null literals, lists
+// ['a', 'b'], attribute paths, the operators == != < <= > >= in, && || !
+// and parentheses. Conditions can't call functions or modify attributes.
+type Condition struct {
+	src  string
+	root node
+}
+
+// CompileCondition compiles an expression.
+func CompileCondition(src string) (*Condition, error) {
+	p := &parser{lex: lexer{src: src}}
+	p.next()
+	root, err := p.parseOr()
+	if err == nil && (p.err != nil || p.tok.kind != tokEOF) {
+		err = p.errorf("unexpected %q", p.tok.text)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q: %v", ErrInvalidCondition, src, err)
+	}
+	return &Condition{src: src, root: root}, nil
+}
+
+// String returns the source of the condition.
+func (c *Condition) String() string {
+	return c.src
+}
+
+// Eval reports whether the condition holds for attrs. It returns an error
+// wrapping ErrConditionEval if the expression can't be evaluated, e.g.
+// referencing a missing attribute, comparing a number with a string or not
+// being a boolean. Such a condition fails closed: an allow policy doesn't
+// apply, and a deny policy does.
+func (c *Condition) Eval(attrs Attributes) (bool, error) {
+	v, err := c.root.eval(&attrs)
+	if err != nil {
+		return false, fmt.Errorf("%w: %q: %v", ErrConditionEval, c.src, err)
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("%w: %q: not a boolean", ErrConditionEval, c.src)
+	}
+	return b, nil
+}
+
+/********************************************/
+/******************* lexer ******************/
+/********************************************/
+
+type tokKind int
+
+const (
+	tokEOF tokKind = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokOp
+)
+
+type token struct {
+	kind tokKind
+	text string
+	pos  int
+}
+
+type lexer struct {
+	src string
+	pos int
+}
+
+func (l *lexer) next() (token, error) {
+	for l.pos < len(l.src) && strings.ContainsRune(" \t\r\n", rune(l.src[l.pos])) {
+		l.pos++
+	}
+	start := l.pos
+	if l.pos >= len(l.src) {
+		return token{kind: tokEOF, pos: start}, nil
+	}
+	c := l.src[l.pos]
+	switch {
+	case c == '\'' || c == '"':
+		var b strings.Builder
+		for l.pos++; l.pos < len(l.src); l.pos++ {
+			switch l.src[l.pos] {
+			case c:
+				l.pos++
+				return token{kind: tokString, text: b.String(), pos: start}, nil
+			case '\\':
+				if l.pos+1 < len(l.src) {
+					l.pos++
+				}
+			}
+			b.WriteByte(l.src[l.pos])
+		}
+		return token{}, fmt.Errorf("unterminated string at %d", start)
+	case c >= '0' && c <= '9' || c == '-' && l.pos+1 < len(l.src) && l.src[l.pos+1] >= '0' && l.src[l.pos+1] <= '9':
+		l.pos++
+		for l.pos < len(l.src) && (l.src[l.pos] >= '0' && l.src[l.pos] <= '9' || l.src[l.pos] == '.') {
+			l.pos++
+		}
+		return token{kind: tokNumber, text: l.src[start:l.pos], pos: start}, nil
+	case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+		for l.pos < len(l.src) && (l.src[l.pos] == '_' || l.src[l.pos] == '.' ||
+			l.src[l.pos] >= 'a' && l.src[l.pos] <= 'z' || l.src[l.pos] >= 'A' && l.src[l.pos] <= 'Z' ||
+			l.src[l.pos] >= '0' && l.src[l.pos] <= '9') {
+			l.pos++
+		}
+		return token{kind: tokIdent, text: l.src[start:l.pos], pos: start}, nil
+	}
+	for _, op := range []string{"==", "!=", "<=", ">=", "&&", "||", "<", ">", "!", "(", ")", "[", "]", ","} {
+		if strings.HasPrefix(l.src[l.pos:], op) {
+			l.pos += len(op)
+			return token{kind: tokOp, text: op, pos: start}, nil
+		}
+	}
+	return token{}, fmt.Errorf("unexpected %q at %d", c, start)
+}
+
+/********************************************/
+/****************** parser ******************/
+/********************************************/
+
+type parser struct {
+	lex lexer
+	tok token
+	err error
+}
+
+func (p *parser) next() {
+	if p.err != nil {
+		return
+	}
+	p.tok, p.err = p.lex.next()
+	if p.err != nil {
+		p.tok = token{kind: tokEOF}
+	}
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	if p.err != nil {
+		return p.err
+	}
+	return fmt.Errorf(format+" at %d", append(args, p.tok.pos)...)
+}
+
+func (p *parser) isOp(op string) bool {
+	return p.tok.kind == tokOp && p.tok.text == op
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	for err == nil && p.isOp("||") {
+		p.next()
+		var right node
+		if right, err = p.parseAnd(); err == nil {
+			left = &logicalNode{or: true, left: left, right: right}
+		}
+	}
+	return left, err
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseNot()
+	for err == nil && p.isOp("&&") {
+		p.next()
+		var right node
+		if right, err = p.parseNot(); err == nil {
+			left = &logicalNode{left: left, right: right}
+		}
+	}
+	return left, err
+}
+
+func (p *parser) parseNot() (node, error) {
+	if p.isOp("!") {
+		p.next()
+		n, err := p.parseNot()
+		return &notNode{n: n}, err
+	}
+	return p.parseCompare()
+}
+
+func (p *parser) parseCompare() (node, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	op := p.tok.text
+	switch {
+	case p.tok.kind == tokOp && slices.Contains([]string{"==", "!=", "<", "<=", ">", ">="}, op):
+	case p.tok.kind == tokIdent && op == "in":
+	default:
+		return left, nil
+	}
+	p.next()
+	right, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	return &compareNode{op: op, left: left, right: right}, nil
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	tok := p.tok
+	switch {
+	case p.err != nil:
+		return nil, p.err
+	case tok.kind == tokString:
+		p.next()
+		return &literalNode{v: tok.text}, nil
+	case tok.kind == tokNumber:
+		f, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, p.errorf("invalid number %q", tok.text)
+		}
+		p.next()
+		return &literalNode{v: f}, nil
+	case tok.kind == tokIdent:
+		p.next()
+		switch tok.text {
+		case "true":
+			return &literalNode{v: true}, nil
+		case "false":
+			return &literalNode{v: false}, nil
+		case "null":
+			return &literalNode{v: nil}, nil
+		}
+		path := strings.Split(tok.text, ".")
+		switch path[0] {
+		case "subject", "resource", "env":
+		default:
+			return nil, fmt.Errorf("unknown attribute %q at %d", tok.text, tok.pos)
+		}
+		if len(path) < 2 || strings.Contains(tok.text, "..") || strings.HasSuffix(tok.text, ".") {
+			return nil, fmt.Errorf("invalid attribute %q at %d", tok.text, tok.pos)
+		}
+		return &pathNode{path: path}, nil
+	case p.isOp("("):
+		p.next()
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.isOp(")") {
+			return nil, p.errorf("expected )")
+		}
+		p.next()
+		return n, nil
+	case p.isOp("["):
+		p.next()
+		list := &listNode{}
+		for !p.isOp("]") {
+			n, err := p.parsePrimary()
+			if err != nil {
+				return nil, err
+			}
+			list.items = append(list.items, n)
+			if p.isOp(",") {
+				p.next()
+			} else if !p.isOp("]") {
+				return nil, p.errorf("expected ]")
+			}
+		}
+		p.next()
+		return list, nil
+	case tok.kind == tokEOF:
+		return nil, p.errorf("unexpected end")
+	default:
+		return nil, p.errorf("unexpected %q", tok.text)
+	}
+}
+
+/********************************************/
+/***************** evaluator ****************/
+/********************************************/
+
+var (
+	errType    = errors.New("mismatched types")
+	errMissing = errors.New("missing attribute")
+)
+
+type node interface {
+	eval(attrs *Attributes) (any, error)
+}
+
+type literalNode struct {
+	v any
+}
+
+func (n *literalNode) eval(*Attributes) (any, error) {
+	return n.v, nil
+}
+
+type listNode struct {
+	items []node
+}
+
+func (n *listNode) eval(attrs *Attributes) (any, error) {
+	list := make([]any, len(n.items))
+	for i, item := range n.items {
+		v, err := item.eval(attrs)
+		if err != nil {
+			return nil, err
+		}
+		list[i] = v
+	}
+	return list, nil
+}
+
+type pathNode struct {
+	path []string
+}
+
+func (n *pathNode) eval(attrs *Attributes) (any, error) {
+	var v any
+	switch n.path[0] {
+	case "subject":
+		v = attrs.Subject
+	case "resource":
+		v = attrs.Resource
+	case "env":
+		v = envAttrs(attrs.Env, n.path[1])
+	}
+	for _, key := range n.path[1:] {
+		var ok bool
+		if v, ok = lookupAttr(v, key); !ok {
+			return nil, errMissing
+		}
+	}
+	return normalize(v), nil
+}
+
+// envAttrs returns env, with the time attributes if key is one of them.
+func envAttrs(env map[string]any, key string) map[string]any {
+	if _, ok := env[key]; ok {
+		return env
+	}
+	now := time.Now()
+	switch key {
+	case "time":
+		return map[string]any{key: now.Format("15:04")}
+	case "hour":
+		return map[string]any{key: now.Hour()}
+	case "weekday":
+		return map[string]any{key: int(now.Weekday())}
+	}
+	return env
+}
+
+func lookupAttr(v any, key string) (any, bool) {
+	switch m := v.(type) {
+	case nil:
+		return nil, false
+	case map[string]any:
+		v, ok := m[key]
+		return v, ok
+	case map[string]string:
+		v, ok := m[key]
+		return v, ok
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Map && rv.Type().Key().Kind() == reflect.String {
+		if e := rv.MapIndex(reflect.ValueOf(key).Convert(rv.Type().Key())); e.IsValid() {
+			return e.Interface(), true
+		}
+	}
+	return nil, false
+}
+
+// normalize converts numbers to float64 and lists to []any.
+func normalize(v any) any {
+	if v == nil {
+		return nil
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		return rv.Float()
+	case reflect.String:
+		return rv.String()
+	case reflect.Bool:
+		return rv.Bool()
+	case reflect.Slice, reflect.Array:
+		list := make([]any, rv.Len())
+		for i := range list {
+			list[i] = normalize(rv.Index(i).Interface())
+		}
+		return list
+	}
+	return v
+}
+
+type notNode struct {
+	n node
+}
+
+func (n *notNode) eval(attrs *Attributes) (any, error) {
+	v, err := n.n.eval(attrs)
+	if err != nil {
+		return nil, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return nil, errType
+	}
+	return !b, nil
+}
+
+type logicalNode struct {
+	or          bool
+	left, right node
+}
+
+func (n *logicalNode) eval(attrs *Attributes) (any, error) {
+	for _, side := range []node{n.left, n.right} {
+		v, err := side.eval(attrs)
+		if err != nil {
+			return nil, err
+		}
+		b, ok := v.(bool)
+		if !ok {
+			return nil, errType
+		}
+		// short circuit.
+		if b == n.or {
+			return b, nil
+		}
+	}
+	return !n.or, nil
+}
+
+type compareNode struct {
+	op          string
+	left, right node
+}
+
+func (n *compareNode) eval(attrs *Attributes) (any, error) {
+	l, err := n.left.eval(attrs)
+	if err != nil {
+		return nil, err
+	}
+	r, err := n.right.eval(attrs)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "==":
+		return reflect.DeepEqual(l, r), nil
+	case "!=":
+		return !reflect.DeepEqual(l, r), nil
+	case "in":
+		list, ok := r.([]any)
+		if !ok {
+			return nil, errType
+		}
+		for _, item := range list {
+			if reflect.DeepEqual(l, item) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	var c int
+	switch l := l.(type) {
+	case float64:
+		r, ok := r.(float64)
+		if !ok {
+			return nil, errType
+		}
+		c = compare(l, r)
+	case string:
+		r, ok := r.(string)
+		if !ok {
+			return nil, errType
+		}
+		c = strings.Compare(l, r)
+	default:
+		return nil, errType
+	}
+	switch n.op {
+	case "<":
+		return c < 0, nil
+	case "<=":
+		return c <= 0, nil
+	case ">":
+		return c > 0, nil
+	default:
+		return c >= 0, nil
+	}
+}
+
+func compare(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
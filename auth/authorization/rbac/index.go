@@ -0,0 +1,200 @@
+package rbac
+
+import "regexp"
+
+// rule is an indexed policy.
+type rule struct {
+	Policy
+	resource  *regexp.Regexp
+	action    *regexp.Regexp
+	condition *Condition
+}
+
+// match reports whether the rule applies, and the error of its condition if
+// it can't be evaluated. A deny rule whose condition fails applies.
+func (r *rule) match(domain, resource, action string, attrs *Attributes) (bool, error) {
+	if r.Domain != "" && r.Domain != "*" && r.Domain != domain {
+		return false, nil
+	}
+	if r.action == nil {
+		if r.Action != action {
+			return false, nil
+		}
+	} else if !r.action.MatchString(action) {
+		return false, nil
+	}
+	if r.resource == nil {
+		if r.Resource != resource {
+			return false, nil
+		}
+	} else if !r.resource.MatchString(resource) {
+		return false, nil
+	}
+	if r.condition == nil {
+		return true, nil
+	}
+	ok, err := r.condition.Eval(*attrs)
+	if err != nil {
+		return r.effect() == Deny, err
+	}
+	return ok, nil
+}
+
+// roleRules are the rules of a role. Rules with a literal resource are looked
+// up by resource, only the pattern rules are scanned.
+type roleRules struct {
+	exact    map[string][]*rule
+	patterns []*rule
+}
+
+// index looks up policies by role and resource, and roles by member.
+type index struct {
+	roles   map[string]*roleRules
+	parents map[string][]Grouping
+	// conditions caches the compiled conditions, policies often share one.
+	conditions map[string]*Condition
+}
+
+func newIndex() *index {
+	return &index{
+		roles:      make(map[string]*roleRules),
+		parents:    make(map[string][]Grouping),
+		conditions: make(map[string]*Condition),
+	}
+}
+
+func (idx *index) newRule(p Policy) (*rule, error) {
+	r := &rule{
+		Policy:   p,
+		resource: compilePattern(p.Resource),
+		action:   compilePattern(p.Action),
+	}
+	if p.Condition == "" {
+		return r, nil
+	}
+	if c, ok := idx.conditions[p.Condition]; ok {
+		r.condition = c
+		return r, nil
+	}
+	c, err := CompileCondition(p.Condition)
+	if err != nil {
+		return nil, err
+	}
+	idx.conditions[p.Condition] = c
+	r.condition = c
+	return r, nil
+}
+
+func (idx *index) addPolicy(p Policy) error {
+	r, err := idx.newRule(p)
+	if err != nil {
+		return err
+	}
+	rr, ok := idx.roles[p.Role]
+	if !ok {
+		rr = &roleRules{exact: make(map[string][]*rule)}
+		idx.roles[p.Role] = rr
+	}
+	if r.resource == nil {
+		rr.exact[p.Resource] = append(rr.exact[p.Resource], r)
+	} else {
+		rr.patterns = append(rr.patterns, r)
+	}
+	return nil
+}
+
+func (idx *index) removePolicy(p Policy) {
+	rr, ok := idx.roles[p.Role]
+	if !ok {
+		return
+	}
+	remove := func(rules []*rule) []*rule {
+		for i, r := range rules {
+			if r.Policy == p {
+				return append(rules[:i:i], rules[i+1:]...)
+			}
+		}
+		return rules
+	}
+	if isPattern(p.Resource) {
+		rr.patterns = remove(rr.patterns)
+	} else if rules := remove(rr.exact[p.Resource]); len(rules) > 0 {
+		rr.exact[p.Resource] = rules
+	} else {
+		delete(rr.exact, p.Resource)
+	}
+	if len(rr.exact) == 0 && len(rr.patterns) == 0 {
+		delete(idx.roles, p.Role)
+	}
+}
+
+func (idx *index) addGrouping(g Grouping) {
+	idx.parents[g.Member] = append(idx.parents[g.Member], g)
+}
+
+func (idx *index) removeGrouping(g Grouping) {
+	groupings := idx.parents[g.Member]
+	for i, v := range groupings {
+		if v == g {
+			groupings = append(groupings[:i:i], groupings[i+1:]...)
+			break
+		}
+	}
+	if len(groupings) == 0 {
+		delete(idx.parents, g.Member)
+		return
+	}
+	idx.parents[g.Member] = groupings
+}
+
+// expand returns subjects and all the roles they inherit in domain.
+func (idx *index) expand(domain string, subjects ...string) []string {
+	seen := make(map[string]bool, len(subjects))
+	queue := make([]string, 0, len(subjects))
+	for _, s := range subjects {
+		if !seen[s] {
+			seen[s] = true
+			queue = append(queue, s)
+		}
+	}
+	for i := 0; i < len(queue); i++ {
+		for _, g := range idx.parents[queue[i]] {
+			if g.Domain != "" && g.Domain != "*" && g.Domain != domain {
+				continue
+			}
+			if !seen[g.Role] {
+				seen[g.Role] = true
+				queue = append(queue, g.Role)
+			}
+		}
+	}
+	return queue
+}
+
+// decide returns the policy deciding whether roles may perform action on
+// resource in domain, nil if no policy matches. Deny policies win. err is
+// the error of the condition of the deciding deny policy, if it failed.
+func (idx *index) decide(roles []string, domain, resource, action string, attrs *Attributes) (*Policy, error) {
+	var allowed *Policy
+	for _, role := range roles {
+		rr, ok := idx.roles[role]
+		if !ok {
+			continue
+		}
+		for _, rules := range [][]*rule{rr.exact[resource], rr.patterns} {
+			for _, r := range rules {
+				ok, err := r.match(domain, resource, action, attrs)
+				if !ok {
+					continue
+				}
+				if r.effect() == Deny {
+					return &r.Policy, err
+				}
+				if allowed == nil {
+					allowed = &r.Policy
+				}
+			}
+		}
+	}
+	return allowed, nil
+}
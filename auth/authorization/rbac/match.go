@@ -0,0 +1,54 @@
+package rbac
+
+import (
+	"regexp"
+	"strings"
+)
+
+// segmentPattern matches "{name}" and "{name...}" in a pattern.
+var segmentPattern = regexp.MustCompile(`\{[^{}/]*\}`)
+
+// isPattern reports whether s contains wildcards.
+func isPattern(s string) bool {
+	return strings.ContainsAny(s, "*{")
+}
+
+// compilePattern compiles a glob or key pattern to a regular expression.
+// It returns nil for patterns without wildcards, which match by equality.
+func compilePattern(pattern string) *regexp.Regexp {
+	if !isPattern(pattern) {
+		return nil
+	}
+	var b strings.Builder
+	b.WriteString("^")
+	last := 0
+	for _, loc := range segmentPattern.FindAllStringIndex(pattern, -1) {
+		b.WriteString(globToRegexp(pattern[last:loc[0]]))
+		if strings.HasSuffix(pattern[loc[0]:loc[1]], "...}") {
+			b.WriteString(".*")
+		} else {
+			b.WriteString("[^/]+")
+		}
+		last = loc[1]
+	}
+	b.WriteString(globToRegexp(pattern[last:]))
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
+
+func globToRegexp(s string) string {
+	parts := strings.Split(s, "*")
+	for i, p := range parts {
+		parts[i] = regexp.QuoteMeta(p)
+	}
+	return strings.Join(parts, ".*")
+}
+
+// KeyMatch reports whether key matches pattern, see Policy for the syntax.
+func KeyMatch(key, pattern string) bool {
+	re := compilePattern(pattern)
+	if re == nil {
+		return key == pattern
+	}
+	return re.MatchString(key)
+}
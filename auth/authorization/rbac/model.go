@@ -1,8 +1,48 @@
 package rbac
 
+// Effect is the effect of a policy.
+type Effect string
+
+const (
+	Allow Effect = "allow"
+	Deny  Effect = "deny"
+)
+
 // Policy defines a role-based access control policy.
+//
+// Resource and Action may be patterns: "*" and "{name...}" match any
+// characters, and "{name}" matches a single path segment, e.g. "/data/*"
+// or "/users/{id}".
 type Policy struct {
 	Role     string
 	Resource string
 	Action   string
+	// Domain restricts the policy to a tenant, an empty domain applies to every domain.
+	Domain string
+	// Effect of the policy, empty means Allow. Deny policies win over Allow policies.
+	Effect Effect
+	// Condition is an optional expression over the request attributes, the
+	// policy applies only if it holds, see Condition.
+	Condition string
+}
+
+func (p Policy) effect() Effect {
+	if p.Effect == "" {
+		return Allow
+	}
+	return p.Effect
+}
+
+func (p Policy) normalize() Policy {
+	p.Effect = p.effect()
+	return p
+}
+
+// Grouping assigns a role to a member, which is a user or another role.
+// The member inherits all the permissions of the role.
+type Grouping struct {
+	Member string
+	Role   string
+	// Domain restricts the assignment to a tenant, an empty domain applies to every domain.
+	Domain string
 }
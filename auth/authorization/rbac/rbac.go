@@ -1,73 +1,313 @@
 package rbac
 
-import "sync"
+import (
+	"slices"
+	"sync"
+)
 
 // Enforcer manages policies and performs permission checks.
 type Enforcer struct {
-	policies []Policy
-	adapter  Adapter
-	mu       sync.RWMutex
+	model   *model
+	adapter Adapter
+	// mu guards model, it is only held to read or swap the model, so
+	// permission checks are not blocked by the adapter.
+	mu sync.RWMutex
+	// wmu serializes the changes of the policies.
+	wmu sync.Mutex
 }
 
-// NewEnforcer creates a new Enforcer with the given adapter.
+// model is the set of policies and groupings of an enforcer.
+type model struct {
+	policies  []Policy
+	groupings []Grouping
+	// known holds the policies and groupings, to ignore duplicates in O(1).
+	known map[any]struct{}
+	index *index
+}
+
+func newModel(policies []Policy, groupings []Grouping) (*model, error) {
+	m := &model{index: newIndex(), known: make(map[any]struct{})}
+	for _, p := range policies {
+		if err := m.addPolicy(p.normalize()); err != nil {
+			return nil, err
+		}
+	}
+	for _, g := range groupings {
+		m.addGrouping(g)
+	}
+	return m, nil
+}
+
+// NewEnforcer creates a new Enforcer with the given adapter. The conditions
+// of the policies are compiled once here.
+//
+// If the adapter implements IncrementalAdapter, every change of the
+// policies is saved by the adapter as it is made.
 func NewEnforcer(adapter Adapter) (*Enforcer, error) {
-	policies, err := adapter.LoadPolicy()
+	policies, groupings, err := adapter.LoadPolicy()
+	if err != nil {
+		return nil, err
+	}
+	m, err := newModel(policies, groupings)
 	if err != nil {
 		return nil, err
 	}
-	return &Enforcer{
-		policies: policies,
-		adapter:  adapter,
-	}, nil
+	return &Enforcer{model: m, adapter: adapter}, nil
 }
 
-// AddPolicy adds a new policy to the enforcer.
-func (e *Enforcer) AddPolicy(role, resource, action string) {
-	e.mu.Lock()
-	defer e.mu.Unlock()
-	// avoid adding duplicate policies.
-	for _, p := range e.policies {
-		if p.Role == role && p.Resource == resource && p.Action == action {
-			return
-		}
+// LoadPolicy reloads the policies from the adapter. The new policies replace
+// the current ones at once, the current ones are kept if loading fails.
+func (e *Enforcer) LoadPolicy() error {
+	e.wmu.Lock()
+	defer e.wmu.Unlock()
+	policies, groupings, err := e.adapter.LoadPolicy()
+	if err != nil {
+		return err
+	}
+	m, err := newModel(policies, groupings)
+	if err != nil {
+		return err
 	}
-	e.policies = append(e.policies, Policy{Role: role, Resource: resource, Action: action})
+	e.mu.Lock()
+	e.model = m
+	e.mu.Unlock()
+	return nil
+}
+
+// Watch reloads the policies whenever w reports a change, e.g.
+//
+//	adapter := rbac.NewCSVAdapter("policy.csv")
+//	e, _ := rbac.NewEnforcer(adapter)
+//	_ = e.Watch(adapter)
+//	defer adapter.Close()
+func (e *Enforcer) Watch(w Watcher) error {
+	return w.Watch(e.LoadPolicy)
+}
+
+// AddPolicy adds a new policy allowing role to perform action on resource.
+func (e *Enforcer) AddPolicy(role, resource, action string) error {
+	return e.AddPolicies(Policy{Role: role, Resource: resource, Action: action})
 }
 
 // RemovePolicy removes a policy from the enforcer.
-func (e *Enforcer) RemovePolicy(role, resource, action string) {
+func (e *Enforcer) RemovePolicy(role, resource, action string) error {
+	return e.RemovePolicies(Policy{Role: role, Resource: resource, Action: action})
+}
+
+// AddPolicies adds policies to the enforcer, duplicate policies are ignored.
+// No policy is added if a condition doesn't compile.
+func (e *Enforcer) AddPolicies(policies ...Policy) error {
+	return e.update(func(m *model) ([]Policy, []Grouping) {
+		return unknown(m, policies), nil
+	}, true)
+}
+
+// RemovePolicies removes policies from the enforcer.
+func (e *Enforcer) RemovePolicies(policies ...Policy) error {
+	return e.update(func(m *model) ([]Policy, []Grouping) {
+		return known(m, policies), nil
+	}, false)
+}
+
+// AddGrouping assigns role to member in domain, an empty domain applies to every domain.
+func (e *Enforcer) AddGrouping(member, role, domain string) error {
+	g := []Grouping{{Member: member, Role: role, Domain: domain}}
+	return e.update(func(m *model) ([]Policy, []Grouping) {
+		return nil, unknown(m, g)
+	}, true)
+}
+
+// RemoveGrouping removes the assignment of role to member in domain.
+func (e *Enforcer) RemoveGrouping(member, role, domain string) error {
+	g := []Grouping{{Member: member, Role: role, Domain: domain}}
+	return e.update(func(m *model) ([]Policy, []Grouping) {
+		return nil, known(m, g)
+	}, false)
+}
+
+// update adds or removes the policies and groupings returned by changes,
+// saving them first if the adapter is incremental.
+func (e *Enforcer) update(changes func(m *model) ([]Policy, []Grouping), add bool) error {
+	e.wmu.Lock()
+	defer e.wmu.Unlock()
+	// the model is only changed with wmu held, it can be read without mu.
+	m := e.model
+	policies, groupings := changes(m)
+	if len(policies) == 0 && len(groupings) == 0 {
+		return nil
+	}
+	if add {
+		for _, p := range policies {
+			if p.Condition == "" {
+				continue
+			}
+			if _, err := CompileCondition(p.Condition); err != nil {
+				return err
+			}
+		}
+	}
+	if adapter, ok := e.adapter.(IncrementalAdapter); ok {
+		var err error
+		if add {
+			err = adapter.AddPolicies(policies, groupings)
+		} else {
+			err = adapter.RemovePolicies(policies, groupings)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
 	e.mu.Lock()
 	defer e.mu.Unlock()
-	e.policies = filterPolicies(e.policies, func(p Policy) bool {
-		return !(p.Role == role && p.Resource == resource && p.Action == action)
-	})
+	for _, p := range policies {
+		if add {
+			_ = m.addPolicy(p)
+		} else {
+			m.removePolicy(p)
+		}
+	}
+	for _, g := range groupings {
+		if add {
+			m.addGrouping(g)
+		} else {
+			m.removeGrouping(g)
+		}
+	}
+	return nil
+}
+
+// Policies returns all policies.
+func (e *Enforcer) Policies() []Policy {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return slices.Clone(e.model.policies)
+}
+
+// Groupings returns all role assignments.
+func (e *Enforcer) Groupings() []Grouping {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return slices.Clone(e.model.groupings)
+}
+
+// Roles returns the roles of member in domain, including the inherited ones.
+func (e *Enforcer) Roles(member, domain string) []string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.model.index.expand(domain, member)[1:]
 }
 
+// Save saves all the policies with the adapter.
 func (e *Enforcer) Save() error {
-	e.mu.Lock()
-	defer e.mu.Unlock()
-	return e.adapter.SavePolicy(e.policies)
+	e.wmu.Lock()
+	defer e.wmu.Unlock()
+	return e.adapter.SavePolicy(e.model.policies, e.model.groupings)
+}
+
+// Enforce checks if a subject, which is a user or a role, has permission to
+// perform an action on a resource.
+func (e *Enforcer) Enforce(subject, resource, action string) bool {
+	return e.EnforceDomain(subject, "", resource, action)
 }
 
-// Enforce checks if a role has permission to perform an action on a resource.
-func (e *Enforcer) Enforce(role, resource, action string) bool {
+// EnforceDomain checks if a subject has permission to perform an action on a
+// resource in domain.
+func (e *Enforcer) EnforceDomain(subject, domain, resource, action string) bool {
+	return e.EnforceAttrs(subject, domain, resource, action, Attributes{})
+}
+
+// EnforceAttrs checks if a subject has permission to perform an action on a
+// resource in domain, the policy conditions are evaluated over attrs.
+func (e *Enforcer) EnforceAttrs(subject, domain, resource, action string, attrs Attributes) bool {
+	p, _ := e.decide([]string{subject}, domain, resource, action, &attrs)
+	return p != nil && p.effect() == Allow
+}
+
+func (e *Enforcer) decide(subjects []string, domain, resource, action string, attrs *Attributes) (*Policy, error) {
 	e.mu.RLock()
 	defer e.mu.RUnlock()
-	for _, p := range e.policies {
-		if p.Role == role && p.Resource == resource && p.Action == action {
-			return true
+	idx := e.model.index
+	return idx.decide(idx.expand(domain, subjects...), domain, resource, action, attrs)
+}
+
+// unknown returns the values not in m, without duplicates.
+func unknown[T Policy | Grouping](m *model, values []T) []T {
+	var result []T
+	seen := make(map[T]struct{}, len(values))
+	for _, v := range values {
+		v = canonical(v)
+		if _, ok := m.known[v]; ok {
+			continue
+		}
+		if _, ok := seen[v]; !ok {
+			seen[v] = struct{}{}
+			result = append(result, v)
 		}
 	}
-	return false
+	return result
 }
 
-// filterPolicies filters a slice of policies based on a condition.
-func filterPolicies(policies []Policy, condition func(Policy) bool) []Policy {
-	var result []Policy
-	for _, p := range policies {
-		if condition(p) {
-			result = append(result, p)
+// known returns the values in m, without duplicates.
+func known[T Policy | Grouping](m *model, values []T) []T {
+	var result []T
+	seen := make(map[T]struct{}, len(values))
+	for _, v := range values {
+		v = canonical(v)
+		if _, ok := m.known[v]; !ok {
+			continue
+		}
+		if _, ok := seen[v]; !ok {
+			seen[v] = struct{}{}
+			result = append(result, v)
 		}
 	}
 	return result
 }
+
+// canonical sets the default effect of policies, so equal policies compare equal.
+func canonical[T Policy | Grouping](v T) T {
+	if p, ok := any(v).(Policy); ok {
+		return any(p.normalize()).(T)
+	}
+	return v
+}
+
+func (m *model) addPolicy(p Policy) error {
+	if _, ok := m.known[p]; ok {
+		return nil
+	}
+	if err := m.index.addPolicy(p); err != nil {
+		return err
+	}
+	m.known[p] = struct{}{}
+	m.policies = append(m.policies, p)
+	return nil
+}
+
+func (m *model) removePolicy(p Policy) {
+	if _, ok := m.known[p]; !ok {
+		return
+	}
+	delete(m.known, p)
+	m.policies = slices.DeleteFunc(m.policies, func(v Policy) bool { return v == p })
+	m.index.removePolicy(p)
+}
+
+func (m *model) addGrouping(g Grouping) {
+	if _, ok := m.known[g]; ok {
+		return
+	}
+	m.known[g] = struct{}{}
+	m.groupings = append(m.groupings, g)
+	m.index.addGrouping(g)
+}
+
+func (m *model) removeGrouping(g Grouping) {
+	if _, ok := m.known[g]; !ok {
+		return
+	}
+	delete(m.known, g)
+	m.groupings = slices.DeleteFunc(m.groupings, func(v Grouping) bool { return v == g })
+	m.index.removeGrouping(g)
+}
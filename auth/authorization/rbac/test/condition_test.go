@@ -0,0 +1,144 @@
+package test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/yates-z/easel/auth/authorization/rbac"
+)
+
+const conditionModel = `p,user,/docs/{id},write,allow,,resource.owner == subject.id
+p,user,/docs/{id},read,allow,,"resource.visibility in ['public', 'internal'] || resource.owner == subject.id"
+p,user,/reports,read,allow,,"env.time >= '09:00' && env.time < '17:00' && env.weekday in [1, 2, 3, 4, 5]"
+p,user,/docs/{id},*,deny,,'blocked' in subject.flags
+g,alice,user
+`
+
+func TestCondition(t *testing.T) {
+	e, _ := newEnforcer(t, conditionModel)
+	alice := map[string]any{"id": "alice", "flags": []string{}}
+	for _, tc := range []struct {
+		name     string
+		resource string
+		action   string
+		attrs    rbac.Attributes
+		want     bool
+	}{
+		{"owner", "/docs/1", "write", rbac.Attributes{Subject: alice, Resource: map[string]any{"owner": "alice"}}, true},
+		{"not owner", "/docs/1", "write", rbac.Attributes{Subject: alice, Resource: map[string]any{"owner": "bob"}}, false},
+		{"public", "/docs/1", "read", rbac.Attributes{Subject: alice, Resource: map[string]any{"visibility": "public"}}, true},
+		{"private", "/docs/1", "read", rbac.Attributes{Subject: alice, Resource: map[string]any{"visibility": "private"}}, false},
+		{"no attributes", "/docs/1", "read", rbac.Attributes{}, false},
+		{"blocked", "/docs/1", "write", rbac.Attributes{
+			Subject:  map[string]any{"id": "alice", "flags": []string{"blocked"}},
+			Resource: map[string]any{"owner": "alice"},
+		}, false},
+		// the deny policy applies if its condition can't be evaluated.
+		{"no flags", "/docs/1", "write", rbac.Attributes{
+			Subject:  map[string]any{"id": "alice"},
+			Resource: map[string]any{"owner": "alice"},
+		}, false},
+		{"business hours", "/reports", "read", rbac.Attributes{Env: map[string]any{"time": "10:30", "weekday": 2}}, true},
+		{"after hours", "/reports", "read", rbac.Attributes{Env: map[string]any{"time": "18:00", "weekday": 2}}, false},
+		{"weekend", "/reports", "read", rbac.Attributes{Env: map[string]any{"time": "10:30", "weekday": 0}}, false},
+	} {
+		if got := e.EnforceAttrs("alice", "", tc.resource, tc.action, tc.attrs); got != tc.want {
+			t.Errorf("%s: EnforceAttrs(%s, %s) = %v, want %v", tc.name, tc.resource, tc.action, got, tc.want)
+		}
+	}
+}
+
+func TestConditionDenyFails(t *testing.T) {
+	e, _ := newEnforcer(t, conditionModel)
+	d := e.AuthorizeAttrs([]string{"user"}, "", "/docs/1", "write", rbac.Attributes{
+		Subject:  map[string]any{"id": "alice"},
+		Resource: map[string]any{"owner": "alice"},
+	})
+	if d.Allowed || d.Policy == nil || d.Policy.Effect != rbac.Deny || !strings.Contains(d.Reason, "condition failed") {
+		t.Fatalf("unexpected decision %+v", d)
+	}
+}
+
+func TestConditionRoundTrip(t *testing.T) {
+	e, path := newEnforcer(t, conditionModel)
+	if err := e.AddPolicies(rbac.Policy{Role: "user", Resource: "/q", Action: "read", Condition: `subject.name == "a, \"b\""`}); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Save(); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := rbac.NewEnforcer(rbac.NewCSVAdapter(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(loaded.Policies()) != fmt.Sprint(e.Policies()) {
+		t.Fatalf("policies changed after a round trip:\n%v\n%v", loaded.Policies(), e.Policies())
+	}
+	if !loaded.EnforceAttrs("alice", "", "/q", "read", rbac.Attributes{Subject: map[string]any{"name": `a, "b"`}}) {
+		t.Fatal("condition changed after a round trip")
+	}
+}
+
+func TestInvalidCondition(t *testing.T) {
+	for _, src := range []string{
+		"subject.id ==",
+		"os.Getenv('HOME') == ''",
+		"(subject.id == 'a'",
+		"subject.id = 'a'",
+		"'unterminated",
+	} {
+		if _, err := rbac.CompileCondition(src); !errors.Is(err, rbac.ErrInvalidCondition) {
+			t.Errorf("CompileCondition(%q) = %v, want ErrInvalidCondition", src, err)
+		}
+	}
+
+	e, _ := newEnforcer(t, "")
+	if err := e.AddPolicies(rbac.Policy{Role: "user", Resource: "/a", Action: "read"}, rbac.Policy{Role: "user", Resource: "/b", Action: "read", Condition: "subject.id =="}); err == nil {
+		t.Fatal("expected an error adding an invalid condition")
+	}
+	if len(e.Policies()) != 0 {
+		t.Fatal("policies were added despite an invalid condition")
+	}
+}
+
+func TestConditionEval(t *testing.T) {
+	attrs := rbac.Attributes{
+		Subject:  map[string]any{"age": 20, "tags": []any{"a", 1}},
+		Resource: map[string]any{"meta": map[string]string{"level": "3"}},
+	}
+	for src, want := range map[string]bool{
+		"subject.age >= 18":            true,
+		"subject.age > 18.5 && !false": true,
+		"subject.age < 18":             false,
+		"1 in subject.tags":            true,
+		"resource.meta.level == '3'":   true,
+		"!(subject.age == 20) || true": true,
+	} {
+		c, err := rbac.CompileCondition(src)
+		if err != nil {
+			t.Fatalf("CompileCondition(%q): %v", src, err)
+		}
+		if got, err := c.Eval(attrs); got != want || err != nil {
+			t.Errorf("%q = %v, %v, want %v", src, got, err, want)
+		}
+	}
+	// the expressions which can't be evaluated fail.
+	for _, src := range []string{
+		"subject.age < '30'",
+		"resource.meta.missing == null",
+		"!(resource.meta.missing == 'a')",
+		"subject.age",
+		"subject.name in subject.tags",
+		"subject.age == 20 && subject.name",
+	} {
+		c, err := rbac.CompileCondition(src)
+		if err != nil {
+			t.Fatalf("CompileCondition(%q): %v", src, err)
+		}
+		if got, err := c.Eval(attrs); got || !errors.Is(err, rbac.ErrConditionEval) {
+			t.Errorf("%q = %v, %v, want ErrConditionEval", src, got, err)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/yates-z/easel/auth/authorization/rbac"
+)
+
+const model = `# policies
+admin,/data/*,*
+p,editor,/users/{id},GET
+p,editor,/users/{id},PUT,allow,tenant1
+p,editor,/users/{id}/password,*,deny
+p,viewer,/docs/{path...},read
+g,alice,admin
+g,bob,editor
+g,editor,viewer
+g,carol,editor,tenant1
+`
+
+func newEnforcer(t testing.TB, content string) (*rbac.Enforcer, string) {
+	path := filepath.Join(t.TempDir(), "policy.csv")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	e, err := rbac.NewEnforcer(rbac.NewCSVAdapter(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e, path
+}
+
+func TestEnforcer(t *testing.T) {
+	e, _ := newEnforcer(t, model)
+	for _, tc := range []struct {
+		subject, domain, resource, action string
+		want                              bool
+	}{
+		{"alice", "", "/data/a/b", "write", true},
+		{"alice", "", "/data", "write", false},
+		{"bob", "", "/users/1", "GET", true},
+		{"bob", "", "/users/1/x", "GET", false},
+		{"bob", "", "/users/1", "PUT", false},
+		{"bob", "tenant1", "/users/1", "PUT", true},
+		{"bob", "", "/docs/a/b.md", "read", true},
+		{"bob", "", "/users/1/password", "GET", false},
+		{"carol", "", "/users/1", "GET", false},
+		{"carol", "tenant1", "/users/1", "GET", true},
+		{"carol", "tenant2", "/users/1", "GET", false},
+		{"viewer", "", "/docs/x", "read", true},
+		{"mallory", "", "/data/a", "read", false},
+	} {
+		if got := e.EnforceDomain(tc.subject, tc.domain, tc.resource, tc.action); got != tc.want {
+			t.Errorf("EnforceDomain(%s, %q, %s, %s) = %v, want %v", tc.subject, tc.domain, tc.resource, tc.action, got, tc.want)
+		}
+	}
+
+	if roles := e.Roles("bob", ""); len(roles) != 2 || roles[0] != "editor" || roles[1] != "viewer" {
+		t.Fatalf("unexpected roles %v", roles)
+	}
+}
+
+func TestDenyWins(t *testing.T) {
+	e, _ := newEnforcer(t, model)
+	e.AddPolicies(rbac.Policy{Role: "viewer", Resource: "/data/secret", Action: "*", Effect: rbac.Deny})
+	if !e.Enforce("alice", "/data/secret", "read") {
+		t.Fatal("alice does not inherit viewer")
+	}
+	e.AddGrouping("alice", "viewer", "")
+	d := e.Authorize([]string{"alice"}, "/data/secret", "read")
+	if d.Allowed || d.Policy == nil || d.Policy.Effect != rbac.Deny {
+		t.Fatalf("expected deny to win, got %+v", d)
+	}
+	e.RemoveGrouping("alice", "viewer", "")
+	if !e.Enforce("alice", "/data/secret", "read") {
+		t.Fatal("grouping was not removed")
+	}
+}
+
+func TestRoleCycle(t *testing.T) {
+	e, _ := newEnforcer(t, "g,a,b\ng,b,a\np,b,/x,read\n")
+	if !e.Enforce("a", "/x", "read") || e.Enforce("a", "/y", "read") {
+		t.Fatal("unexpected decision with a role cycle")
+	}
+}
+
+func TestCSVRoundTrip(t *testing.T) {
+	e, path := newEnforcer(t, model)
+	e.RemovePolicy("admin", "/data/*", "*")
+	if err := e.Save(); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := rbac.NewEnforcer(rbac.NewCSVAdapter(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(loaded.Policies()) != fmt.Sprint(e.Policies()) || fmt.Sprint(loaded.Groupings()) != fmt.Sprint(e.Groupings()) {
+		t.Fatalf("policies changed after a round trip:\n%v\n%v", loaded.Policies(), e.Policies())
+	}
+	if loaded.Enforce("alice", "/data/a", "read") {
+		t.Fatal("removed policy was saved")
+	}
+}
+
+func TestKeyMatch(t *testing.T) {
+	for _, tc := range []struct {
+		key, pattern string
+		want         bool
+	}{
+		{"/data", "/data", true},
+		{"/data/a", "/data/*", true},
+		{"/users/1", "/users/{id}", true},
+		{"/users/1/2", "/users/{id}", false},
+		{"/files/a/b", "/files/{path...}", true},
+		{"/pb.Greeter/SayHello", "/pb.Greeter/*", true},
+		{"/a.b", "/a*b", true},
+		{"/axb", "/a.b", false},
+	} {
+		if got := rbac.KeyMatch(tc.key, tc.pattern); got != tc.want {
+			t.Errorf("KeyMatch(%q, %q) = %v, want %v", tc.key, tc.pattern, got, tc.want)
+		}
+	}
+}
+
+func BenchmarkEnforce(b *testing.B) {
+	e, _ := newEnforcer(b, "g,alice,role9999\n")
+	policies := make([]rbac.Policy, 0, 50000)
+	for i := range 50000 {
+		policies = append(policies, rbac.Policy{Role: fmt.Sprintf("role%d", i%10000), Resource: fmt.Sprintf("/data/%d", i), Action: "read"})
+	}
+	e.AddPolicies(policies...)
+	b.ResetTimer()
+	for range b.N {
+		e.Enforce("alice", "/data/49999", "read")
+	}
+}
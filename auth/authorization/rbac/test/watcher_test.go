@@ -0,0 +1,115 @@
+package test
+
+import (
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yates-z/easel/auth/authorization/rbac"
+)
+
+func TestIncrementalAdapter(t *testing.T) {
+	e, path := newEnforcer(t, "# admins\nadmin,/data/*,*\n# viewers\nviewer,/docs,read")
+	if err := e.AddPolicy("editor", "/docs", "write"); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.AddGrouping("alice", "editor", "tenant1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.RemovePolicy("viewer", "/docs", "read"); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# admins\nadmin,/data/*,*\neditor,/docs,write\ng,alice,editor,tenant1\n"
+	if string(data) != want {
+		t.Fatalf("unexpected file:\n%s\nwant:\n%s", data, want)
+	}
+	if !e.EnforceDomain("alice", "tenant1", "/docs", "write") || e.Enforce("viewer", "/docs", "read") {
+		t.Fatal("changes were not applied")
+	}
+}
+
+func TestWatch(t *testing.T) {
+	_, path := newEnforcer(t, "viewer,/docs,read\n")
+	var (
+		mu     sync.Mutex
+		errors []error
+	)
+	adapter := rbac.NewCSVAdapter(path, rbac.PollInterval(10*time.Millisecond), rbac.OnWatchError(func(err error) {
+		mu.Lock()
+		errors = append(errors, err)
+		mu.Unlock()
+	}))
+	e, err := rbac.NewEnforcer(adapter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Watch(adapter); err != nil {
+		t.Fatal(err)
+	}
+	defer adapter.Close()
+
+	// keep enforcing while the policies are reloaded.
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				e.Enforce("viewer", "/docs", "read")
+			}
+		}
+	}()
+
+	waitFor := func(cond func() bool) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for !cond() {
+			if time.Now().After(deadline) {
+				t.Fatal("timed out waiting for the policies to reload")
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+
+	if err := os.WriteFile(path, []byte("viewer,/docs,read\nviewer,/docs,write\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(func() bool { return e.Enforce("viewer", "/docs", "write") })
+
+	// an invalid file keeps the current policies.
+	if err := os.WriteFile(path, []byte("p,viewer,/docs,read,maybe\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(errors) > 0
+	})
+	if !e.Enforce("viewer", "/docs", "write") {
+		t.Fatal("policies were dropped by an invalid file")
+	}
+
+	// the file is reloaded once fixed, and the changes of the enforcer are saved to it.
+	if err := os.WriteFile(path, []byte("viewer,/docs,read\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(func() bool { return !e.Enforce("viewer", "/docs", "write") })
+	if err := e.AddPolicy("viewer", "/docs", "delete"); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "viewer,/docs,delete") || len(e.Policies()) != 2 {
+		t.Fatalf("unexpected policies %v in file:\n%s", e.Policies(), data)
+	}
+}
@@ -0,0 +1,26 @@
+package oauth2
+
+import "errors"
+
+var (
+	ErrInvalidState    = errors.New("oauth2: invalid state")
+	ErrMissingCode     = errors.New("oauth2: missing authorization code")
+	ErrMissingIDToken  = errors.New("oauth2: missing id token")
+	ErrInvalidNonce    = errors.New("oauth2: invalid nonce")
+	ErrNoRefreshToken  = errors.New("oauth2: no refresh token")
+	ErrInvalidResponse = errors.New("oauth2: invalid token response")
+)
+
+// Error is an error response of the IdP, see RFC 6749 section 5.2.
+type Error struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+	URI         string `json:"error_uri,omitempty"`
+}
+
+func (e *Error) Error() string {
+	if e.Description == "" {
+		return "oauth2: " + e.Code
+	}
+	return "oauth2: " + e.Code + ": " + e.Description
+}
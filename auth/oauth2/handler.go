@@ -0,0 +1,154 @@
+package oauth2
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/yates-z/easel/transport/http/server"
+)
+
+// CookieName is the name of the cookie holding the session ID of a pending login.
+const CookieName = "oauth2_login"
+
+// loginTTL is how long a login may stay pending at the IdP.
+const loginTTL = 10 * time.Minute
+
+// the keys of a pending login in its session.
+const (
+	stateKey    = "oauth2.state"
+	nonceKey    = "oauth2.nonce"
+	verifierKey = "oauth2.verifier"
+	nextKey     = "oauth2.next"
+	expiresKey  = "oauth2.expires"
+)
+
+// LoginFunc logs in the user of token, e.g. by storing its ID token claims
+// in the session of the request. The user is then redirected to the page
+// the login started from.
+type LoginFunc func(ctx *server.Context, token *Token) error
+
+// LoginHandler starts a login: it stores the state, nonce and PKCE verifier
+// of the login in a new session, and redirects to the IdP. The "next" query
+// parameter is the local URL the user is redirected to after the login.
+func (c *Client) LoginHandler() server.HandlerFunc {
+	return func(ctx *server.Context) error {
+		var values [3]string
+		for i := range values {
+			v, err := randomString(32)
+			if err != nil {
+				return err
+			}
+			values[i] = v
+		}
+		state, nonce, verifier := values[0], values[1], values[2]
+
+		id, err := c.sessions.CreateSession()
+		if err != nil {
+			return err
+		}
+		sess, err := c.sessions.GetSession(id)
+		if err != nil {
+			return err
+		}
+		sess.Data[stateKey] = state
+		sess.Data[nonceKey] = nonce
+		sess.Data[verifierKey] = verifier
+		sess.Data[nextKey] = localURL(ctx.Query("next"))
+		// a string, to be kept as is by every session codec.
+		sess.Data[expiresKey] = time.Now().Add(loginTTL).Format(time.RFC3339Nano)
+		if err := c.sessions.SaveSession(sess); err != nil {
+			return err
+		}
+
+		c.setCookie(ctx, id, int(loginTTL/time.Second))
+		http.Redirect(ctx.Response, ctx.Request, c.AuthCodeURL(state, nonce, verifier), http.StatusFound)
+		return nil
+	}
+}
+
+// CallbackHandler completes a login: it checks the state of the redirect of
+// the IdP and that the login is still pending, exchanges the code for a
+// token, and calls login with it.
+func (c *Client) CallbackHandler(login LoginFunc) server.HandlerFunc {
+	return func(ctx *server.Context) error {
+		id, err := ctx.GetCookie(CookieName)
+		if err != nil || id == "" {
+			return ErrInvalidState
+		}
+		sess, err := c.sessions.GetSession(id)
+		if err != nil {
+			return ErrInvalidState
+		}
+		// a pending login is completed once.
+		if err := c.sessions.DestroySession(id); err != nil {
+			return err
+		}
+		c.setCookie(ctx, "", -1)
+
+		// the cookie isn't enough, the session may outlive it.
+		expires, _ := sess.Data[expiresKey].(string)
+		if at, err := time.Parse(time.RFC3339Nano, expires); err != nil || time.Now().After(at) {
+			return ErrInvalidState
+		}
+		state, _ := sess.Data[stateKey].(string)
+		if state == "" || !equal(ctx.Query("state"), state) {
+			return ErrInvalidState
+		}
+		if code := ctx.Query("error"); code != "" {
+			return &Error{Code: code, Description: ctx.Query("error_description"), URI: ctx.Query("error_uri")}
+		}
+		code := ctx.Query("code")
+		if code == "" {
+			return ErrMissingCode
+		}
+
+		nonce, _ := sess.Data[nonceKey].(string)
+		verifier, _ := sess.Data[verifierKey].(string)
+		token, err := c.Exchange(ctx, code, verifier, nonce)
+		if err != nil {
+			return err
+		}
+		if err := login(ctx, token); err != nil {
+			return err
+		}
+		next, _ := sess.Data[nextKey].(string)
+		http.Redirect(ctx.Response, ctx.Request, localURL(next), http.StatusFound)
+		return nil
+	}
+}
+
+// Register registers the login handler at loginPath, and the callback
+// handler at the path of the redirect URL.
+func (c *Client) Register(r server.IRoute, loginPath string, login LoginFunc) error {
+	redirect, err := url.Parse(c.config.RedirectURL)
+	if err != nil {
+		return err
+	}
+	r.GET(loginPath, c.LoginHandler())
+	r.GET(redirect.Path, c.CallbackHandler(login))
+	return nil
+}
+
+func (c *Client) setCookie(ctx *server.Context, value string, maxAge int) {
+	http.SetCookie(ctx.Response, &http.Cookie{
+		Name:     CookieName,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   maxAge,
+		Secure:   c.secure,
+		HttpOnly: true,
+		// the IdP redirects to the callback with a top-level navigation.
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// localURL returns u if it's a path on this site, "/" otherwise, so that
+// logins can't redirect to other sites.
+func localURL(u string) string {
+	if !strings.HasPrefix(u, "/") || strings.HasPrefix(u, "//") || strings.HasPrefix(u, "/\\") {
+		return "/"
+	}
+	return u
+}
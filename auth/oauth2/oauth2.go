@@ -0,0 +1,337 @@
+package oauth2
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/yates-z/easel/auth/authentication/jwt"
+	"github.com/yates-z/easel/auth/authentication/jwt/jwks"
+	"github.com/yates-z/easel/auth/authentication/session"
+)
+
+// Endpoint are the URLs of an IdP.
+type Endpoint struct {
+	// Issuer is the "iss" claim of the ID tokens of an OpenID provider.
+	Issuer   string `json:"issuer"`
+	AuthURL  string `json:"authorization_endpoint"`
+	TokenURL string `json:"token_endpoint"`
+	// JWKSURL is the URL of the keys verifying the ID tokens.
+	JWKSURL string `json:"jwks_uri"`
+}
+
+// Config is the registration of the client at the IdP.
+type Config struct {
+	ClientID string
+	// ClientSecret is empty for public clients, which rely on PKCE only.
+	ClientSecret string
+	// RedirectURL is the URL of the callback handler.
+	RedirectURL string
+	// Scopes are the requested scopes. With the "openid" scope, the token
+	// response must have an ID token.
+	Scopes   []string
+	Endpoint Endpoint
+}
+
+// Token is the token response of the IdP.
+type Token struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	IDToken      string `json:"id_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+	ExpiresIn    int64  `json:"expires_in,omitempty"`
+	// Expiry is when the access token expires, zero if unknown.
+	Expiry time.Time `json:"-"`
+	// IDClaims are the claims of the validated ID token, if any.
+	IDClaims *IDClaims `json:"-"`
+}
+
+// Expired reports whether the access token expired.
+func (t *Token) Expired() bool {
+	return !t.Expiry.IsZero() && time.Now().After(t.Expiry)
+}
+
+// IDClaims are the claims of an OpenID Connect ID token.
+type IDClaims struct {
+	jwt.RegisteredClaims
+	Nonce         string `json:"nonce,omitempty"`
+	AuthTime      int64  `json:"auth_time,omitempty"`
+	AZP           string `json:"azp,omitempty"`
+	Name          string `json:"name,omitempty"`
+	Email         string `json:"email,omitempty"`
+	EmailVerified bool   `json:"email_verified,omitempty"`
+}
+
+type Option func(*Client)
+
+// HTTPClient with the client requesting the IdP.
+func HTTPClient(c *http.Client) Option {
+	return func(cl *Client) {
+		cl.client = c
+	}
+}
+
+// IDTokenKeys with the keys verifying ID tokens. The default fetches the keys
+// at the JWKSURL of the endpoint.
+func IDTokenKeys(keys jwt.KeyResolver) Option {
+	return func(cl *Client) {
+		cl.keys = keys
+	}
+}
+
+// IDTokenMethods with the signing methods of the accepted ID tokens, the
+// asymmetric ones by default.
+func IDTokenMethods(algs ...string) Option {
+	return func(cl *Client) {
+		cl.methods = algs
+	}
+}
+
+// IDTokenLeeway with the tolerated clock skew with the IdP, 1 minute by default.
+func IDTokenLeeway(d time.Duration) Option {
+	return func(cl *Client) {
+		cl.leeway = d
+	}
+}
+
+// SecureCookie sends the cookie of pending logins over HTTPS only.
+func SecureCookie() Option {
+	return func(cl *Client) {
+		cl.secure = true
+	}
+}
+
+// Client logs users in through an IdP with the authorization code flow and
+// PKCE. The state of pending logins is stored in a session of the manager.
+type Client struct {
+	config   Config
+	sessions *session.SessionManager
+	client   *http.Client
+	keys     jwt.KeyResolver
+	methods  []string
+	leeway   time.Duration
+	secure   bool
+}
+
+// NewClient creates a Client storing the pending logins in sessions.
+func NewClient(config Config, sessions *session.SessionManager, opts ...Option) *Client {
+	c := &Client{
+		config:   config,
+		sessions: sessions,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		methods:  []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"},
+		leeway:   time.Minute,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.keys == nil && config.Endpoint.JWKSURL != "" {
+		c.keys = jwks.NewResolver(config.Endpoint.JWKSURL, jwks.HTTPClient(c.client))
+	}
+	return c
+}
+
+// Discover fetches the endpoint of an OpenID provider from its discovery
+// document, at issuer + "/.well-known/openid-configuration".
+func Discover(ctx context.Context, issuer string, client *http.Client) (Endpoint, error) {
+	var endpoint Endpoint
+	if client == nil {
+		client = http.DefaultClient
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(issuer, "/")+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return endpoint, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return endpoint, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return endpoint, fmt.Errorf("oauth2: discovery: unexpected status %s", resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&endpoint); err != nil {
+		return endpoint, fmt.Errorf("oauth2: discovery: %w", err)
+	}
+	// the issuer must be the one the document was fetched for, see OpenID
+	// Connect Discovery section 4.3.
+	if endpoint.Issuer != issuer {
+		return endpoint, fmt.Errorf("oauth2: discovery: issuer %q doesn't match %q", endpoint.Issuer, issuer)
+	}
+	return endpoint, nil
+}
+
+// randomString returns a random URL safe string of n bytes of entropy.
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// equal compares secrets in constant time.
+func equal(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+// codeChallenge returns the S256 PKCE challenge of verifier, see RFC 7636.
+func codeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// AuthCodeURL returns the URL of the IdP the user is redirected to, to log in.
+func (c *Client) AuthCodeURL(state, nonce, verifier string) string {
+	params := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {c.config.ClientID},
+		"redirect_uri":          {c.config.RedirectURL},
+		"state":                 {state},
+		"code_challenge":        {codeChallenge(verifier)},
+		"code_challenge_method": {"S256"},
+	}
+	if len(c.config.Scopes) > 0 {
+		params.Set("scope", strings.Join(c.config.Scopes, " "))
+	}
+	if nonce != "" {
+		params.Set("nonce", nonce)
+	}
+	sep := "?"
+	if strings.Contains(c.config.Endpoint.AuthURL, "?") {
+		sep = "&"
+	}
+	return c.config.Endpoint.AuthURL + sep + params.Encode()
+}
+
+// Exchange exchanges an authorization code for a token. The ID token of the
+// response is validated, and must carry nonce.
+func (c *Client) Exchange(ctx context.Context, code, verifier, nonce string) (*Token, error) {
+	token, err := c.token(ctx, url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {c.config.RedirectURL},
+		"code_verifier": {verifier},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if token.IDToken == "" {
+		if slices.Contains(c.config.Scopes, "openid") {
+			return nil, ErrMissingIDToken
+		}
+		return token, nil
+	}
+	if token.IDClaims, err = c.VerifyIDToken(token.IDToken); err != nil {
+		return nil, err
+	}
+	if nonce != "" && token.IDClaims.Nonce != nonce {
+		return nil, ErrInvalidNonce
+	}
+	return token, nil
+}
+
+// Refresh renews a token with its refresh token. The refresh token is kept
+// if the IdP doesn't rotate it.
+func (c *Client) Refresh(ctx context.Context, refreshToken string) (*Token, error) {
+	if refreshToken == "" {
+		return nil, ErrNoRefreshToken
+	}
+	token, err := c.token(ctx, url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if token.RefreshToken == "" {
+		token.RefreshToken = refreshToken
+	}
+	if token.IDToken != "" {
+		if token.IDClaims, err = c.VerifyIDToken(token.IDToken); err != nil {
+			return nil, err
+		}
+	}
+	return token, nil
+}
+
+// VerifyIDToken verifies the signature, issuer, audience and expiration of
+// an ID token, and returns its claims.
+func (c *Client) VerifyIDToken(raw string) (*IDClaims, error) {
+	if c.keys == nil {
+		return nil, fmt.Errorf("oauth2: no keys verifying id tokens")
+	}
+	opts := []jwt.ValidateOption{
+		jwt.WithAudience(c.config.ClientID),
+		jwt.WithMethods(c.methods...),
+		jwt.WithLeeway(c.leeway),
+	}
+	if c.config.Endpoint.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(c.config.Endpoint.Issuer))
+	}
+	token, err := jwt.Parse[IDClaims](raw, c.keys, opts...)
+	if err != nil {
+		return nil, err
+	}
+	// with several audiences, the client must be the authorized party.
+	if len(token.Claims.Aud) > 1 && token.Claims.AZP != c.config.ClientID {
+		return nil, jwt.ErrAudienceInvalid
+	}
+	return &token.Claims, nil
+}
+
+// token requests the token endpoint, see RFC 6749 section 4.1.3.
+func (c *Client) token(ctx context.Context, params url.Values) (*Token, error) {
+	if c.config.ClientSecret == "" {
+		params.Set("client_id", c.config.ClientID)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.config.Endpoint.TokenURL, strings.NewReader(params.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if c.config.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(c.config.ClientID), url.QueryEscape(c.config.ClientSecret))
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType != "application/json" {
+		return nil, fmt.Errorf("%w: status %s, content type %q", ErrInvalidResponse, resp.Status, mediaType)
+	}
+	if resp.StatusCode != http.StatusOK {
+		e := &Error{}
+		if err := json.Unmarshal(body, e); err != nil || e.Code == "" {
+			return nil, fmt.Errorf("%w: status %s", ErrInvalidResponse, resp.Status)
+		}
+		return nil, e
+	}
+	token := &Token{}
+	if err := json.Unmarshal(body, token); err != nil || token.AccessToken == "" {
+		return nil, ErrInvalidResponse
+	}
+	if token.ExpiresIn > 0 {
+		token.Expiry = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+	return token, nil
+}
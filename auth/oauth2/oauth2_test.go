@@ -0,0 +1,267 @@
+package oauth2
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yates-z/easel/auth/authentication/jwt"
+	"github.com/yates-z/easel/auth/authentication/session"
+	"github.com/yates-z/easel/transport/http/server"
+	sessionmw "github.com/yates-z/easel/transport/http/server/middlewares/session"
+	"github.com/yates-z/easel/transport/http/server/servertest"
+)
+
+// idp is a fake OpenID provider.
+type idp struct {
+	*httptest.Server
+	t    *testing.T
+	key  *rsa.PrivateKey
+	keys *jwt.KeySet
+
+	mu    sync.Mutex
+	codes map[string]url.Values
+	// nonce overrides the nonce of the ID tokens.
+	nonce string
+}
+
+func newIdP(t *testing.T) *idp {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &idp{t: t, key: key, keys: jwt.NewKeySet(), codes: make(map[string]url.Values)}
+	jwt.AddKey(p.keys, "k1", "RS256", &key.PublicKey)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, Endpoint{Issuer: p.URL, AuthURL: p.URL + "/authorize", TokenURL: p.URL + "/token", JWKSURL: p.URL + "/jwks"})
+	})
+	mux.HandleFunc("GET /jwks", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, p.keys.JWKS())
+	})
+	mux.HandleFunc("GET /authorize", p.authorize)
+	mux.HandleFunc("POST /token", p.token)
+	p.Server = httptest.NewServer(mux)
+	t.Cleanup(p.Close)
+	return p
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+// authorize logs the user in at once and redirects back with a code.
+func (p *idp) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("response_type") != "code" || q.Get("code_challenge_method") != "S256" || q.Get("client_id") != "client" {
+		p.t.Errorf("unexpected authorization request %v", q)
+	}
+	code, _ := randomString(16)
+	p.mu.Lock()
+	p.codes[code] = q
+	p.mu.Unlock()
+	http.Redirect(w, r, q.Get("redirect_uri")+"?"+url.Values{"code": {code}, "state": {q.Get("state")}}.Encode(), http.StatusFound)
+}
+
+func (p *idp) token(w http.ResponseWriter, r *http.Request) {
+	if id, secret, _ := r.BasicAuth(); id != "client" || secret != "secret" {
+		writeJSON(w, http.StatusUnauthorized, Error{Code: "invalid_client"})
+		return
+	}
+	r.ParseForm()
+	switch r.PostForm.Get("grant_type") {
+	case "authorization_code":
+		p.mu.Lock()
+		q, ok := p.codes[r.PostForm.Get("code")]
+		delete(p.codes, r.PostForm.Get("code"))
+		p.mu.Unlock()
+		if !ok || codeChallenge(r.PostForm.Get("code_verifier")) != q.Get("code_challenge") || r.PostForm.Get("redirect_uri") != q.Get("redirect_uri") {
+			writeJSON(w, http.StatusBadRequest, Error{Code: "invalid_grant"})
+			return
+		}
+		nonce := q.Get("nonce")
+		if p.nonce != "" {
+			nonce = p.nonce
+		}
+		writeJSON(w, http.StatusOK, Token{AccessToken: "access-1", TokenType: "Bearer", RefreshToken: "refresh-1", ExpiresIn: 3600, IDToken: p.idToken(nonce)})
+	case "refresh_token":
+		if r.PostForm.Get("refresh_token") != "refresh-1" {
+			writeJSON(w, http.StatusBadRequest, Error{Code: "invalid_grant", Description: "unknown refresh token"})
+			return
+		}
+		writeJSON(w, http.StatusOK, Token{AccessToken: "access-2", TokenType: "Bearer", ExpiresIn: 3600})
+	}
+}
+
+func (p *idp) idToken(nonce string) string {
+	now := time.Now()
+	token := jwt.NewToken(jwt.NewMethodRS256, IDClaims{
+		RegisteredClaims: jwt.RegisteredClaims{Iss: p.URL, Sub: "alice", Aud: jwt.Audience{"client"}, Exp: now.Add(time.Hour).Unix(), Iat: now.Unix()},
+		Nonce:            nonce,
+		Email:            "alice@example.com",
+	})
+	token.Header.Kid = "k1"
+	raw, err := jwt.Sign(token, p.key)
+	if err != nil {
+		p.t.Fatal(err)
+	}
+	return raw
+}
+
+func newApp(t *testing.T, p *idp) (*servertest.Harness, *Client) {
+	endpoint, err := Discover(context.Background(), p.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sm := session.NewSessionManager(session.NewCacheSessionBackend(1, 100, time.Minute))
+	client := NewClient(Config{
+		ClientID:     "client",
+		ClientSecret: "secret",
+		RedirectURL:  "http://app.test/oauth2/callback",
+		Scopes:       []string{"openid", "email"},
+		Endpoint:     endpoint,
+	}, sm)
+
+	s := server.NewServer()
+	app := s.Group("", sessionmw.Middleware(sm, sessionmw.Anonymous("/oauth2/*")))
+	err = client.Register(app, "/oauth2/login", func(ctx *server.Context, token *Token) error {
+		sess, _ := sessionmw.FromContext(ctx)
+		sess.Data["user"] = token.IDClaims.Email
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.GET("/profile", func(c *server.Context) error {
+		sess, _ := sessionmw.FromContext(c)
+		return c.String(http.StatusOK, sess.Data["user"].(string))
+	})
+	return servertest.New(t, s), client
+}
+
+// redirect returns the location of a redirect response.
+func redirect(t *testing.T, resp *servertest.Response) string {
+	t.Helper()
+	return resp.Status(http.StatusFound).Raw().Header.Get("Location")
+}
+
+// authorize follows the redirect to the IdP, and returns the callback URL.
+func authorize(t *testing.T, authURL string) string {
+	t.Helper()
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	callback, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return callback.RequestURI()
+}
+
+func TestLogin(t *testing.T) {
+	p := newIdP(t)
+	h, _ := newApp(t, p)
+
+	h.GET("/profile").Expect().Status(http.StatusUnauthorized)
+	callback := authorize(t, redirect(t, h.GET("/oauth2/login?next=/profile").Expect()))
+	if next := redirect(t, h.GET(callback).Expect()); next != "/profile" {
+		t.Fatalf("unexpected redirect %s", next)
+	}
+	h.GET("/profile").Expect().Status(http.StatusOK).BodyEqual("alice@example.com")
+
+	// the state of a login is used once.
+	h.GET(callback).Expect().Status(http.StatusBadRequest)
+}
+
+func TestLoginRejected(t *testing.T) {
+	p := newIdP(t)
+	h, _ := newApp(t, p)
+
+	// the callback must come from the browser that started the login.
+	callback := authorize(t, redirect(t, h.GET("/oauth2/login?next=//evil.test").Expect()))
+	h.ClearCookies()
+	h.GET(callback).Expect().Status(http.StatusBadRequest)
+
+	// open redirects are prevented.
+	callback = authorize(t, redirect(t, h.GET("/oauth2/login?next=//evil.test").Expect()))
+	if next := redirect(t, h.GET(callback).Expect()); next != "/" {
+		t.Fatalf("unexpected redirect %s", next)
+	}
+
+	p.nonce = "replayed"
+	callback = authorize(t, redirect(t, h.GET("/oauth2/login").Expect()))
+	h.GET(callback).Expect().Status(http.StatusBadRequest).BodyContains(ErrInvalidNonce.Error())
+	p.nonce = ""
+
+	authURL, _ := url.Parse(redirect(t, h.GET("/oauth2/login").Expect()))
+	q := authURL.Query()
+	h.GET("/oauth2/callback?" + url.Values{"state": {q.Get("state")}, "error": {"access_denied"}}.Encode()).Expect().
+		Status(http.StatusBadRequest).
+		BodyContains("access_denied")
+}
+
+func TestLoginExpired(t *testing.T) {
+	p := newIdP(t)
+	h, client := newApp(t, p)
+
+	callback := authorize(t, redirect(t, h.GET("/oauth2/login").Expect()))
+	sess, err := client.sessions.GetSession(h.Cookie(CookieName).Value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sess.Data[expiresKey] = time.Now().Add(-time.Second).Format(time.RFC3339Nano)
+	if err = client.sessions.SaveSession(sess); err != nil {
+		t.Fatal(err)
+	}
+	h.GET(callback).Expect().Status(http.StatusBadRequest).BodyContains(ErrInvalidState.Error())
+}
+
+func TestRefresh(t *testing.T) {
+	p := newIdP(t)
+	_, client := newApp(t, p)
+
+	token, err := client.Refresh(context.Background(), "refresh-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.AccessToken != "access-2" || token.RefreshToken != "refresh-1" || token.Expired() {
+		t.Fatalf("unexpected token %+v", token)
+	}
+	var e *Error
+	if _, err := client.Refresh(context.Background(), "unknown"); !errors.As(err, &e) || e.Code != "invalid_grant" {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	p := newIdP(t)
+	_, client := newApp(t, p)
+
+	claims, err := client.VerifyIDToken(p.idToken("n"))
+	if err != nil || claims.Sub != "alice" || claims.Nonce != "n" {
+		t.Fatalf("unexpected claims %+v, error %v", claims, err)
+	}
+
+	other := newIdP(t)
+	if _, err := client.VerifyIDToken(other.idToken("n")); err == nil {
+		t.Fatal("id token of another issuer accepted")
+	}
+	hs, _ := jwt.Sign(jwt.NewToken(jwt.NewMethodHS256, IDClaims{RegisteredClaims: jwt.RegisteredClaims{Iss: p.URL, Aud: jwt.Audience{"client"}, Exp: time.Now().Add(time.Hour).Unix()}}), []byte("secret"))
+	if _, err := client.VerifyIDToken(hs); err == nil {
+		t.Fatal("symmetric id token accepted")
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+const (
+	contextPackage = protogen.GoImportPath("context")
+	serverPackage  = protogen.GoImportPath("github.com/yates-z/easel/transport/http/server")
+	adapterPackage = protogen.GoImportPath("github.com/yates-z/easel/transport/http/server/adapter")
+	clientPackage  = protogen.GoImportPath("github.com/yates-z/easel/transport/http/client")
+)
+
+// generateFile generates a _http.pb.go file containing easel HTTP handlers and clients.
+func generateFile(gen *protogen.Plugin, file *protogen.File) error {
+	if len(file.Services) == 0 {
+		return nil
+	}
+	filename := file.GeneratedFilenamePrefix + "_http.pb.go"
+	g := gen.NewGeneratedFile(filename, file.GoImportPath)
+	g.P("// Code generated by protoc-gen-go-easel. DO NOT EDIT.")
+	g.P("// versions:")
+	g.P("// - protoc-gen-go-easel ", version)
+	g.P("// - protoc              ", protocVersion(gen))
+	if file.Proto.GetOptions().GetDeprecated() {
+		g.P("// ", file.Desc.Path(), " is a deprecated file.")
+	} else {
+		g.P("// source: ", file.Desc.Path())
+	}
+	g.P()
+	g.P("package ", file.GoPackageName)
+	g.P()
+
+	for _, service := range file.Services {
+		if err := genService(g, service); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func genService(g *protogen.GeneratedFile, service *protogen.Service) error {
+	var methods []*protogen.Method
+	rules := make(map[*protogen.Method][]*httpRule)
+	for _, method := range service.Methods {
+		// Streaming RPCs have no HTTP mapping.
+		if method.Desc.IsStreamingClient() || method.Desc.IsStreamingServer() {
+			continue
+		}
+		r, err := methodRules(method)
+		if err != nil {
+			return err
+		}
+		methods = append(methods, method)
+		rules[method] = r
+	}
+
+	serverType := service.GoName + "Server"
+	clientType := service.GoName + "HTTPClient"
+
+	// Server registration.
+	g.P("// Register", service.GoName, "HTTPServer registers the ", service.GoName, " service on the router.")
+	g.P("// Requests are bound and replies are encoded by ", adapterPackage.Ident("GRPC"), ",")
+	g.P("// the routes are documented with the service name as tag.")
+	if service.Desc.Options().(*descriptorpb.ServiceOptions).GetDeprecated() {
+		g.P("//")
+		g.P(deprecationComment)
+	}
+	g.P("func Register", service.GoName, "HTTPServer(router ", serverPackage.Ident("IRouter"), ", srv ", serverType, ") {")
+	for _, method := range methods {
+		for _, r := range rules[method] {
+			g.P(adapterPackage.Ident("Handle"), "(router, ", strconv.Quote(r.method), ", ", strconv.Quote(r.path), ", srv.", method.GoName, ")",
+				routeMeta(service, method))
+		}
+	}
+	g.P("}")
+	g.P()
+
+	// Client interface.
+	g.P("// ", clientType, " is the HTTP client API for ", service.GoName, " service.")
+	g.P("type ", clientType, " interface {")
+	for _, method := range methods {
+		g.Annotate(clientType+"."+method.GoName, method.Location)
+		if method.Desc.Options().(*descriptorpb.MethodOptions).GetDeprecated() {
+			g.P(deprecationComment)
+		}
+		g.P(method.Comments.Leading, clientSignature(g, method))
+	}
+	g.P("}")
+	g.P()
+
+	// Client implementation.
+	unexported := unexport(clientType)
+	g.P("type ", unexported, " struct {")
+	g.P("cc *", clientPackage.Ident("Client"))
+	g.P("}")
+	g.P()
+	if service.Desc.Options().(*descriptorpb.ServiceOptions).GetDeprecated() {
+		g.P(deprecationComment)
+	}
+	g.P("func New", clientType, "(cc *", clientPackage.Ident("Client"), ") ", clientType, " {")
+	g.P("return &", unexported, "{cc}")
+	g.P("}")
+	g.P()
+	for _, method := range methods {
+		r := rules[method][0]
+		g.P("func (c *", unexported, ") ", clientSignature(g, method), " {")
+		g.P("out := new(", method.Output.GoIdent, ")")
+		g.P("path := ", clientPackage.Ident("EncodeURL"), "(", strconv.Quote(r.path), ", in, ", !r.hasBody(), ")")
+		body := "nil"
+		if r.hasBody() {
+			body = "in"
+		}
+		g.P("if err := c.cc.Invoke(ctx, ", strconv.Quote(r.method), ", path, ", body, ", out, opts...); err != nil {")
+		g.P("return nil, err")
+		g.P("}")
+		g.P("return out, nil")
+		g.P("}")
+		g.P()
+	}
+	return nil
+}
+
+func clientSignature(g *protogen.GeneratedFile, method *protogen.Method) string {
+	return fmt.Sprintf("%s(ctx %s, in *%s, opts ...%s) (*%s, error)",
+		method.GoName,
+		g.QualifiedGoIdent(contextPackage.Ident("Context")),
+		g.QualifiedGoIdent(method.Input.GoIdent),
+		g.QualifiedGoIdent(clientPackage.Ident("CallOption")),
+		g.QualifiedGoIdent(method.Output.GoIdent),
+	)
+}
+
+func protocVersion(gen *protogen.Plugin) string {
+	v := gen.Request.GetCompilerVersion()
+	if v == nil {
+		return "(unknown)"
+	}
+	var suffix string
+	if s := v.GetSuffix(); s != "" {
+		suffix = "-" + s
+	}
+	return fmt.Sprintf("v%d.%d.%d%s", v.GetMajor(), v.GetMinor(), v.GetPatch(), suffix)
+}
+
+func unexport(s string) string { return strings.ToLower(s[:1]) + s[1:] }
+
+const deprecationComment = "// Deprecated: Do not use."
+
+// routeMeta returns the chained Route calls documenting method.
+func routeMeta(service *protogen.Service, method *protogen.Method) string {
+	meta := ".Tags(" + strconv.Quote(string(service.Desc.FullName())) + ")"
+	if summary, _, _ := strings.Cut(strings.TrimSpace(string(method.Comments.Leading)), "\n"); summary != "" {
+		meta += ".Summary(" + strconv.Quote(summary) + ")"
+	}
+	if method.Desc.Options().(*descriptorpb.MethodOptions).GetDeprecated() {
+		meta += ".Deprecated()"
+	}
+	return meta
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/yates-z/easel/transport/grpc/server/test/api"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+func run(t *testing.T, files ...*descriptorpb.FileDescriptorProto) string {
+	t.Helper()
+	req := &pluginpb.CodeGeneratorRequest{ProtoFile: files}
+	for _, f := range files {
+		req.FileToGenerate = append(req.FileToGenerate, f.GetName())
+	}
+	gen, err := protogen.Options{}.New(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = generate(gen); err != nil {
+		t.Fatal(err)
+	}
+	resp := gen.Response()
+	if resp.Error != nil {
+		t.Fatal(resp.GetError())
+	}
+	var b strings.Builder
+	for _, f := range resp.File {
+		b.WriteString(f.GetContent())
+	}
+	return b.String()
+}
+
+func httpOption(rule []byte) *descriptorpb.MethodOptions {
+	opts := &descriptorpb.MethodOptions{}
+	var b []byte
+	b = protowire.AppendTag(b, httpExtensionNumber, protowire.BytesType)
+	b = protowire.AppendBytes(b, rule)
+	opts.ProtoReflect().SetUnknown(b)
+	return opts
+}
+
+func appendString(b []byte, num protowire.Number, s string) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, s)
+}
+
+func TestGenerateDefaultRoute(t *testing.T) {
+	file := protodesc.ToFileDescriptorProto(api.File_api_hello_proto)
+	out := run(t, file)
+	for _, want := range []string{
+		"func RegisterGreeterHTTPServer(router server.IRouter, srv GreeterServer)",
+		`adapter.Handle(router, "POST", "/pb.Greeter/SayHello", srv.SayHello).Tags("pb.Greeter")`,
+		"SayHello(ctx context.Context, in *HelloRequest, opts ...client.CallOption) (*HelloResponse, error)",
+		`client.EncodeURL("/pb.Greeter/SayHello", in, false)`,
+		`c.cc.Invoke(ctx, "POST", path, in, out, opts...)`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("generated code does not contain %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestGenerateHTTPRule(t *testing.T) {
+	file := protodesc.ToFileDescriptorProto(api.File_api_hello_proto)
+	var additional []byte
+	additional = appendString(additional, 4, "/v1/hello")
+	additional = appendString(additional, 7, "*")
+	var rule []byte
+	rule = appendString(rule, 2, "/v1/hello/{name=*}")
+	rule = appendString(rule, 11, string(additional))
+	file.Service[0].Method[0].Options = httpOption(rule)
+
+	out := run(t, file)
+	for _, want := range []string{
+		`adapter.Handle(router, "GET", "/v1/hello/{name}", srv.SayHello)`,
+		`adapter.Handle(router, "POST", "/v1/hello", srv.SayHello)`,
+		`client.EncodeURL("/v1/hello/{name}", in, true)`,
+		`c.cc.Invoke(ctx, "GET", path, nil, out, opts...)`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("generated code does not contain %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestGenerateUnsupportedBody(t *testing.T) {
+	file := protodesc.ToFileDescriptorProto(api.File_api_hello_proto)
+	var rule []byte
+	rule = appendString(rule, 4, "/v1/hello")
+	rule = appendString(rule, 7, "name")
+	file.Service[0].Method[0].Options = httpOption(rule)
+
+	req := &pluginpb.CodeGeneratorRequest{ProtoFile: []*descriptorpb.FileDescriptorProto{file}, FileToGenerate: []string{file.GetName()}}
+	gen, err := protogen.Options{}.New(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = generate(gen); err == nil {
+		t.Fatal("expected an error for a body field selector")
+	}
+}
+
+func TestRoutePath(t *testing.T) {
+	cases := []struct {
+		template string
+		want     string
+		err      bool
+	}{
+		{"/v1/users", "/v1/users", false},
+		{"/v1/users/{id}", "/v1/users/{id}", false},
+		{"/v1/users/{id=*}/books", "/v1/users/{id}/books", false},
+		{"/v1/{name=messages/*}", "/v1/{name...}", false},
+		{"/v1/files/{path=**}", "/v1/files/{path...}", false},
+		{"/v1/{name=messages/*}/x", "", true},
+		{"/v1/users/{user.id}", "", true},
+		{"/v1/users/{id}:cancel", "", true},
+		{"v1/users", "", true},
+	}
+	for _, c := range cases {
+		got, err := routePath(c.template)
+		if (err != nil) != c.err {
+			t.Fatalf("routePath(%q) error = %v, want error %v", c.template, err, c.err)
+		}
+		if got != c.want {
+			t.Fatalf("routePath(%q) = %q, want %q", c.template, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// httpExtensionNumber is the field number of the google.api.http method option.
+// The option is read from the raw descriptor so that googleapis does not have to be a dependency.
+const httpExtensionNumber protowire.Number = 72295728
+
+// httpRule is the subset of google.api.HttpRule that adapter.GRPC can serve.
+type httpRule struct {
+	method       string
+	path         string
+	body         string
+	responseBody string
+	additional   []*httpRule
+}
+
+// hasBody reports whether the request message is sent as the request body.
+func (r *httpRule) hasBody() bool {
+	return r.body != ""
+}
+
+// methodRules returns the HTTP rules of a method. Methods without a google.api.http
+// option are served at "POST /{package}.{Service}/{Method}" with the whole message as body.
+func methodRules(m *protogen.Method) ([]*httpRule, error) {
+	rule, err := findHTTPRule(m)
+	if err != nil {
+		return nil, err
+	}
+	if rule == nil {
+		return []*httpRule{{
+			method: http.MethodPost,
+			path:   fmt.Sprintf("/%s/%s", m.Parent.Desc.FullName(), m.Desc.Name()),
+			body:   "*",
+		}}, nil
+	}
+	rules := append([]*httpRule{rule}, rule.additional...)
+	for _, r := range rules {
+		if r.method == "" || r.path == "" {
+			return nil, fmt.Errorf("%s: google.api.http pattern is empty", m.Desc.FullName())
+		}
+		if r.body != "" && r.body != "*" {
+			return nil, fmt.Errorf("%s: body %q is not supported, adapter.GRPC binds the whole message, use body: \"*\"", m.Desc.FullName(), r.body)
+		}
+		if r.responseBody != "" {
+			return nil, fmt.Errorf("%s: response_body is not supported", m.Desc.FullName())
+		}
+		path, err := routePath(r.path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", m.Desc.FullName(), err)
+		}
+		r.path = path
+	}
+	return rules, nil
+}
+
+func findHTTPRule(m *protogen.Method) (*httpRule, error) {
+	opts, ok := m.Desc.Options().(*descriptorpb.MethodOptions)
+	if !ok || opts == nil {
+		return nil, nil
+	}
+	// Extensions that are not linked into the binary are kept as unknown fields,
+	// marshaling the options gives back the wire bytes in both cases.
+	b, err := proto.Marshal(opts)
+	if err != nil {
+		return nil, err
+	}
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		b = b[n:]
+		if num == httpExtensionNumber && typ == protowire.BytesType {
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return nil, protowire.ParseError(n)
+			}
+			return parseHTTPRule(v)
+		}
+		n = protowire.ConsumeFieldValue(num, typ, b)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		b = b[n:]
+	}
+	return nil, nil
+}
+
+// parseHTTPRule decodes a google.api.HttpRule message.
+func parseHTTPRule(b []byte) (*httpRule, error) {
+	rule := &httpRule{}
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		b = b[n:]
+		if typ != protowire.BytesType {
+			n = protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return nil, protowire.ParseError(n)
+			}
+			b = b[n:]
+			continue
+		}
+		v, n := protowire.ConsumeBytes(b)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		b = b[n:]
+		switch num {
+		case 2:
+			rule.method, rule.path = http.MethodGet, string(v)
+		case 3:
+			rule.method, rule.path = http.MethodPut, string(v)
+		case 4:
+			rule.method, rule.path = http.MethodPost, string(v)
+		case 5:
+			rule.method, rule.path = http.MethodDelete, string(v)
+		case 6:
+			rule.method, rule.path = http.MethodPatch, string(v)
+		case 7:
+			rule.body = string(v)
+		case 8:
+			kind, path, err := parseCustomPattern(v)
+			if err != nil {
+				return nil, err
+			}
+			rule.method, rule.path = strings.ToUpper(kind), path
+		case 11:
+			additional, err := parseHTTPRule(v)
+			if err != nil {
+				return nil, err
+			}
+			rule.additional = append(rule.additional, additional)
+		case 12:
+			rule.responseBody = string(v)
+		}
+	}
+	return rule, nil
+}
+
+// parseCustomPattern decodes a google.api.CustomHttpPattern message.
+func parseCustomPattern(b []byte) (kind, path string, err error) {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return "", "", protowire.ParseError(n)
+		}
+		b = b[n:]
+		n = protowire.ConsumeFieldValue(num, typ, b)
+		if n < 0 {
+			return "", "", protowire.ParseError(n)
+		}
+		if typ == protowire.BytesType {
+			v, _ := protowire.ConsumeBytes(b)
+			switch num {
+			case 1:
+				kind = string(v)
+			case 2:
+				path = string(v)
+			}
+		}
+		b = b[n:]
+	}
+	return kind, path, nil
+}
+
+// routePath converts a google.api.http path template into a http.ServeMux pattern.
+//
+//	/v1/{name}             -> /v1/{name}
+//	/v1/{name=*}           -> /v1/{name}
+//	/v1/{name=messages/*}  -> /v1/{name...}
+//	/v1/{path=**}          -> /v1/{path...}
+func routePath(template string) (string, error) {
+	if !strings.HasPrefix(template, "/") {
+		return "", fmt.Errorf("path %q must start with \"/\"", template)
+	}
+	var b strings.Builder
+	for i := 0; i < len(template); {
+		c := template[i]
+		if c != '{' {
+			if c == ':' && !strings.Contains(template[i:], "/") {
+				return "", fmt.Errorf("path %q: custom verbs are not supported", template)
+			}
+			b.WriteByte(c)
+			i++
+			continue
+		}
+		end := strings.IndexByte(template[i:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("path %q: unclosed variable", template)
+		}
+		variable := template[i+1 : i+end]
+		i += end + 1
+		name, pattern, _ := strings.Cut(variable, "=")
+		name = strings.TrimSpace(name)
+		if strings.Contains(name, ".") {
+			return "", fmt.Errorf("path %q: nested field %q is not supported", template, name)
+		}
+		if template[i-end-2] != '/' || (i < len(template) && template[i] != '/') {
+			return "", fmt.Errorf("path %q: variable %q must be a whole path segment", template, name)
+		}
+		switch pattern {
+		case "", "*":
+			b.WriteString("{" + name + "}")
+		default:
+			if i != len(template) {
+				return "", fmt.Errorf("path %q: multi-segment variable %q must be the last segment", template, name)
+			}
+			b.WriteString("{" + name + "...}")
+		}
+	}
+	return b.String(), nil
+}
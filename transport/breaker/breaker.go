@@ -0,0 +1,250 @@
+// Package breaker stops calling failing dependencies, so that the callers
+// fail fast and the dependencies recover instead of being flooded by retries.
+//
+// A CircuitBreaker opens once the failure rate of its window is too high,
+// rejects the calls while open, and lets a few probes through once half-open
+// to decide whether to close again. An SRE breaker rejects a share of the
+// calls matching the failure rate instead, the adaptive throttling of the
+// Google SRE book.
+//
+//	g := breaker.NewGroup(func(name string) breaker.Breaker {
+//		return breaker.NewCircuitBreaker(breaker.WithName(name))
+//	})
+//	err := breaker.Do(g.Get("users"), func() error { ... }, nil)
+package breaker
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/yates-z/easel/core/signal"
+)
+
+var ErrOpen = errors.New("breaker: circuit is open")
+
+// State is the state of a breaker.
+type State int
+
+const (
+	// StateClosed breakers allow the calls.
+	StateClosed State = iota
+	// StateOpen breakers reject the calls.
+	StateOpen
+	// StateHalfOpen breakers allow a few probes.
+	StateHalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	}
+	return ""
+}
+
+// DoneFunc ends an allowed call, with whether it succeeded.
+type DoneFunc func(success bool)
+
+// Breaker allows the calls to a dependency while it isn't failing.
+type Breaker interface {
+	// Allow allows a call, which must be done once it returns, or returns ErrOpen.
+	Allow() (DoneFunc, error)
+	// State returns the state of the breaker.
+	State() State
+}
+
+// Do calls fn if b allows it, and marks it failed if it returns an error
+// reported by isFailure, any error if isFailure is nil.
+func Do(b Breaker, fn func() error, isFailure func(error) bool) error {
+	done, err := b.Allow()
+	if err != nil {
+		return err
+	}
+	// a panicking call is a failure
+	success := false
+	defer func() { done(success) }()
+	err = fn()
+	success = err == nil || (isFailure != nil && !isFailure(err))
+	return err
+}
+
+// StateChangeFunc is called when the state of the breaker named name changes.
+type StateChangeFunc func(name string, from, to State)
+
+type Option func(*options)
+
+type options struct {
+	name          string
+	window        time.Duration
+	buckets       int
+	failureRate   float64
+	minRequests   int64
+	openTimeout   time.Duration
+	halfOpenCalls int64
+	k             float64
+	onStateChange []StateChangeFunc
+	signal        *signal.Signal
+	now           func() time.Time
+}
+
+// WithName with the name of the breaker passed to the state callbacks.
+func WithName(name string) Option {
+	return func(o *options) {
+		o.name = name
+	}
+}
+
+// Window with the period of the counted calls, 10 seconds by default, split
+// in buckets, 10 by default.
+func Window(d time.Duration, buckets int) Option {
+	return func(o *options) {
+		o.window = d
+		o.buckets = buckets
+	}
+}
+
+// FailureRate with the rate of failed calls of the window beyond which a
+// CircuitBreaker opens, 0.5 by default.
+func FailureRate(rate float64) Option {
+	return func(o *options) {
+		o.failureRate = rate
+	}
+}
+
+// MinRequests with the number of calls of the window below which the
+// breakers never reject, 20 by default.
+func MinRequests(n int64) Option {
+	return func(o *options) {
+		o.minRequests = n
+	}
+}
+
+// OpenTimeout with the time a CircuitBreaker stays open before it's
+// half-open, 5 seconds by default.
+func OpenTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.openTimeout = d
+	}
+}
+
+// HalfOpenCalls with the number of probes of a half-open CircuitBreaker,
+// all of them must succeed to close it, 5 by default.
+func HalfOpenCalls(n int64) Option {
+	return func(o *options) {
+		o.halfOpenCalls = n
+	}
+}
+
+// K with the multiplier of the accepted calls of an SRE breaker, 1.5 by
+// default: it rejects the calls beyond K times the accepted ones. A lower K
+// rejects more aggressively.
+func K(k float64) Option {
+	return func(o *options) {
+		o.k = k
+	}
+}
+
+// OnStateChange with a function called when the state of the breaker changes.
+// An SRE breaker is open while it rejects calls.
+func OnStateChange(f StateChangeFunc) Option {
+	return func(o *options) {
+		o.onStateChange = append(o.onStateChange, f)
+	}
+}
+
+// WithSignal emits the state changes to s, with the arguments of a
+// StateChangeFunc.
+func WithSignal(s *signal.Signal) Option {
+	return func(o *options) {
+		o.signal = s
+	}
+}
+
+// WithTimeFunc with the clock of the breaker, time.Now by default.
+func WithTimeFunc(now func() time.Time) Option {
+	return func(o *options) {
+		o.now = now
+	}
+}
+
+func newOptions(opts ...Option) *options {
+	o := &options{
+		window:        10 * time.Second,
+		buckets:       10,
+		failureRate:   0.5,
+		minRequests:   20,
+		openTimeout:   5 * time.Second,
+		halfOpenCalls: 5,
+		k:             1.5,
+		now:           time.Now,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.buckets <= 0 {
+		o.buckets = 1
+	}
+	if o.window < time.Duration(o.buckets) {
+		o.window = time.Duration(o.buckets)
+	}
+	return o
+}
+
+// transition is a state change, notified once the lock of the breaker is
+// released so that the callbacks may use the breaker.
+type transition struct {
+	from, to State
+}
+
+// notify notifies state changes.
+func (o *options) notify(transitions []transition) {
+	for _, t := range transitions {
+		for _, f := range o.onStateChange {
+			f(o.name, t.from, t.to)
+		}
+		if o.signal != nil {
+			o.signal.Emit(o.name, t.from, t.to)
+		}
+	}
+}
+
+// Group holds a breaker per key, e.g. per target and method.
+type Group struct {
+	newBreaker func(name string) Breaker
+	breakers   sync.Map
+	// mu serializes the creation of the breakers, so that newBreaker is
+	// called once per key, not by every concurrent miss.
+	mu sync.Mutex
+}
+
+// NewGroup creates a Group creating the breaker of a key with newBreaker,
+// when it's first used. A nil newBreaker creates CircuitBreakers named by
+// their keys.
+func NewGroup(newBreaker func(name string) Breaker) *Group {
+	if newBreaker == nil {
+		newBreaker = func(name string) Breaker {
+			return NewCircuitBreaker(WithName(name))
+		}
+	}
+	return &Group{newBreaker: newBreaker}
+}
+
+// Get returns the breaker of key.
+func (g *Group) Get(key string) Breaker {
+	if b, ok := g.breakers.Load(key); ok {
+		return b.(Breaker)
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if b, ok := g.breakers.Load(key); ok {
+		return b.(Breaker)
+	}
+	b := g.newBreaker(key)
+	g.breakers.Store(key, b)
+	return b
+}
@@ -0,0 +1,249 @@
+package breaker
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// clock is a manual clock.
+type clock struct {
+	t time.Time
+}
+
+func (c *clock) now() time.Time {
+	return c.t
+}
+
+func (c *clock) advance(d time.Duration) {
+	c.t = c.t.Add(d)
+}
+
+type change struct {
+	from, to State
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	c := &clock{t: time.Unix(1000, 0)}
+	var changes []change
+	b := NewCircuitBreaker(
+		WithName("users"),
+		MinRequests(4),
+		HalfOpenCalls(2),
+		OpenTimeout(time.Second),
+		WithTimeFunc(c.now),
+		OnStateChange(func(name string, from, to State) {
+			if name != "users" {
+				t.Fatalf("unexpected name %q", name)
+			}
+			changes = append(changes, change{from, to})
+		}),
+	)
+
+	call := func(success bool) error {
+		done, err := b.Allow()
+		if err != nil {
+			return err
+		}
+		done(success)
+		return nil
+	}
+
+	// too few requests to open
+	for range 3 {
+		if err := call(false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// a call allowed while closed and done once the breaker is open is ignored
+	stale, err := b.Allow()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := call(false); err != nil {
+		t.Fatal(err)
+	}
+	if b.State() != StateOpen {
+		t.Fatalf("unexpected state %s", b.State())
+	}
+	stale(true)
+	if err := call(true); !errors.Is(err, ErrOpen) {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	c.advance(time.Second)
+	if b.State() != StateHalfOpen {
+		t.Fatalf("unexpected state %s", b.State())
+	}
+	probe1, _ := b.Allow()
+	probe2, _ := b.Allow()
+	if _, err := b.Allow(); !errors.Is(err, ErrOpen) {
+		t.Fatalf("unexpected error %v, want the probes limited", err)
+	}
+	probe1(true)
+	probe2(false)
+	if b.State() != StateOpen {
+		t.Fatalf("unexpected state %s after a failed probe", b.State())
+	}
+
+	c.advance(time.Second)
+	for range 2 {
+		if err := call(true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if b.State() != StateClosed {
+		t.Fatalf("unexpected state %s", b.State())
+	}
+	want := []change{
+		{StateClosed, StateOpen},
+		{StateOpen, StateHalfOpen},
+		{StateHalfOpen, StateOpen},
+		{StateOpen, StateHalfOpen},
+		{StateHalfOpen, StateClosed},
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("unexpected changes %v", changes)
+	}
+	for i := range want {
+		if changes[i] != want[i] {
+			t.Fatalf("unexpected changes %v", changes)
+		}
+	}
+}
+
+func TestCircuitBreakerWindow(t *testing.T) {
+	c := &clock{t: time.Unix(1000, 0)}
+	b := NewCircuitBreaker(MinRequests(4), Window(time.Second, 10), WithTimeFunc(c.now))
+
+	for range 3 {
+		done, _ := b.Allow()
+		done(false)
+	}
+	// the failures are out of the window
+	c.advance(time.Second)
+	for range 3 {
+		done, err := b.Allow()
+		if err != nil {
+			t.Fatal(err)
+		}
+		done(true)
+	}
+	done, _ := b.Allow()
+	done(false)
+	if b.State() != StateClosed {
+		t.Fatalf("unexpected state %s", b.State())
+	}
+}
+
+func TestSRE(t *testing.T) {
+	c := &clock{t: time.Unix(1000, 0)}
+	var changes []change
+	b := NewSRE(MinRequests(10), WithTimeFunc(c.now), OnStateChange(func(_ string, from, to State) {
+		changes = append(changes, change{from, to})
+	}))
+
+	for range 100 {
+		done, err := b.Allow()
+		if err != nil {
+			t.Fatal(err)
+		}
+		done(true)
+	}
+	// failures are throttled, but not all of them
+	rejected := 0
+	for range 1000 {
+		done, err := b.Allow()
+		if err != nil {
+			rejected++
+			continue
+		}
+		done(false)
+	}
+	if rejected == 0 || rejected == 1000 {
+		t.Fatalf("unexpected rejected calls %d", rejected)
+	}
+	if b.State() != StateOpen {
+		t.Fatalf("unexpected state %s", b.State())
+	}
+
+	c.advance(10 * time.Second)
+	if _, err := b.Allow(); err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 2 || changes[0] != (change{StateClosed, StateOpen}) || changes[1] != (change{StateOpen, StateClosed}) {
+		t.Fatalf("unexpected changes %v", changes)
+	}
+}
+
+func TestDo(t *testing.T) {
+	b := NewCircuitBreaker(MinRequests(2))
+	errIgnored := errors.New("ignored")
+	isFailure := func(err error) bool { return !errors.Is(err, errIgnored) }
+
+	if err := Do(b, func() error { return errIgnored }, isFailure); !errors.Is(err, errIgnored) {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if b.State() != StateClosed {
+		t.Fatalf("unexpected state %s", b.State())
+	}
+	Do(b, func() error { return errors.New("failure") }, isFailure)
+	if err := Do(b, func() error { return nil }, isFailure); !errors.Is(err, ErrOpen) {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestGroup(t *testing.T) {
+	g := NewGroup(nil)
+	if g.Get("a") != g.Get("a") || g.Get("a") == g.Get("b") {
+		t.Fatal("unexpected breakers")
+	}
+}
+
+func TestGroupCreatesOnce(t *testing.T) {
+	var created atomic.Int64
+	g := NewGroup(func(name string) Breaker {
+		created.Add(1)
+		return NewCircuitBreaker(WithName(name))
+	})
+	var wg sync.WaitGroup
+	for range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			g.Get("a")
+		}()
+	}
+	wg.Wait()
+	if n := created.Load(); n != 1 {
+		t.Fatalf("%d breakers created for a key", n)
+	}
+}
+
+func TestStateChangeCallback(t *testing.T) {
+	var b Breaker
+	var states []State
+	callback := OnStateChange(func(_ string, _, to State) {
+		// the breaker isn't locked by the callbacks
+		b.Allow()
+		states = append(states, b.State())
+	})
+
+	b = NewCircuitBreaker(MinRequests(1), callback)
+	done, _ := b.Allow()
+	done(false)
+	if len(states) != 1 || states[0] != StateOpen {
+		t.Fatalf("unexpected states %v", states)
+	}
+
+	states = nil
+	b = NewSRE(MinRequests(1), callback)
+	done, _ = b.Allow()
+	done(false)
+	b.Allow()
+	if len(states) != 1 {
+		t.Fatalf("unexpected states %v", states)
+	}
+}
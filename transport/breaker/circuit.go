@@ -0,0 +1,120 @@
+package breaker
+
+import (
+	"sync"
+	"time"
+
+	"github.com/yates-z/easel/transport/internal/window"
+)
+
+// CircuitBreaker is a Breaker with the closed, open and half-open states.
+type CircuitBreaker struct {
+	opts *options
+
+	mu     sync.Mutex
+	state  State
+	window *window.Window[counts]
+	// generation changes with the state, so that the calls allowed in a
+	// previous state aren't counted.
+	generation uint64
+	openedAt   time.Time
+	probes     int64
+	successes  int64
+	// transitions are notified by unlock
+	transitions []transition
+}
+
+// NewCircuitBreaker creates a CircuitBreaker, closed.
+func NewCircuitBreaker(opts ...Option) *CircuitBreaker {
+	o := newOptions(opts...)
+	return &CircuitBreaker{opts: o, window: newWindow(o.window, o.buckets)}
+}
+
+// State returns the state of the breaker.
+func (b *CircuitBreaker) State() State {
+	b.mu.Lock()
+	defer b.unlock()
+	return b.current(b.opts.now())
+}
+
+// current returns the state at now, an open breaker is half-open once
+// OpenTimeout elapsed.
+func (b *CircuitBreaker) current(now time.Time) State {
+	if b.state == StateOpen && now.Sub(b.openedAt) >= b.opts.openTimeout {
+		b.setState(StateHalfOpen, now)
+	}
+	return b.state
+}
+
+// Allow allows a call unless the breaker is open, or half-open with all the
+// probes in flight.
+func (b *CircuitBreaker) Allow() (DoneFunc, error) {
+	b.mu.Lock()
+	defer b.unlock()
+
+	switch b.current(b.opts.now()) {
+	case StateOpen:
+		return nil, ErrOpen
+	case StateHalfOpen:
+		if b.probes >= b.opts.halfOpenCalls {
+			return nil, ErrOpen
+		}
+		b.probes++
+	}
+	generation := b.generation
+	var once sync.Once
+	return func(success bool) {
+		once.Do(func() { b.done(generation, success) })
+	}, nil
+}
+
+func (b *CircuitBreaker) done(generation uint64, success bool) {
+	b.mu.Lock()
+	defer b.unlock()
+	if generation != b.generation {
+		return
+	}
+
+	now := b.opts.now()
+	switch b.state {
+	case StateClosed:
+		add(b.window, now, success)
+		if success {
+			return
+		}
+		total, successes := sum(b.window, now)
+		if total >= b.opts.minRequests && float64(total-successes) >= b.opts.failureRate*float64(total) {
+			b.setState(StateOpen, now)
+		}
+	case StateHalfOpen:
+		if !success {
+			b.setState(StateOpen, now)
+			return
+		}
+		if b.successes++; b.successes >= b.opts.halfOpenCalls {
+			b.setState(StateClosed, now)
+		}
+	}
+}
+
+// unlock releases b.mu, then notifies the state changes made with it held.
+func (b *CircuitBreaker) unlock() {
+	transitions := b.transitions
+	b.transitions = nil
+	b.mu.Unlock()
+	b.opts.notify(transitions)
+}
+
+func (b *CircuitBreaker) setState(state State, now time.Time) {
+	from := b.state
+	b.state = state
+	b.generation++
+	b.probes, b.successes = 0, 0
+	switch state {
+	case StateOpen:
+		b.openedAt = now
+	case StateClosed:
+		b.window.Reset()
+	}
+	b.transitions = append(b.transitions, transition{from, state})
+}
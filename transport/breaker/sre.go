@@ -0,0 +1,93 @@
+package breaker
+
+import (
+	"math/rand/v2"
+	"sync"
+
+	"github.com/yates-z/easel/transport/internal/window"
+)
+
+// SRE is a Breaker rejecting calls with the probability
+//
+//	max(0, (requests - K * accepts) / (requests + 1))
+//
+// where requests are the calls of the window, rejected ones included, and
+// accepts the successful ones, see the "Handling Overload" chapter of the
+// Google SRE book. Unlike a CircuitBreaker, it never rejects all the calls,
+// and keeps probing the dependency as it recovers.
+type SRE struct {
+	opts *options
+
+	mu       sync.Mutex
+	window   *window.Window[counts]
+	throttle bool
+	// transitions are notified by unlock
+	transitions []transition
+}
+
+// NewSRE creates an SRE breaker.
+func NewSRE(opts ...Option) *SRE {
+	o := newOptions(opts...)
+	return &SRE{opts: o, window: newWindow(o.window, o.buckets)}
+}
+
+// State returns StateOpen while the breaker rejects calls, StateClosed otherwise.
+func (b *SRE) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.probability() > 0 {
+		return StateOpen
+	}
+	return StateClosed
+}
+
+// probability returns the probability to reject a call.
+func (b *SRE) probability() float64 {
+	requests, accepts := sum(b.window, b.opts.now())
+	if requests < b.opts.minRequests {
+		return 0
+	}
+	return max(0, (float64(requests)-b.opts.k*float64(accepts))/float64(requests+1))
+}
+
+// Allow allows a call, or rejects it with the probability of the window.
+func (b *SRE) Allow() (DoneFunc, error) {
+	b.mu.Lock()
+	defer b.unlock()
+
+	p := b.probability()
+	b.setThrottle(p > 0)
+	if p > 0 && rand.Float64() < p {
+		// a rejected call is a request too
+		add(b.window, b.opts.now(), false)
+		return nil, ErrOpen
+	}
+	var once sync.Once
+	return func(success bool) {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			add(b.window, b.opts.now(), success)
+		})
+	}, nil
+}
+
+func (b *SRE) setThrottle(throttle bool) {
+	if throttle == b.throttle {
+		return
+	}
+	b.throttle = throttle
+	if throttle {
+		b.transitions = append(b.transitions, transition{StateClosed, StateOpen})
+	} else {
+		b.transitions = append(b.transitions, transition{StateOpen, StateClosed})
+	}
+}
+
+// unlock releases b.mu, then notifies the state changes made with it held.
+func (b *SRE) unlock() {
+	transitions := b.transitions
+	b.transitions = nil
+	b.mu.Unlock()
+	b.opts.notify(transitions)
+}
@@ -0,0 +1,34 @@
+package breaker
+
+import (
+	"time"
+
+	"github.com/yates-z/easel/transport/internal/window"
+)
+
+// counts are the calls of a bucket of the window of a breaker.
+type counts struct {
+	total     int64
+	successes int64
+}
+
+func newWindow(period time.Duration, n int) *window.Window[counts] {
+	return window.New[counts](period, n)
+}
+
+func add(w *window.Window[counts], now time.Time, success bool) {
+	b := w.Bucket(now)
+	b.total++
+	if success {
+		b.successes++
+	}
+}
+
+// sum returns the calls and the successful ones of the period ending at now.
+func sum(w *window.Window[counts], now time.Time) (total, successes int64) {
+	w.Each(now, func(b counts, _ bool) {
+		total += b.total
+		successes += b.successes
+	})
+	return total, successes
+}
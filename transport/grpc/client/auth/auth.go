@@ -0,0 +1,90 @@
+package auth
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc/credentials"
+)
+
+// TokenSource returns the token attached to a call.
+type TokenSource func(ctx context.Context) (string, error)
+
+// StaticToken returns a TokenSource always returning token.
+func StaticToken(token string) TokenSource {
+	return func(context.Context) (string, error) {
+		return token, nil
+	}
+}
+
+type Option func(*options)
+
+type options struct {
+	key      string
+	scheme   string
+	insecure bool
+}
+
+// WithMetadataKey with the metadata key carrying the credentials.
+func WithMetadataKey(key string) Option {
+	return func(o *options) {
+		o.key = strings.ToLower(key)
+	}
+}
+
+// WithScheme with the scheme preceding the credentials, e.g. "Bearer".
+func WithScheme(scheme string) Option {
+	return func(o *options) {
+		o.scheme = scheme
+	}
+}
+
+// AllowInsecure allows sending the credentials over connections without
+// transport security, e.g. in tests.
+func AllowInsecure() Option {
+	return func(o *options) {
+		o.insecure = true
+	}
+}
+
+var _ credentials.PerRPCCredentials = (*perRPCCredentials)(nil)
+
+type perRPCCredentials struct {
+	source TokenSource
+	opts   options
+}
+
+func (c *perRPCCredentials) GetRequestMetadata(ctx context.Context, _ ...string) (map[string]string, error) {
+	token, err := c.source(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if c.opts.scheme != "" {
+		token = c.opts.scheme + " " + token
+	}
+	return map[string]string{c.opts.key: token}, nil
+}
+
+func (c *perRPCCredentials) RequireTransportSecurity() bool {
+	return !c.opts.insecure
+}
+
+// Bearer returns credentials sending the token of source as
+// "authorization: Bearer <token>", as expected by the server auth.JWT.
+func Bearer(source TokenSource, opts ...Option) credentials.PerRPCCredentials {
+	c := &perRPCCredentials{source: source, opts: options{key: "authorization", scheme: "Bearer"}}
+	for _, opt := range opts {
+		opt(&c.opts)
+	}
+	return c
+}
+
+// APIKey returns credentials sending key as "x-api-key: <key>", as expected
+// by the server auth.APIKey.
+func APIKey(key string, opts ...Option) credentials.PerRPCCredentials {
+	c := &perRPCCredentials{source: StaticToken(key), opts: options{key: "x-api-key"}}
+	for _, opt := range opts {
+		opt(&c.opts)
+	}
+	return c
+}
@@ -29,6 +29,13 @@ func TLSConfig(c *tls.Config) DialOption {
 	}
 }
 
+// PerRPCCredentials with credentials attached to every call, e.g. auth.Bearer.
+func PerRPCCredentials(c credentials.PerRPCCredentials) DialOption {
+	return func(o *dialOptions) {
+		o._opts = append(o._opts, grpc.WithPerRPCCredentials(c))
+	}
+}
+
 // UnaryInterceptor returns a ServerOption that sets the UnaryServerInterceptor for the client.
 func UnaryInterceptor(in ...grpc.UnaryClientInterceptor) DialOption {
 	return func(o *dialOptions) {
@@ -0,0 +1,161 @@
+// Package breaker rejects the calls to a failing target with the breakers of
+// the transport/breaker package, instead of letting retries amplify its load.
+package breaker
+
+import (
+	"context"
+	"errors"
+	"io"
+	"slices"
+	"sync"
+
+	"github.com/yates-z/easel/transport/breaker"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// DefaultFailureCodes are the codes counted as failures of the target.
+var DefaultFailureCodes = []codes.Code{
+	codes.Unknown,
+	codes.DeadlineExceeded,
+	codes.ResourceExhausted,
+	codes.Internal,
+	codes.Unavailable,
+}
+
+// KeyFunc returns the key of the breaker of a call.
+type KeyFunc func(cc *grpc.ClientConn, method string) string
+
+// FailureFunc reports whether the error of a call is a failure of the target.
+type FailureFunc func(err error) bool
+
+type Option func(*options)
+
+type options struct {
+	group     *breaker.Group
+	key       KeyFunc
+	isFailure FailureFunc
+}
+
+// WithGroup with the breakers of the calls, circuit breakers by default.
+func WithGroup(g *breaker.Group) Option {
+	return func(o *options) {
+		o.group = g
+	}
+}
+
+// WithKey with the key of the breaker of a call, the target and the method by
+// default. Return the target only to share a breaker between the methods.
+func WithKey(f KeyFunc) Option {
+	return func(o *options) {
+		o.key = f
+	}
+}
+
+// WithFailure with the errors counted as failures, the ones with
+// DefaultFailureCodes by default.
+func WithFailure(f FailureFunc) Option {
+	return func(o *options) {
+		o.isFailure = f
+	}
+}
+
+// WithFailureCodes with the codes counted as failures.
+func WithFailureCodes(c ...codes.Code) Option {
+	return WithFailure(failureCodes(c))
+}
+
+func failureCodes(c []codes.Code) FailureFunc {
+	return func(err error) bool {
+		return slices.Contains(c, status.Code(err))
+	}
+}
+
+func newOptions(opts ...Option) *options {
+	o := &options{
+		key: func(cc *grpc.ClientConn, method string) string {
+			return cc.Target() + method
+		},
+		isFailure: failureCodes(DefaultFailureCodes),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.group == nil {
+		o.group = breaker.NewGroup(nil)
+	}
+	return o
+}
+
+func (o *options) allow(cc *grpc.ClientConn, method string) (breaker.DoneFunc, error) {
+	done, err := o.group.Get(o.key(cc, method)).Allow()
+	if errors.Is(err, breaker.ErrOpen) {
+		return nil, status.Errorf(codes.Unavailable, "%s: %v", method, err)
+	}
+	return done, err
+}
+
+// UnaryClientInterceptor returns a new unary client interceptor failing the
+// calls with codes.Unavailable while their breakers reject them.
+func UnaryClientInterceptor(opts ...Option) grpc.UnaryClientInterceptor {
+	o := newOptions(opts...)
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		done, err := o.allow(cc, method)
+		if err != nil {
+			return err
+		}
+		err = invoker(ctx, method, req, reply, cc, opts...)
+		done(err == nil || !o.isFailure(err))
+		return err
+	}
+}
+
+// StreamClientInterceptor returns a new stream client interceptor failing the
+// streams with codes.Unavailable while their breakers reject them. A stream
+// is done once it receives its status, or its reply if the server doesn't
+// stream, or once its context is done.
+func StreamClientInterceptor(opts ...Option) grpc.StreamClientInterceptor {
+	o := newOptions(opts...)
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		done, err := o.allow(cc, method)
+		if err != nil {
+			return nil, err
+		}
+		stream, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			done(!o.isFailure(err))
+			return nil, err
+		}
+		s := &clientStream{ClientStream: stream, isFailure: o.isFailure, serverStreams: desc.ServerStreams}
+		s.done = func(success bool) {
+			s.once.Do(func() { done(success) })
+		}
+		// an abandoned stream isn't a failure of the target
+		s.stop = context.AfterFunc(ctx, func() { s.done(true) })
+		return s, nil
+	}
+}
+
+type clientStream struct {
+	grpc.ClientStream
+	isFailure FailureFunc
+	// serverStreams is false when the stream receives a single reply, e.g.
+	// with CloseAndRecv, which ends it
+	serverStreams bool
+	once          sync.Once
+	done          breaker.DoneFunc
+	stop          func() bool
+}
+
+func (s *clientStream) RecvMsg(m any) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err != nil {
+		s.stop()
+		s.done(errors.Is(err, io.EOF) || !s.isFailure(err))
+	} else if !s.serverStreams {
+		s.stop()
+		s.done(true)
+	}
+	return err
+}
@@ -0,0 +1,162 @@
+package breaker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/yates-z/easel/transport/breaker"
+	"github.com/yates-z/easel/transport/grpc/client"
+	"github.com/yates-z/easel/transport/grpc/server"
+	"github.com/yates-z/easel/transport/grpc/server/servertest"
+	"github.com/yates-z/easel/transport/grpc/server/test/api"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+const sayHello = "/pb.Greeter/SayHello"
+
+type greeter struct {
+	api.UnimplementedGreeterServer
+}
+
+func (greeter) SayHello(_ context.Context, in *api.HelloRequest) (*api.HelloResponse, error) {
+	return &api.HelloResponse{Replay: "hello, " + in.Name}, nil
+}
+
+func newServer(t *testing.T, g *breaker.Group) *servertest.Server {
+	return servertest.New(t, func(s *server.Server) {
+		api.RegisterGreeterServer(s, greeter{})
+	}, servertest.DialOptions(
+		client.UnaryInterceptor(UnaryClientInterceptor(WithGroup(g))),
+		client.StreamInterceptor(StreamClientInterceptor(WithGroup(g))),
+	))
+}
+
+func newGroup() *breaker.Group {
+	return breaker.NewGroup(func(name string) breaker.Breaker {
+		return breaker.NewCircuitBreaker(breaker.WithName(name), breaker.MinRequests(2), breaker.OpenTimeout(time.Hour))
+	})
+}
+
+func TestUnaryClientInterceptor(t *testing.T) {
+	g := newGroup()
+	s := newServer(t, g)
+	c := api.NewGreeterClient(s.Conn)
+	ctx := context.Background()
+
+	// client errors aren't failures
+	s.Faults.Inject(sayHello, servertest.Fault{Code: codes.InvalidArgument, Times: 2})
+	for range 2 {
+		if _, err := c.SayHello(ctx, &api.HelloRequest{}); status.Code(err) != codes.InvalidArgument {
+			t.Fatalf("unexpected error %v", err)
+		}
+	}
+	if _, err := c.SayHello(ctx, &api.HelloRequest{}); err != nil {
+		t.Fatal(err)
+	}
+
+	s.Faults.Inject(sayHello, servertest.Fault{Code: codes.Unavailable, Times: 3})
+	for range 3 {
+		if _, err := c.SayHello(ctx, &api.HelloRequest{}); status.Code(err) != codes.Unavailable {
+			t.Fatalf("unexpected error %v", err)
+		}
+	}
+	calls := s.Faults.Calls(sayHello)
+	if _, err := c.SayHello(ctx, &api.HelloRequest{}); status.Code(err) != codes.Unavailable {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if s.Faults.Calls(sayHello) != calls {
+		t.Fatal("the call isn't rejected by the breaker")
+	}
+	if g.Get(s.Conn.Target()+sayHello).State() != breaker.StateOpen {
+		t.Fatal("unexpected state")
+	}
+	// the breakers are per method
+	if _, err := grpc_health_v1.NewHealthClient(s.Conn).Check(ctx, &grpc_health_v1.HealthCheckRequest{}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStreamClientInterceptor(t *testing.T) {
+	const watch = "/grpc.health.v1.Health/Watch"
+	g := newGroup()
+	s := newServer(t, g)
+	c := grpc_health_v1.NewHealthClient(s.Conn)
+
+	s.Faults.Inject(watch, servertest.Fault{Code: codes.Unavailable, Times: 2})
+	for range 2 {
+		stream, err := c.Watch(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := stream.Recv(); status.Code(err) != codes.Unavailable {
+			t.Fatalf("unexpected error %v", err)
+		}
+	}
+	if _, err := c.Watch(context.Background(), &grpc_health_v1.HealthCheckRequest{}); status.Code(err) != codes.Unavailable {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	// a canceled stream is a success
+	g = newGroup()
+	s = newServer(t, g)
+	c = grpc_health_v1.NewHealthClient(s.Conn)
+	for range 3 {
+		ctx, cancel := context.WithCancel(context.Background())
+		stream, err := c.Watch(ctx, &grpc_health_v1.HealthCheckRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := stream.Recv(); err != nil {
+			t.Fatal(err)
+		}
+		cancel()
+		if _, err := stream.Recv(); status.Code(err) != codes.Canceled {
+			t.Fatalf("unexpected error %v", err)
+		}
+	}
+	if g.Get(s.Conn.Target()+watch).State() != breaker.StateClosed {
+		t.Fatal("unexpected state")
+	}
+}
+
+// recorder is a Breaker recording the outcomes of the calls.
+type recorder struct {
+	outcomes []bool
+}
+
+func (r *recorder) Allow() (breaker.DoneFunc, error) {
+	return func(success bool) { r.outcomes = append(r.outcomes, success) }, nil
+}
+
+func (r *recorder) State() breaker.State { return breaker.StateClosed }
+
+// replyStream is a client stream receiving a single reply.
+type replyStream struct {
+	grpc.ClientStream
+}
+
+func (replyStream) RecvMsg(any) error { return nil }
+
+func TestClientStreaming(t *testing.T) {
+	r := &recorder{}
+	interceptor := StreamClientInterceptor(WithGroup(breaker.NewGroup(func(string) breaker.Breaker { return r })))
+	streamer := func(context.Context, *grpc.StreamDesc, *grpc.ClientConn, string, ...grpc.CallOption) (grpc.ClientStream, error) {
+		return replyStream{}, nil
+	}
+
+	// CloseAndRecv of a client stream returns nil once the reply is received
+	stream, err := interceptor(context.Background(), &grpc.StreamDesc{ClientStreams: true}, &grpc.ClientConn{}, "/pb.Uploader/Upload", streamer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := stream.RecvMsg(nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.outcomes) != 1 || !r.outcomes[0] {
+		t.Fatalf("unexpected outcomes %v", r.outcomes)
+	}
+}
@@ -50,3 +50,21 @@ func BackoffExponentialWithJitter(scalar time.Duration, jitterFraction float64)
 		return jitterUp(scalar*time.Duration(exponentBase2(attempt)), jitterFraction)
 	}
 }
+
+// BackoffExponentialWithFullJitter creates an exponential backoff waiting a
+// random time between 0 and the exponential interval, capped to maxWait.
+// Spreading the retries over the whole interval avoids the bursts of clients
+// retrying in sync after an outage.
+func BackoffExponentialWithFullJitter(scalar, maxWait time.Duration) BackoffFunc {
+	return func(ctx context.Context, attempt uint) time.Duration {
+		wait := scalar * time.Duration(exponentBase2(attempt))
+		if wait <= 0 || wait > maxWait {
+			// the exponent overflowed
+			wait = maxWait
+		}
+		if wait <= 0 {
+			return 0
+		}
+		return time.Duration(rand.Int63n(int64(wait) + 1))
+	}
+}
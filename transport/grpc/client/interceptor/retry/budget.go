@@ -0,0 +1,63 @@
+package retry
+
+import (
+	"sync"
+	"time"
+)
+
+// Budget is a token bucket capping the retries of the calls sharing it, so
+// that retries can't multiply the load of a failing server. Every call
+// deposits a share of a token, and every retry, or hedged attempt, withdraws
+// a whole one.
+type Budget struct {
+	mu           sync.Mutex
+	ratio        float64
+	minPerSecond float64
+	burst        float64
+	tokens       float64
+	last         time.Time
+	now          func() time.Time
+}
+
+// NewBudget creates a Budget allowing retries for ratio of the calls, e.g. 0.1
+// for 10%, plus minPerSecond retries per second however few the calls. It
+// saves up to burst retries while the calls succeed.
+func NewBudget(ratio float64, minPerSecond, burst int) *Budget {
+	b := &Budget{
+		ratio:        ratio,
+		minPerSecond: float64(minPerSecond),
+		burst:        float64(max(burst, 1)),
+		now:          time.Now,
+	}
+	b.tokens = b.burst
+	b.last = b.now()
+	return b
+}
+
+// refill adds the tokens of minPerSecond since the last call, with b.mu held.
+func (b *Budget) refill() {
+	now := b.now()
+	b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.minPerSecond)
+	b.last = now
+}
+
+// Deposit deposits the share of a call.
+func (b *Budget) Deposit() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill()
+	b.tokens = min(b.burst, b.tokens+b.ratio)
+}
+
+// Withdraw withdraws the token of a retry, it reports false if the budget is
+// spent and the call must not be retried.
+func (b *Budget) Withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill()
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
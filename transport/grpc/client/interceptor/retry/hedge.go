@@ -0,0 +1,134 @@
+package retry
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	grpcMetadata "google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/protobuf/proto"
+)
+
+type hedgeResult struct {
+	reply   proto.Message
+	header  grpcMetadata.MD
+	trailer grpcMetadata.MD
+	peer    *peer.Peer
+	err     error
+}
+
+// deliver sets the header, the trailer and the peer of the attempt into the
+// call options of the caller.
+func (r *hedgeResult) deliver(opts []grpc.CallOption) {
+	for _, opt := range opts {
+		switch o := opt.(type) {
+		case grpc.HeaderCallOption:
+			*o.HeaderAddr = r.header
+		case grpc.TrailerCallOption:
+			*o.TrailerAddr = r.trailer
+		case grpc.PeerCallOption:
+			*o.PeerAddr = *r.peer
+		}
+	}
+}
+
+// attemptOptions returns opts without the options receiving the header, the
+// trailer or the peer of the call, which the concurrent attempts can't share.
+func attemptOptions(opts []grpc.CallOption) []grpc.CallOption {
+	filtered := make([]grpc.CallOption, 0, len(opts)+2)
+	for _, opt := range opts {
+		switch opt.(type) {
+		case grpc.HeaderCallOption, grpc.TrailerCallOption, grpc.PeerCallOption:
+			continue
+		}
+		filtered = append(filtered, opt)
+	}
+	return filtered
+}
+
+// hedge makes the attempts of a unary call concurrently, see WithHedging.
+// Every attempt decodes its own reply, the one of the winner is merged into
+// reply, and its header, trailer and peer are set into opts.
+func hedge(parentCtx context.Context, method string, req any, reply proto.Message, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, callOpts *options, opts []grpc.CallOption) error {
+	ctx, cancel := context.WithCancel(parentCtx)
+	// cancels the attempts still in flight once there is a winner
+	defer cancel()
+
+	filtered := attemptOptions(opts)
+	results := make(chan *hedgeResult, callOpts.max)
+	start := func(attempt uint) {
+		go func() {
+			r := &hedgeResult{reply: reply.ProtoReflect().New().Interface(), peer: &peer.Peer{}}
+			r.trailer, r.err = invoke(ctx, method, req, r.reply, cc, invoker, callOpts, attempt,
+				append(filtered[:len(filtered):len(filtered)], grpc.Header(&r.header), grpc.Peer(r.peer)))
+			results <- r
+		}()
+	}
+
+	start(0)
+	started, pending := uint(1), 1
+	// armed reports whether the timer sends the next attempt
+	armed := true
+	timer := time.NewTimer(callOpts.hedgingDelay)
+	defer timer.Stop()
+	var last *hedgeResult
+	for pending > 0 || (armed && started < callOpts.max) {
+		select {
+		case <-parentCtx.Done():
+			return contextErrToGrpcErr(parentCtx.Err())
+		case <-timer.C:
+			armed = false
+			if started >= callOpts.max {
+				continue
+			}
+			if callOpts.budget != nil && !callOpts.budget.Withdraw() {
+				logAndTrace(parentCtx, "grpc_retry attempt: %d, retry budget exhausted", started)
+				started = callOpts.max
+				continue
+			}
+			var lastErr error
+			if last != nil {
+				lastErr = last.err
+			}
+			callOpts.onRetryCallback(parentCtx, started, lastErr)
+			start(started)
+			started++
+			pending++
+			armed = true
+			timer.Reset(callOpts.hedgingDelay)
+		case r := <-results:
+			pending--
+			last = r
+			if r.err == nil {
+				proto.Reset(reply)
+				proto.Merge(reply, r.reply)
+				r.deliver(opts)
+				return nil
+			}
+			// a context error of WithPerRetryTimeout is retried, as in
+			// sequential retries
+			perCallTimeout := isContextError(r.err) && parentCtx.Err() == nil && callOpts.perCallTimeout != 0
+			if !perCallTimeout && !isRetriable(r.err, callOpts) {
+				r.deliver(opts)
+				return r.err
+			}
+			// a retriable failure sends the next attempt right away, unless
+			// the server pushes back
+			waitTime, ok := pushback(r.trailer)
+			switch {
+			case ok && waitTime < 0:
+				logAndTrace(parentCtx, "grpc_retry attempt: %d, retry refused by the server", started)
+				started = callOpts.max
+			case ok:
+				armed = true
+				timer.Reset(waitTime)
+			default:
+				armed = true
+				timer.Reset(0)
+			}
+		}
+	}
+	last.deliver(opts)
+	return last.err
+}
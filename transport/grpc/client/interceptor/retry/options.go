@@ -2,11 +2,13 @@ package retry
 
 import (
 	"context"
+	"slices"
+	"time"
+
+	"github.com/yates-z/easel/transport/internal/match"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
-	"slices"
-	"time"
 )
 
 var DefaultRetriableCodes = []codes.Code{codes.ResourceExhausted, codes.Unavailable, codes.Unknown}
@@ -82,6 +84,30 @@ func WithRetriable(retriableFunc RetriableFunc) Option {
 	}
 }
 
+// WithBudget sets the Budget capping the retries, shared by the calls of
+// the interceptor or of several interceptors. The calls exceeding it aren't
+// retried.
+func WithBudget(b *Budget) Option {
+	return func(o *options) {
+		o.budget = b
+	}
+}
+
+// WithHedging hedges the calls of methods: another attempt is sent whenever
+// the previous one hasn't replied after delay, or failed with a retriable
+// error, up to the maximum of attempts. The first successful attempt wins and
+// the others are canceled. A method is a full method name, e.g.
+// "/pb.Greeter/SayHello", or a service followed by "*", all of them if none.
+//
+// Only idempotent methods should be hedged, as several attempts may reach the
+// server. Hedging is available for unary calls with proto replies only.
+func WithHedging(delay time.Duration, methods ...string) Option {
+	return func(o *options) {
+		o.hedgingDelay = delay
+		o.hedgingMethods = methods
+	}
+}
+
 type options struct {
 	max             uint
 	perCallTimeout  time.Duration
@@ -89,6 +115,14 @@ type options struct {
 	backoffFunc     BackoffFunc
 	onRetryCallback OnRetryCallback
 	retriableFunc   RetriableFunc
+	budget          *Budget
+	hedgingDelay    time.Duration
+	hedgingMethods  []string
+}
+
+// hedged reports whether the calls of method are hedged.
+func (o *options) hedged(method string) bool {
+	return o.hedgingDelay > 0 && (len(o.hedgingMethods) == 0 || match.Any(o.hedgingMethods, method))
 }
 
 // CallOption is a grpc.CallOption that is local to grpc_retry.
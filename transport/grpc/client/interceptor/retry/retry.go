@@ -10,13 +10,18 @@ import (
 	"google.golang.org/grpc/codes"
 	grpcMetadata "google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 	"io"
+	"strconv"
 	"sync"
 	"time"
 )
 
 const (
 	AttemptMetadataKey = "x-retry-attempt"
+	// PushbackMetadataKey is the trailer of the delay in milliseconds a server
+	// asks before the next attempt, a negative or invalid delay asks not to retry.
+	PushbackMetadataKey = "grpc-retry-pushback-ms"
 )
 
 // UnaryClientInterceptor returns a new retrying unary client interceptor.
@@ -42,17 +47,26 @@ func UnaryClientInterceptor(max uint, interval time.Duration, opts ...Option) gr
 		if callOpts.max == 0 {
 			return invoker(parentCtx, method, req, reply, cc, opts...)
 		}
+		if callOpts.budget != nil {
+			callOpts.budget.Deposit()
+		}
+		if msg, ok := reply.(proto.Message); ok && callOpts.hedged(method) {
+			return hedge(parentCtx, method, req, msg, cc, invoker, callOpts, opts)
+		}
 		var lastErr error
+		var trailer grpcMetadata.MD
 		for attempt := range callOpts.max {
-			if err := waitRetryBackoff(attempt, parentCtx, callOpts); err != nil {
-				return err
-			}
 			if attempt > 0 {
+				waitTime, ok := retryBackoff(parentCtx, attempt, callOpts, trailer)
+				if !ok {
+					return lastErr
+				}
+				if err := waitRetryBackoff(attempt, parentCtx, waitTime); err != nil {
+					return err
+				}
 				callOpts.onRetryCallback(parentCtx, attempt, lastErr)
 			}
-			callCtx, cancel := perCallContext(parentCtx, callOpts, attempt)
-			defer cancel() // Clean up potential resources.
-			lastErr = invoker(callCtx, method, req, reply, cc, opts...)
+			trailer, lastErr = invoke(parentCtx, method, req, reply, cc, invoker, callOpts, attempt, opts)
 			// TODO(mwitkow): Maybe dial and transport errors should be retriable?
 			if lastErr == nil {
 				return nil
@@ -77,6 +91,15 @@ func UnaryClientInterceptor(max uint, interval time.Duration, opts ...Option) gr
 	}
 }
 
+// invoke makes an attempt of a unary call, and returns the trailer of the reply.
+func invoke(parentCtx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, callOpts *options, attempt uint, opts []grpc.CallOption) (grpcMetadata.MD, error) {
+	callCtx, cancel := perCallContext(parentCtx, callOpts, attempt)
+	defer cancel()
+	var trailer grpcMetadata.MD
+	err := invoker(callCtx, method, req, reply, cc, append(opts[:len(opts):len(opts)], grpc.Trailer(&trailer))...)
+	return trailer, err
+}
+
 // StreamClientInterceptor returns a new retrying stream client interceptor for server side streaming calls.
 //
 // The default configuration of the interceptor is to not retry *at all*. This behaviour can be
@@ -107,12 +130,19 @@ func StreamClientInterceptor(max uint, interval time.Duration, opts ...Option) g
 			return nil, status.Error(codes.Unimplemented, "grpc_retry: cannot retry on ClientStreams, set grpc_retry.Disable()")
 		}
 
+		if callOpts.budget != nil {
+			callOpts.budget.Deposit()
+		}
 		var lastErr error
 		for attempt := uint(0); attempt < callOpts.max; attempt++ {
-			if err := waitRetryBackoff(attempt, parentCtx, callOpts); err != nil {
-				return nil, err
-			}
 			if attempt > 0 {
+				waitTime, ok := retryBackoff(parentCtx, attempt, callOpts, nil)
+				if !ok {
+					return nil, lastErr
+				}
+				if err := waitRetryBackoff(attempt, parentCtx, waitTime); err != nil {
+					return nil, err
+				}
 				callOpts.onRetryCallback(parentCtx, attempt, lastErr)
 			}
 			var newStreamer grpc.ClientStream
@@ -200,9 +230,14 @@ func (s *serverStreamingRetryingStream) RecvMsg(m any) error {
 	if !attemptRetry {
 		return lastErr // success or hard failure
 	}
+	trailer := s.getStream().Trailer()
 	// We start off from attempt 1, because zeroth was already made on normal SendMsg().
 	for attempt := uint(1); attempt < s.callOpts.max; attempt++ {
-		if err := waitRetryBackoff(attempt, s.parentCtx, s.callOpts); err != nil {
+		waitTime, ok := retryBackoff(s.parentCtx, attempt, s.callOpts, trailer)
+		if !ok {
+			return lastErr
+		}
+		if err := waitRetryBackoff(attempt, s.parentCtx, waitTime); err != nil {
 			return err
 		}
 		s.callOpts.onRetryCallback(s.parentCtx, attempt, lastErr)
@@ -210,6 +245,7 @@ func (s *serverStreamingRetryingStream) RecvMsg(m any) error {
 		if err != nil {
 			// Retry dial and transport errors of establishing stream as grpc doesn't retry.
 			if isRetriable(err, s.callOpts) {
+				trailer = nil
 				continue
 			}
 			return err
@@ -221,6 +257,7 @@ func (s *serverStreamingRetryingStream) RecvMsg(m any) error {
 		if !attemptRetry {
 			return lastErr
 		}
+		trailer = newStream.Trailer()
 	}
 	return lastErr
 }
@@ -266,11 +303,39 @@ func (s *serverStreamingRetryingStream) reestablishStreamAndResendBuffer(callCtx
 	return newStream, nil
 }
 
-func waitRetryBackoff(attempt uint, parentCtx context.Context, callOpts *options) error {
-	var waitTime time.Duration = 0
-	if attempt > 0 {
+// pushback returns the delay asked by the server in the trailer of an
+// attempt, negative if it asked not to retry, and whether it asked one.
+func pushback(trailer grpcMetadata.MD) (time.Duration, bool) {
+	values := trailer.Get(PushbackMetadataKey)
+	if len(values) == 0 {
+		return 0, false
+	}
+	ms, err := strconv.ParseInt(values[0], 10, 64)
+	if err != nil || ms < 0 {
+		return -1, true
+	}
+	return time.Duration(ms) * time.Millisecond, true
+}
+
+// retryBackoff returns the wait before attempt, the pushback of the server if
+// any, or false if the server or the budget refuse the retry.
+func retryBackoff(parentCtx context.Context, attempt uint, callOpts *options, trailer grpcMetadata.MD) (time.Duration, bool) {
+	waitTime, ok := pushback(trailer)
+	if ok && waitTime < 0 {
+		logAndTrace(parentCtx, "grpc_retry attempt: %d, retry refused by the server", attempt)
+		return 0, false
+	}
+	if callOpts.budget != nil && !callOpts.budget.Withdraw() {
+		logAndTrace(parentCtx, "grpc_retry attempt: %d, retry budget exhausted", attempt)
+		return 0, false
+	}
+	if !ok {
 		waitTime = callOpts.backoffFunc(parentCtx, attempt)
 	}
+	return waitTime, true
+}
+
+func waitRetryBackoff(attempt uint, parentCtx context.Context, waitTime time.Duration) error {
 	if waitTime > 0 {
 		logAndTrace(parentCtx, "grpc_retry attempt: %d, backoff for %v", attempt, waitTime)
 		timer := time.NewTimer(waitTime)
@@ -0,0 +1,188 @@
+package retry
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/yates-z/easel/transport/grpc/client"
+	"github.com/yates-z/easel/transport/grpc/server"
+	"github.com/yates-z/easel/transport/grpc/server/servertest"
+	"github.com/yates-z/easel/transport/grpc/server/test/api"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+const sayHello = "/pb.Greeter/SayHello"
+
+type greeter struct {
+	api.UnimplementedGreeterServer
+}
+
+func (greeter) SayHello(_ context.Context, in *api.HelloRequest) (*api.HelloResponse, error) {
+	return &api.HelloResponse{Replay: "hello, " + in.Name}, nil
+}
+
+func newServer(t *testing.T, in ...grpc.UnaryClientInterceptor) *servertest.Server {
+	return servertest.New(t, func(s *server.Server) {
+		api.RegisterGreeterServer(s, greeter{})
+	}, servertest.DialOptions(client.UnaryInterceptor(in...)))
+}
+
+func TestUnaryClientInterceptor(t *testing.T) {
+	s := newServer(t, UnaryClientInterceptor(3, time.Millisecond))
+	s.Faults.Inject(sayHello, servertest.Fault{Code: codes.Unavailable, Times: 2})
+
+	res, err := api.NewGreeterClient(s.Conn).SayHello(context.Background(), &api.HelloRequest{Name: "easel"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Replay != "hello, easel" || s.Faults.Calls(sayHello) != 3 {
+		t.Fatalf("unexpected reply %q after %d calls", res.Replay, s.Faults.Calls(sayHello))
+	}
+}
+
+func TestPushback(t *testing.T) {
+	s := newServer(t, UnaryClientInterceptor(3, time.Hour))
+	c := api.NewGreeterClient(s.Conn)
+
+	// the pushback replaces the backoff
+	s.Faults.Inject(sayHello, servertest.Fault{
+		Code:    codes.Unavailable,
+		Trailer: metadata.Pairs(PushbackMetadataKey, "1"),
+		Times:   1,
+	})
+	if _, err := c.SayHello(context.Background(), &api.HelloRequest{}); err != nil {
+		t.Fatal(err)
+	}
+
+	s.Faults.Reset()
+	s.Faults.Inject(sayHello, servertest.Fault{
+		Code:    codes.Unavailable,
+		Trailer: metadata.Pairs(PushbackMetadataKey, "-1"),
+		Times:   1,
+	})
+	if _, err := c.SayHello(context.Background(), &api.HelloRequest{}); status.Code(err) != codes.Unavailable {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if s.Faults.Calls(sayHello) != 1 {
+		t.Fatalf("unexpected calls %d, want no retry", s.Faults.Calls(sayHello))
+	}
+}
+
+func TestBudget(t *testing.T) {
+	b := NewBudget(0.5, 0, 1)
+	s := newServer(t, UnaryClientInterceptor(3, 0, WithBudget(b)))
+	c := api.NewGreeterClient(s.Conn)
+
+	s.Faults.Inject(sayHello, servertest.Fault{Code: codes.Unavailable})
+	c.SayHello(context.Background(), &api.HelloRequest{})
+	// the call and the retry of the only token
+	if s.Faults.Calls(sayHello) != 2 {
+		t.Fatalf("unexpected calls %d", s.Faults.Calls(sayHello))
+	}
+	// two calls save the token of a retry
+	c.SayHello(context.Background(), &api.HelloRequest{})
+	c.SayHello(context.Background(), &api.HelloRequest{})
+	if s.Faults.Calls(sayHello) != 5 {
+		t.Fatalf("unexpected calls %d", s.Faults.Calls(sayHello))
+	}
+}
+
+func TestBudgetRefill(t *testing.T) {
+	now := time.Unix(1000, 0)
+	b := NewBudget(0, 2, 4)
+	b.now = func() time.Time { return now }
+	b.last = now
+	for range 4 {
+		if !b.Withdraw() {
+			t.Fatal("the budget is spent")
+		}
+	}
+	if b.Withdraw() {
+		t.Fatal("the budget isn't spent")
+	}
+	now = now.Add(time.Second)
+	if !b.Withdraw() || !b.Withdraw() || b.Withdraw() {
+		t.Fatal("unexpected retries per second")
+	}
+}
+
+func TestBackoffExponentialWithFullJitter(t *testing.T) {
+	backoff := BackoffExponentialWithFullJitter(100*time.Millisecond, time.Second)
+	for attempt := uint(1); attempt < 100; attempt++ {
+		ceiling := min(time.Second, 100*time.Millisecond<<(attempt-1))
+		if attempt > 10 {
+			ceiling = time.Second
+		}
+		if wait := backoff(context.Background(), attempt); wait < 0 || wait > ceiling {
+			t.Fatalf("unexpected wait %v of attempt %d", wait, attempt)
+		}
+	}
+}
+
+func TestHedging(t *testing.T) {
+	var retries atomic.Int32
+	s := newServer(t, UnaryClientInterceptor(3, 0,
+		WithHedging(10*time.Millisecond, "/pb.Greeter/*"),
+		WithOnRetryCallback(func(context.Context, uint, error) { retries.Add(1) }),
+	))
+	c := api.NewGreeterClient(s.Conn)
+
+	// the first attempt hangs, the hedged one wins
+	s.Faults.Inject(sayHello, servertest.Fault{Delay: time.Hour, Times: 1})
+	res, err := c.SayHello(context.Background(), &api.HelloRequest{Name: "easel"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Replay != "hello, easel" || retries.Load() != 1 {
+		t.Fatalf("unexpected reply %q after %d retries", res.Replay, retries.Load())
+	}
+
+	// a retriable failure is hedged right away
+	s.Faults.Inject(sayHello, servertest.Fault{Code: codes.Unavailable, Times: 2})
+	if _, err := c.SayHello(context.Background(), &api.HelloRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	// other errors win
+	s.Faults.Inject(sayHello, servertest.Fault{Code: codes.InvalidArgument, Times: 1})
+	if _, err := c.SayHello(context.Background(), &api.HelloRequest{}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestHedgingCallOptions(t *testing.T) {
+	s := newServer(t, UnaryClientInterceptor(3, 0, WithHedging(10*time.Millisecond)))
+	c := api.NewGreeterClient(s.Conn)
+
+	s.Faults.Inject(sayHello, servertest.Fault{Delay: time.Hour, Times: 1})
+	var header, trailer metadata.MD
+	var p peer.Peer
+	if _, err := c.SayHello(context.Background(), &api.HelloRequest{}, grpc.Header(&header), grpc.Trailer(&trailer), grpc.Peer(&p)); err != nil {
+		t.Fatal(err)
+	}
+	if p.Addr == nil || header == nil {
+		t.Fatalf("unexpected peer %v, header %v", p, header)
+	}
+}
+
+func TestHedgingPerRetryTimeout(t *testing.T) {
+	s := newServer(t, UnaryClientInterceptor(3, 0,
+		WithHedging(time.Hour),
+		WithPerRetryTimeout(20*time.Millisecond),
+	))
+	c := api.NewGreeterClient(s.Conn)
+
+	// the attempt timing out is hedged right away
+	s.Faults.Inject(sayHello, servertest.Fault{Delay: time.Hour, Times: 1})
+	if _, err := c.SayHello(context.Background(), &api.HelloRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if s.Faults.Calls(sayHello) != 2 {
+		t.Fatalf("unexpected calls %d", s.Faults.Calls(sayHello))
+	}
+}
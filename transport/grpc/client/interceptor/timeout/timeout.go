@@ -1,19 +1,135 @@
+// Package timeout bounds the time of the calls of a client.
+//
+// The deadline of a call is the earliest of its timeout and the deadline of
+// its context, e.g. the deadline of the incoming call handled by a server,
+// which gRPC propagates to the next server.
 package timeout
 
 import (
 	"context"
-	"google.golang.org/grpc"
 	"time"
+
+	"github.com/yates-z/easel/transport/internal/match"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
-// UnaryClientInterceptor returns a new unary client interceptor that sets a timeout on the request context.
-func UnaryClientInterceptor(timeout time.Duration) grpc.UnaryClientInterceptor {
+type Option func(*options)
+
+type options struct {
+	timeout time.Duration
+	methods map[string]time.Duration
+	reserve time.Duration
+}
+
+// Methods with the timeouts of methods, overriding the default timeout. A
+// method is a full method name, e.g. "/pb.Greeter/SayHello", or a service
+// followed by "*", e.g. "/pb.Greeter/*". Full names win over services, and
+// a timeout of 0 disables the timeout of a method.
+func Methods(timeouts map[string]time.Duration) Option {
+	return func(o *options) {
+		for method, timeout := range timeouts {
+			o.methods[method] = timeout
+		}
+	}
+}
+
+// Reserve with the time kept from the deadline of the context, for the
+// caller to handle the reply, or the failure, before its own deadline. The
+// calls with less time left fail right away with codes.DeadlineExceeded.
+func Reserve(d time.Duration) Option {
+	return func(o *options) {
+		o.reserve = d
+	}
+}
+
+func newOptions(timeout time.Duration, opts ...Option) *options {
+	o := &options{timeout: timeout, methods: make(map[string]time.Duration)}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// lookup returns the timeout of method, the one of its longest pattern.
+func (o *options) lookup(method string) time.Duration {
+	if timeout, ok := match.Longest(o.methods, method); ok {
+		return timeout
+	}
+	return o.timeout
+}
+
+// context returns the context of a call of method, or an error if the
+// budget of ctx is spent.
+func (o *options) context(ctx context.Context, method string) (context.Context, context.CancelFunc, error) {
+	deadline, ok := ctx.Deadline()
+	set := false
+	if ok && o.reserve > 0 {
+		deadline, set = deadline.Add(-o.reserve), true
+		if time.Until(deadline) <= 0 {
+			return nil, nil, status.Errorf(codes.DeadlineExceeded, "request %s: deadline exceeded", method)
+		}
+	}
+	if timeout := o.lookup(method); timeout > 0 {
+		if d := time.Now().Add(timeout); !ok || d.Before(deadline) {
+			deadline, set = d, true
+		}
+	}
+	if !set {
+		return ctx, func() {}, nil
+	}
+	ctx, cancel := context.WithDeadline(ctx, deadline)
+	return ctx, cancel, nil
+}
+
+// UnaryClientInterceptor returns a new unary client interceptor that sets a
+// timeout on the request context, unless the context has an earlier
+// deadline.
+func UnaryClientInterceptor(timeout time.Duration, opts ...Option) grpc.UnaryClientInterceptor {
+	o := newOptions(timeout, opts...)
 	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
-		if timeout > 0 {
-			timedCtx, cancel := context.WithTimeout(ctx, timeout)
-			defer cancel()
-			return invoker(timedCtx, method, req, reply, cc, opts...)
+		ctx, cancel, err := o.context(ctx, method)
+		if err != nil {
+			return err
 		}
+		defer cancel()
 		return invoker(ctx, method, req, reply, cc, opts...)
 	}
 }
+
+// StreamClientInterceptor returns a new stream client interceptor that sets
+// a timeout on the stream context, unless the context has an earlier
+// deadline. The timeout bounds the whole stream.
+func StreamClientInterceptor(timeout time.Duration, opts ...Option) grpc.StreamClientInterceptor {
+	o := newOptions(timeout, opts...)
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		ctx, cancel, err := o.context(ctx, method)
+		if err != nil {
+			return nil, err
+		}
+		stream, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		return &clientStream{ClientStream: stream, cancel: cancel, single: !desc.ServerStreams}, nil
+	}
+}
+
+// clientStream releases the context of the stream once it's done.
+type clientStream struct {
+	grpc.ClientStream
+	cancel context.CancelFunc
+	// single is true when the server sends a single reply, which ends the
+	// stream.
+	single bool
+}
+
+func (s *clientStream) RecvMsg(m any) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err != nil || s.single {
+		s.cancel()
+	}
+	return err
+}
@@ -0,0 +1,105 @@
+package timeout
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// left invokes method with the interceptor and returns the time left of the
+// call, or 0 without deadline.
+func left(interceptor grpc.UnaryClientInterceptor, ctx context.Context, method string) (time.Duration, error) {
+	var d time.Duration
+	err := interceptor(ctx, method, nil, nil, nil, func(ctx context.Context, _ string, _, _ any, _ *grpc.ClientConn, _ ...grpc.CallOption) error {
+		if deadline, ok := ctx.Deadline(); ok {
+			d = time.Until(deadline)
+		}
+		return nil
+	})
+	return d, err
+}
+
+func TestUnaryClientInterceptor(t *testing.T) {
+	interceptor := UnaryClientInterceptor(time.Second, Methods(map[string]time.Duration{
+		"/pb.Greeter/*":        time.Minute,
+		"/pb.Greeter/SayHello": 0,
+	}))
+	for _, tc := range []struct {
+		method   string
+		min, max time.Duration
+	}{
+		{"/pb.Other/Call", time.Second / 2, time.Second},
+		{"/pb.Greeter/SayBye", time.Second, time.Minute},
+		{"/pb.Greeter/SayHello", 0, 0},
+	} {
+		d, err := left(interceptor, context.Background(), tc.method)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d < tc.min || d > tc.max {
+			t.Fatalf("%s: unexpected time left %v", tc.method, d)
+		}
+	}
+
+	// the deadline of the incoming call is propagated
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if d, _ := left(interceptor, ctx, "/pb.Other/Call"); d <= 0 || d > 100*time.Millisecond {
+		t.Fatalf("unexpected time left %v", d)
+	}
+}
+
+func TestReserve(t *testing.T) {
+	interceptor := UnaryClientInterceptor(0, Reserve(50*time.Millisecond))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if d, err := left(interceptor, ctx, "/pb.Greeter/SayHello"); err != nil || d <= 0 || d > 50*time.Millisecond {
+		t.Fatalf("unexpected time left %v, error %v", d, err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := left(interceptor, ctx, "/pb.Greeter/SayHello"); status.Code(err) != codes.DeadlineExceeded {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+// replyStream is a client stream receiving replies.
+type replyStream struct {
+	grpc.ClientStream
+}
+
+func (replyStream) RecvMsg(any) error { return nil }
+
+func TestStreamClientInterceptor(t *testing.T) {
+	interceptor := StreamClientInterceptor(time.Minute)
+	for _, tc := range []struct {
+		name     string
+		desc     *grpc.StreamDesc
+		canceled bool
+	}{
+		{"client streaming", &grpc.StreamDesc{ClientStreams: true}, true},
+		{"server streaming", &grpc.StreamDesc{ServerStreams: true}, false},
+	} {
+		var streamCtx context.Context
+		streamer := func(ctx context.Context, _ *grpc.StreamDesc, _ *grpc.ClientConn, _ string, _ ...grpc.CallOption) (grpc.ClientStream, error) {
+			streamCtx = ctx
+			return replyStream{}, nil
+		}
+		stream, err := interceptor(context.Background(), tc.desc, nil, "/pb.Uploader/Upload", streamer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := stream.RecvMsg(nil); err != nil {
+			t.Fatal(err)
+		}
+		if canceled := streamCtx.Err() != nil; canceled != tc.canceled {
+			t.Fatalf("%s: context canceled %v after a reply", tc.name, canceled)
+		}
+	}
+}
@@ -6,25 +6,34 @@ import (
 	"github.com/yates-z/easel/transport/grpc/client"
 	"github.com/yates-z/easel/transport/grpc/client/interceptor/retry"
 	"github.com/yates-z/easel/transport/grpc/client/interceptor/timeout"
+	"github.com/yates-z/easel/transport/grpc/server"
+	"github.com/yates-z/easel/transport/grpc/server/servertest"
 	"github.com/yates-z/easel/transport/grpc/server/test/api"
+	"google.golang.org/grpc/codes"
 	"testing"
 	"time"
 )
 
+type greeter struct {
+	api.UnimplementedGreeterServer
+}
+
+func (greeter) SayHello(ctx context.Context, in *api.HelloRequest) (*api.HelloResponse, error) {
+	return &api.HelloResponse{Replay: "hello, " + in.Name}, nil
+}
+
 func TestNewClient(t *testing.T) {
-	client, err := client.NewInsecureClient(
-		"127.0.0.1:9100",
+	s := servertest.New(t, func(s *server.Server) {
+		api.RegisterGreeterServer(s, greeter{})
+	}, servertest.DialOptions(
 		client.UnaryInterceptor(
 			timeout.UnaryClientInterceptor(0),
 			retry.UnaryClientInterceptor(5, time.Second, retry.WithPerRetryTimeout(time.Second)),
 		),
-	)
-
-	if err != nil {
-		panic(err)
-	}
+	))
+	s.Faults.Inject("/pb.Greeter/SayHello", servertest.Fault{Code: codes.Unavailable, Times: 1})
 
-	c := api.NewGreeterClient(client)
+	c := api.NewGreeterClient(s.Conn)
 	res, err := c.SayHello(context.Background(), &api.HelloRequest{Name: ""})
 	if err != nil {
 		panic(err)
@@ -0,0 +1,24 @@
+// Package stream wraps the streams of the interceptors.
+package stream
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+// WithContext returns ss with the context ctx, e.g. carrying the values set
+// by an interceptor.
+func WithContext(ss grpc.ServerStream, ctx context.Context) grpc.ServerStream {
+	return &serverStream{ServerStream: ss, ctx: ctx}
+}
+
+// serverStream overrides the context of a grpc.ServerStream.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
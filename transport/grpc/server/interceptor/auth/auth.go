@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/yates-z/easel/transport/grpc/internal/stream"
+	"github.com/yates-z/easel/transport/internal/match"
+	"google.golang.org/grpc"
+)
+
+type Option func(*options)
+
+type options struct {
+	public []string
+}
+
+// WithPublicMethods with methods that are called without credentials.
+// A method is a full method name, e.g. "/pb.Greeter/SayHello", or a service
+// followed by "*", e.g. "/pb.Greeter/*".
+func WithPublicMethods(methods ...string) Option {
+	return func(o *options) {
+		o.public = append(o.public, methods...)
+	}
+}
+
+func (o *options) isPublic(method string) bool {
+	return match.Any(o.public, method)
+}
+
+type claimsKey struct{}
+
+// NewContext returns a new Context that carries claims.
+func NewContext(ctx context.Context, claims any) context.Context {
+	return context.WithValue(ctx, claimsKey{}, claims)
+}
+
+// FromContext returns the claims put into ctx by the interceptors.
+func FromContext(ctx context.Context) (any, bool) {
+	claims := ctx.Value(claimsKey{})
+	return claims, claims != nil
+}
+
+// ClaimsFromContext returns the claims put into ctx as a T, e.g. *jwt.Payload.
+func ClaimsFromContext[T any](ctx context.Context) (T, bool) {
+	claims, ok := ctx.Value(claimsKey{}).(T)
+	return claims, ok
+}
+
+// UnaryServerInterceptor returns a new unary server interceptor that authenticates
+// calls with a and puts the claims into the context.
+func UnaryServerInterceptor(a Authenticator, opts ...Option) grpc.UnaryServerInterceptor {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if o.isPublic(info.FullMethod) {
+			return handler(ctx, req)
+		}
+		claims, err := a.Authenticate(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return handler(NewContext(ctx, claims), req)
+	}
+}
+
+// StreamServerInterceptor returns a new stream server interceptor that authenticates
+// calls with a and puts the claims into the context.
+func StreamServerInterceptor(a Authenticator, opts ...Option) grpc.StreamServerInterceptor {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if o.isPublic(info.FullMethod) {
+			return handler(srv, ss)
+		}
+		claims, err := a.Authenticate(ss.Context())
+		if err != nil {
+			return err
+		}
+		return handler(srv, stream.WithContext(ss, NewContext(ss.Context(), claims)))
+	}
+}
@@ -0,0 +1,177 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/yates-z/easel/auth/authentication/jwt"
+	"github.com/yates-z/easel/transport/grpc/client"
+	clientauth "github.com/yates-z/easel/transport/grpc/client/auth"
+	"github.com/yates-z/easel/transport/grpc/internal/stream"
+	"github.com/yates-z/easel/transport/grpc/server"
+	"github.com/yates-z/easel/transport/grpc/server/servertest"
+	"github.com/yates-z/easel/transport/grpc/server/test/api"
+	"github.com/yates-z/easel/transport/grpc/utils/metadata"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+var key = []byte("secret")
+
+type greeter struct {
+	api.UnimplementedGreeterServer
+}
+
+func (greeter) SayHello(ctx context.Context, _ *api.HelloRequest) (*api.HelloResponse, error) {
+	claims, ok := FromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Internal, "no claims")
+	}
+	switch c := claims.(type) {
+	case *jwt.Payload:
+		return &api.HelloResponse{Replay: "hello, " + c.Sub}, nil
+	case string:
+		return &api.HelloResponse{Replay: "hello, " + c}, nil
+	}
+	return nil, status.Error(codes.Internal, "unexpected claims")
+}
+
+func newToken(t *testing.T, exp time.Time) string {
+	token, err := jwt.Sign(jwt.NewToken(jwt.NewMethodHS256, jwt.Payload{Sub: "easel", Exp: exp.Unix()}), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func newServer(t *testing.T, a Authenticator) *servertest.Server {
+	return servertest.New(t, func(s *server.Server) {
+		api.RegisterGreeterServer(s, greeter{})
+	}, servertest.ServerOptions(
+		server.UnaryInterceptor(UnaryServerInterceptor(a, WithPublicMethods("/grpc.health.v1.Health/*"))),
+		server.StreamInterceptor(StreamServerInterceptor(a, WithPublicMethods("/grpc.health.v1.Health/*"))),
+	))
+}
+
+func TestJWT(t *testing.T) {
+	s := newServer(t, JWT[jwt.Payload](jwt.StaticKey(key)))
+
+	for _, tc := range []struct {
+		name  string
+		token string
+		code  codes.Code
+	}{
+		{"valid", newToken(t, time.Now().Add(time.Hour)), codes.OK},
+		{"expired", newToken(t, time.Now().Add(-time.Hour)), codes.Unauthenticated},
+		{"malformed", "not-a-token", codes.Unauthenticated},
+	} {
+		conn, err := s.Dial(client.PerRPCCredentials(clientauth.Bearer(clientauth.StaticToken(tc.token), clientauth.AllowInsecure())))
+		if err != nil {
+			t.Fatal(err)
+		}
+		reply, err := api.NewGreeterClient(conn).SayHello(context.Background(), &api.HelloRequest{})
+		if status.Code(err) != tc.code {
+			t.Fatalf("%s: unexpected error %v", tc.name, err)
+		}
+		if err == nil && reply.Replay != "hello, easel" {
+			t.Fatalf("%s: unexpected reply %q", tc.name, reply.Replay)
+		}
+	}
+
+	// no credentials.
+	if _, err := api.NewGreeterClient(s.Conn).SayHello(context.Background(), &api.HelloRequest{}); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("unexpected error %v", err)
+	}
+	// public methods.
+	if _, err := grpc_health_v1.NewHealthClient(s.Conn).Check(context.Background(), &grpc_health_v1.HealthCheckRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	stream, err := grpc_health_v1.NewHealthClient(s.Conn).Watch(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = stream.Recv(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+type userClaims struct {
+	jwt.RegisteredClaims
+	Tenant string `json:"tenant"`
+}
+
+func TestJWTClaims(t *testing.T) {
+	raw, err := jwt.Sign(jwt.NewToken(jwt.NewMethodHS256, userClaims{
+		RegisteredClaims: jwt.RegisteredClaims{Sub: "easel", Exp: time.Now().Add(time.Hour).Unix()},
+		Tenant:           "acme",
+	}), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := metadata.MD{}.Set("authorization", "Bearer "+raw).ToIncoming(context.Background())
+	claims, err := JWT[userClaims](jwt.StaticKey(key)).Authenticate(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c, ok := claims.(*userClaims); !ok || c.Sub != "easel" || c.Tenant != "acme" {
+		t.Fatalf("unexpected claims %#v", claims)
+	}
+}
+
+func TestAPIKey(t *testing.T) {
+	s := newServer(t, APIKey(func(_ context.Context, key string) (any, error) {
+		switch key {
+		case "k1":
+			return "service-a", nil
+		case "revoked":
+			return nil, status.Error(codes.PermissionDenied, "revoked")
+		}
+		return nil, errors.New("unknown key")
+	}))
+
+	for key, code := range map[string]codes.Code{"k1": codes.OK, "revoked": codes.PermissionDenied, "k2": codes.Unauthenticated} {
+		conn, err := s.Dial(client.PerRPCCredentials(clientauth.APIKey(key, clientauth.AllowInsecure())))
+		if err != nil {
+			t.Fatal(err)
+		}
+		reply, err := api.NewGreeterClient(conn).SayHello(context.Background(), &api.HelloRequest{})
+		if status.Code(err) != code {
+			t.Fatalf("%s: unexpected error %v", key, err)
+		}
+		if err == nil && reply.Replay != "hello, service-a" {
+			t.Fatalf("%s: unexpected reply %q", key, reply.Replay)
+		}
+	}
+}
+
+func TestPublicMethods(t *testing.T) {
+	o := &options{}
+	WithPublicMethods("/pb.Greeter/SayHello", "/grpc.health.v1.Health/*")(o)
+	for method, want := range map[string]bool{
+		"/pb.Greeter/SayHello":          true,
+		"/pb.Greeter/SayBye":            false,
+		"/grpc.health.v1.Health/Watch":  true,
+		"/grpc.health.v1.HealthX/Watch": false,
+	} {
+		if o.isPublic(method) != want {
+			t.Fatalf("isPublic(%q) != %v", method, want)
+		}
+	}
+}
+
+func TestStreamClaims(t *testing.T) {
+	a := AuthenticatorFunc(func(context.Context) (any, error) { return "claims", nil })
+	var got any
+	interceptor := StreamServerInterceptor(a)
+	err := interceptor(nil, stream.WithContext(nil, context.Background()), &grpc.StreamServerInfo{FullMethod: "/pb.Greeter/Stream"}, func(_ any, ss grpc.ServerStream) error {
+		got, _ = FromContext(ss.Context())
+		return nil
+	})
+	if err != nil || got != "claims" {
+		t.Fatalf("unexpected claims %v, error %v", got, err)
+	}
+}
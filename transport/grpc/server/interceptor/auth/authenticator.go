@@ -0,0 +1,128 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/yates-z/easel/auth/authentication/jwt"
+	"github.com/yates-z/easel/transport/grpc/utils/metadata"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Authenticator authenticates the credentials of an incoming call.
+type Authenticator interface {
+	// Authenticate returns the claims of the caller, or a status error.
+	Authenticate(ctx context.Context) (any, error)
+}
+
+// AuthenticatorFunc is an adapter to use a function as an Authenticator.
+type AuthenticatorFunc func(ctx context.Context) (any, error)
+
+func (f AuthenticatorFunc) Authenticate(ctx context.Context) (any, error) {
+	return f(ctx)
+}
+
+type CredentialOption func(*credentialOptions)
+
+type credentialOptions struct {
+	key      string
+	scheme   string
+	validate []jwt.ValidateOption
+}
+
+// WithMetadataKey with the metadata key carrying the credentials.
+func WithMetadataKey(key string) CredentialOption {
+	return func(o *credentialOptions) {
+		o.key = strings.ToLower(key)
+	}
+}
+
+// WithScheme with the scheme preceding the credentials, e.g. "Bearer".
+// An empty scheme means the whole value is the credentials.
+func WithScheme(scheme string) CredentialOption {
+	return func(o *credentialOptions) {
+		o.scheme = scheme
+	}
+}
+
+// WithValidation with the options validating the claims of a JWT, e.g.
+// jwt.WithAudience.
+func WithValidation(opts ...jwt.ValidateOption) CredentialOption {
+	return func(o *credentialOptions) {
+		o.validate = append(o.validate, opts...)
+	}
+}
+
+// credentials extracts the credentials from the incoming metadata.
+func (o *credentialOptions) credentials(ctx context.Context) (string, error) {
+	value := metadata.ExtractIncoming(ctx).Get(o.key)
+	if value == "" {
+		return "", ErrMissingCredentials
+	}
+	if o.scheme == "" {
+		return value, nil
+	}
+	scheme, credentials, ok := strings.Cut(value, " ")
+	if !ok || !strings.EqualFold(scheme, o.scheme) {
+		return "", ErrMissingCredentials
+	}
+	return strings.TrimSpace(credentials), nil
+}
+
+// JWT returns an Authenticator verifying the token sent as "authorization:
+// Bearer <token>" with the key resolved by keys, e.g. jwt.StaticKey(secret)
+// or a *jwt.KeySet. The claims are the *C of the token, e.g. *jwt.Payload,
+// or custom claims embedding jwt.RegisteredClaims, such as roles for the
+// rbac interceptors:
+//
+//	auth.JWT[UserClaims](jwt.StaticKey(secret))
+func JWT[C jwt.Claims](keys jwt.KeyResolver, opts ...CredentialOption) Authenticator {
+	o := &credentialOptions{key: "authorization", scheme: "Bearer"}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return AuthenticatorFunc(func(ctx context.Context) (any, error) {
+		raw, err := o.credentials(ctx)
+		if err != nil {
+			return nil, err
+		}
+		token, err := jwt.Parse[C](raw, keys, o.validate...)
+		if err != nil {
+			return nil, toStatus(err)
+		}
+		return &token.Claims, nil
+	})
+}
+
+// APIKeyFunc validates an API key and returns the claims of its owner.
+type APIKeyFunc func(ctx context.Context, key string) (any, error)
+
+// APIKey returns an Authenticator validating the API key sent as "x-api-key: <key>".
+func APIKey(validate APIKeyFunc, opts ...CredentialOption) Authenticator {
+	o := &credentialOptions{key: "x-api-key"}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return AuthenticatorFunc(func(ctx context.Context) (any, error) {
+		key, err := o.credentials(ctx)
+		if err != nil {
+			return nil, err
+		}
+		claims, err := validate(ctx, key)
+		if err != nil {
+			return nil, toStatus(err)
+		}
+		return claims, nil
+	})
+}
+
+// toStatus keeps status errors, other errors become ErrInvalidCredentials.
+func toStatus(err error) error {
+	var se interface{ GRPCStatus() *status.Status }
+	if errors.As(err, &se) && se.GRPCStatus().Code() != codes.Unknown {
+		return err
+	}
+	return ErrInvalidCredentials
+}
@@ -0,0 +1,11 @@
+package auth
+
+import (
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	ErrMissingCredentials = status.Error(codes.Unauthenticated, "missing credentials")
+	ErrInvalidCredentials = status.Error(codes.Unauthenticated, "invalid credentials")
+)
@@ -0,0 +1,163 @@
+package logging
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/yates-z/easel/logger"
+	"github.com/yates-z/easel/transport/internal/match"
+	"github.com/yates-z/easel/transport/realip"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryServerInterceptor returns a new unary server interceptor logging the
+// method, code, duration, peer and message sizes of the calls, and the IP of
+// the client resolved by the realip interceptors.
+func UnaryServerInterceptor(opts ...Option) grpc.UnaryServerInterceptor {
+	o := newOptions(opts...)
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if o.skipped(info.FullMethod) {
+			return handler(ctx, req)
+		}
+		startTime := time.Now()
+		resp, err := handler(ctx, req)
+
+		code := status.Code(err)
+		level := o.level(code)
+		l := o.loggerFor(ctx)
+		if !l.Level().Enabled(level) {
+			return resp, err
+		}
+		fields := o.fields(ctx, info.FullMethod, code, level, startTime)
+		if n := size(req); n >= 0 {
+			fields = append(fields, logger.Int("request_size", n))
+		}
+		if n := size(resp); err == nil && n >= 0 {
+			fields = append(fields, logger.Int("response_size", n))
+		}
+		if o.payloads {
+			fields = append(fields, logger.String("request", o.payload(req)))
+			if err == nil {
+				fields = append(fields, logger.String("response", o.payload(resp)))
+			}
+		}
+		if err != nil {
+			fields = append(fields, logger.String("error", status.Convert(err).Message()))
+		}
+		l.Logs(level, "", fields...)
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor returns a new streaming server interceptor logging
+// the method, code, duration, peer, and the count and sizes of the messages
+// of the streams once they end.
+func StreamServerInterceptor(opts ...Option) grpc.StreamServerInterceptor {
+	o := newOptions(opts...)
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if o.skipped(info.FullMethod) {
+			return handler(srv, stream)
+		}
+		startTime := time.Now()
+		ss := &serverStream{ServerStream: stream, opts: o, method: info.FullMethod}
+		err := handler(srv, ss)
+
+		ctx := stream.Context()
+		code := status.Code(err)
+		level := o.level(code)
+		l := o.loggerFor(ctx)
+		if !l.Level().Enabled(level) {
+			return err
+		}
+		fields := append(o.fields(ctx, info.FullMethod, code, level, startTime),
+			logger.Int("received", int(ss.received.Load())),
+			logger.Int("request_size", int(ss.receivedSize.Load())),
+			logger.Int("sent", int(ss.sent.Load())),
+			logger.Int("response_size", int(ss.sentSize.Load())),
+		)
+		if err != nil {
+			fields = append(fields, logger.String("error", status.Convert(err).Message()))
+		}
+		l.Logs(level, "", fields...)
+		return err
+	}
+}
+
+// fields returns the fields shared by unary and stream calls.
+func (o *options) fields(ctx context.Context, method string, code codes.Code, level logger.LogLevel, startTime time.Time) []logger.FieldBuilder {
+	codeField := logger.String("code", code.String())
+	switch {
+	case level >= logger.ErrorLevel:
+		codeField = codeField.Background(logger.Red)
+	case level >= logger.WarnLevel:
+		codeField = codeField.Background(logger.Yellow)
+	default:
+		codeField = codeField.Background(logger.Green)
+	}
+	fields := []logger.FieldBuilder{
+		logger.String("method", method),
+		codeField,
+		logger.String("duration", time.Since(startTime).String()),
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		fields = append(fields, logger.String("peer", p.Addr.String()))
+	}
+	if ip, ok := realip.FromContext(ctx); ok {
+		fields = append(fields, logger.String("client_ip", ip.String()))
+	}
+	return fields
+}
+
+func (o *options) skipped(method string) bool {
+	return match.Any(o.skip, method)
+}
+
+// serverStream counts the messages of a stream, and logs them if payloads
+// are logged.
+type serverStream struct {
+	grpc.ServerStream
+	opts   *options
+	method string
+
+	received, receivedSize atomic.Int64
+	sent, sentSize         atomic.Int64
+}
+
+func (s *serverStream) RecvMsg(m any) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	s.received.Add(1)
+	if n := size(m); n > 0 {
+		s.receivedSize.Add(int64(n))
+	}
+	s.logPayload("request", m)
+	return nil
+}
+
+func (s *serverStream) SendMsg(m any) error {
+	if err := s.ServerStream.SendMsg(m); err != nil {
+		return err
+	}
+	s.sent.Add(1)
+	if n := size(m); n > 0 {
+		s.sentSize.Add(int64(n))
+	}
+	s.logPayload("response", m)
+	return nil
+}
+
+func (s *serverStream) logPayload(key string, m any) {
+	if !s.opts.payloads {
+		return
+	}
+	l := s.opts.loggerFor(s.Context())
+	if !l.Level().Enabled(logger.DebugLevel) {
+		return
+	}
+	l.Logs(logger.DebugLevel, "", logger.String("method", s.method), logger.String(key, s.opts.payload(m)))
+}
@@ -0,0 +1,228 @@
+package logging
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yates-z/easel/logger"
+	"github.com/yates-z/easel/logger/buffer"
+	"github.com/yates-z/easel/transport/grpc/server"
+	"github.com/yates-z/easel/transport/grpc/server/servertest"
+	"github.com/yates-z/easel/transport/grpc/server/test/api"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+type greeter struct {
+	api.UnimplementedGreeterServer
+}
+
+func (greeter) SayHello(_ context.Context, in *api.HelloRequest) (*api.HelloResponse, error) {
+	// the name of the request is the code to fail with, if any
+	for c := codes.OK + 1; c <= codes.Unauthenticated; c++ {
+		if in.Name == c.String() {
+			return nil, status.Error(c, "failed")
+		}
+	}
+	return &api.HelloResponse{Replay: "hello, " + in.Name}, nil
+}
+
+// recorder is a logger keeping the fields of the structured logs.
+type recorder struct {
+	logger.Logger
+	mu      sync.Mutex
+	entries []map[string]string
+}
+
+func newRecorder(level logger.LogLevel) *recorder {
+	return &recorder{Logger: logger.NewLogger(logger.WithLevel(level))}
+}
+
+func (r *recorder) Context(context.Context) logger.Logger {
+	return r
+}
+
+func (r *recorder) Logs(level logger.LogLevel, _ string, fields ...logger.FieldBuilder) {
+	if !r.Level().Enabled(level) {
+		return
+	}
+	entry := map[string]string{"level": level.String()}
+	for _, f := range fields {
+		field := f.Build()
+		buf := buffer.New()
+		field.Log(buf)
+		entry[field.Key()] = buf.String()
+		buf.Free()
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, entry)
+}
+
+func (r *recorder) logged() []map[string]string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.entries)
+}
+
+func newServer(t *testing.T, opts ...Option) *servertest.Server {
+	return servertest.New(t, func(s *server.Server) {
+		api.RegisterGreeterServer(s, greeter{})
+	}, servertest.ServerOptions(
+		server.UnaryInterceptor(UnaryServerInterceptor(opts...)),
+		server.StreamInterceptor(StreamServerInterceptor(opts...)),
+	))
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	r := newRecorder(logger.DebugLevel)
+	s := newServer(t, WithLogger(r))
+
+	req := &api.HelloRequest{Name: "easel"}
+	if _, err := api.NewGreeterClient(s.Conn).SayHello(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	entries := r.logged()
+	if len(entries) != 1 {
+		t.Fatalf("unexpected entries %v", entries)
+	}
+	entry := entries[0]
+	for key, want := range map[string]string{
+		"level":         "info",
+		"method":        "/pb.Greeter/SayHello",
+		"code":          "OK",
+		"request_size":  "7",
+		"response_size": "14",
+	} {
+		if entry[key] != want {
+			t.Fatalf("unexpected %s %q, want %q", key, entry[key], want)
+		}
+	}
+	if entry["duration"] == "" || entry["peer"] == "" {
+		t.Fatalf("missing duration or peer in %v", entry)
+	}
+	if _, ok := entry["request"]; ok {
+		t.Fatal("payloads logged by default")
+	}
+}
+
+func TestLevels(t *testing.T) {
+	r := newRecorder(logger.InfoLevel)
+	s := newServer(t,
+		WithLogger(r),
+		WithLevel(logger.DebugLevel, codes.NotFound),
+	)
+	client := api.NewGreeterClient(s.Conn)
+
+	for _, tc := range []struct {
+		code  codes.Code
+		level string
+	}{
+		{codes.InvalidArgument, "warn"},
+		{codes.Internal, "error"},
+		{codes.NotFound, ""},
+	} {
+		before := len(r.logged())
+		if _, err := client.SayHello(context.Background(), &api.HelloRequest{Name: tc.code.String()}); status.Code(err) != tc.code {
+			t.Fatalf("unexpected error %v", err)
+		}
+		entries := r.logged()
+		if tc.level == "" {
+			if len(entries) != before {
+				t.Fatalf("%v: unexpected entry %v", tc.code, entries[len(entries)-1])
+			}
+			continue
+		}
+		if len(entries) != before+1 {
+			t.Fatalf("%v: missing entry", tc.code)
+		}
+		entry := entries[len(entries)-1]
+		if entry["level"] != tc.level || entry["code"] != tc.code.String() || entry["error"] == "" {
+			t.Fatalf("%v: unexpected entry %v", tc.code, entry)
+		}
+	}
+}
+
+func TestPayloads(t *testing.T) {
+	r := newRecorder(logger.DebugLevel)
+	s := newServer(t,
+		WithLogger(r),
+		WithPayloads(),
+		WithRedactedFields("name"),
+	)
+
+	if _, err := api.NewGreeterClient(s.Conn).SayHello(context.Background(), &api.HelloRequest{Name: "secret"}); err != nil {
+		t.Fatal(err)
+	}
+	entry := r.logged()[0]
+	if entry["request"] != `{"name":"[REDACTED]"}` {
+		t.Fatalf("unexpected request %q", entry["request"])
+	}
+	if entry["response"] != `{"replay":"hello, secret"}` {
+		t.Fatalf("unexpected response %q", entry["response"])
+	}
+}
+
+func TestRedact(t *testing.T) {
+	o := newOptions(WithRedactedFields("password", "token"))
+	got := o.payload(map[string]any{
+		"user":   map[string]any{"name": "easel", "password": "p"},
+		"tokens": []any{map[string]any{"token": "t"}},
+	})
+	want := `{"tokens":[{"token":"[REDACTED]"}],"user":{"name":"easel","password":"[REDACTED]"}}`
+	if got != want {
+		t.Fatalf("unexpected payload %s", got)
+	}
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	r := newRecorder(logger.DebugLevel)
+	s := newServer(t, WithLogger(r), WithPayloads())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := grpc_health_v1.NewHealthClient(s.Conn).Watch(ctx, &grpc_health_v1.HealthCheckRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stream.Recv(); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		entries := r.logged()
+		if n := len(entries); n > 0 && entries[n-1]["code"] != "" {
+			entry := entries[n-1]
+			if entry["method"] != "/grpc.health.v1.Health/Watch" || entry["code"] != "Canceled" ||
+				entry["received"] != "1" || entry["sent"] != "1" || entry["response_size"] != "2" {
+				t.Fatalf("unexpected entry %v", entry)
+			}
+			// the messages are logged at the debug level
+			if entries[0]["level"] != "debug" || entries[0]["request"] != "{}" {
+				t.Fatalf("unexpected payload entry %v", entries[0])
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("missing entry in %v", entries)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSkipMethods(t *testing.T) {
+	r := newRecorder(logger.DebugLevel)
+	s := newServer(t, WithLogger(r), SkipMethods("/grpc.health.v1.Health/*"))
+
+	if _, err := grpc_health_v1.NewHealthClient(s.Conn).Check(context.Background(), &grpc_health_v1.HealthCheckRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if entries := r.logged(); len(entries) != 0 {
+		t.Fatalf("unexpected entries %v", entries)
+	}
+}
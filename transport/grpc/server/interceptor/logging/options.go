@@ -0,0 +1,112 @@
+package logging
+
+import (
+	"context"
+
+	"github.com/yates-z/easel/logger"
+	"google.golang.org/grpc/codes"
+)
+
+type Option func(*options)
+
+type options struct {
+	logger   logger.Logger
+	levels   map[codes.Code]logger.LogLevel
+	levelFn  func(codes.Code) logger.LogLevel
+	payloads bool
+	redacted map[string]struct{}
+	skip     []string
+}
+
+// WithLogger with the logger of the calls, the default logger by default.
+func WithLogger(l logger.Logger) Option {
+	return func(o *options) {
+		o.logger = l
+	}
+}
+
+// WithLevel logs the calls ending with any of codes at level, e.g.
+// WithLevel(logger.DebugLevel, codes.NotFound).
+func WithLevel(level logger.LogLevel, codes ...codes.Code) Option {
+	return func(o *options) {
+		for _, code := range codes {
+			o.levels[code] = level
+		}
+	}
+}
+
+// WithLevelFunc with the function mapping the codes to the levels of the
+// calls, DefaultLevel by default. WithLevel takes precedence over it.
+func WithLevelFunc(f func(codes.Code) logger.LogLevel) Option {
+	return func(o *options) {
+		o.levelFn = f
+	}
+}
+
+// WithPayloads logs the requests and responses of the calls, encoded in JSON.
+// The messages of streams are logged one by one at the debug level.
+func WithPayloads() Option {
+	return func(o *options) {
+		o.payloads = true
+	}
+}
+
+// WithRedactedFields hides the values of the fields named as names in the
+// payloads, e.g. "password", at any depth. Names are the field names of the
+// proto definitions, not their JSON names.
+func WithRedactedFields(names ...string) Option {
+	return func(o *options) {
+		for _, name := range names {
+			o.redacted[name] = struct{}{}
+		}
+	}
+}
+
+// SkipMethods with the full methods not logged, e.g. "/grpc.health.v1.Health/*".
+// A pattern ending with "*" matches the methods with its prefix.
+func SkipMethods(patterns ...string) Option {
+	return func(o *options) {
+		o.skip = append(o.skip, patterns...)
+	}
+}
+
+func newOptions(opts ...Option) *options {
+	o := &options{
+		levels:   make(map[codes.Code]logger.LogLevel),
+		levelFn:  DefaultLevel,
+		redacted: make(map[string]struct{}),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// DefaultLevel logs successful calls at the info level, the errors of the
+// client at the warn level, and the errors of the server at the error level.
+func DefaultLevel(code codes.Code) logger.LogLevel {
+	switch code {
+	case codes.OK:
+		return logger.InfoLevel
+	case codes.Canceled, codes.InvalidArgument, codes.NotFound, codes.AlreadyExists,
+		codes.PermissionDenied, codes.Unauthenticated, codes.ResourceExhausted,
+		codes.FailedPrecondition, codes.Aborted, codes.OutOfRange, codes.DeadlineExceeded:
+		return logger.WarnLevel
+	default:
+		return logger.ErrorLevel
+	}
+}
+
+func (o *options) level(code codes.Code) logger.LogLevel {
+	if level, ok := o.levels[code]; ok {
+		return level
+	}
+	return o.levelFn(code)
+}
+
+func (o *options) loggerFor(ctx context.Context) logger.Logger {
+	if o.logger != nil {
+		return o.logger.Context(ctx)
+	}
+	return logger.Context(ctx)
+}
@@ -0,0 +1,67 @@
+package logging
+
+import (
+	"encoding/json"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// Redacted replaces the values of the redacted fields in the payloads.
+const Redacted = "[REDACTED]"
+
+var marshaler = protojson.MarshalOptions{UseProtoNames: true}
+
+// size returns the encoded size of a message, or -1 if it isn't a proto message.
+func size(m any) int {
+	if msg, ok := m.(proto.Message); ok {
+		return proto.Size(msg)
+	}
+	return -1
+}
+
+// payload encodes a message in JSON, with the redacted fields hidden.
+func (o *options) payload(m any) string {
+	var (
+		b   []byte
+		err error
+	)
+	if msg, ok := m.(proto.Message); ok {
+		b, err = marshaler.Marshal(msg)
+	} else {
+		b, err = json.Marshal(m)
+	}
+	if err != nil {
+		return err.Error()
+	}
+	if len(o.redacted) == 0 {
+		return string(b)
+	}
+
+	var v any
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err.Error()
+	}
+	if b, err = json.Marshal(o.redact(v)); err != nil {
+		return err.Error()
+	}
+	return string(b)
+}
+
+func (o *options) redact(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if _, ok := o.redacted[key]; ok {
+				v[key] = Redacted
+			} else {
+				v[key] = o.redact(value)
+			}
+		}
+	case []any:
+		for i, value := range v {
+			v[i] = o.redact(value)
+		}
+	}
+	return v
+}
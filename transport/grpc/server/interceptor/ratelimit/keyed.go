@@ -0,0 +1,85 @@
+package ratelimit
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/yates-z/easel/core/cache"
+	"github.com/yates-z/easel/transport/realip"
+	"google.golang.org/grpc/peer"
+)
+
+// KeyFunc returns the key of the caller of a call, e.g. ClientIP.
+type KeyFunc func(ctx context.Context) string
+
+// ClientIP returns the IP of the client resolved by the realip interceptors,
+// or the IP of the peer if they aren't used.
+func ClientIP(ctx context.Context) string {
+	if ip, ok := realip.FromContext(ctx); ok {
+		return ip.String()
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		if host, _, err := net.SplitHostPort(p.Addr.String()); err == nil {
+			return host
+		}
+		return p.Addr.String()
+	}
+	return ""
+}
+
+// Keyed limits each caller with its own limiter.
+type Keyed struct {
+	key        KeyFunc
+	newLimiter func() Limiter
+	ttl        time.Duration
+	limiters   cache.Cache[string, Limiter]
+	// mu serializes the creation of the limiters, so that newLimiter is
+	// called once per key, not by every concurrent miss.
+	mu sync.Mutex
+}
+
+// NewKeyed creates a Limiter limiting the callers of each key with a limiter
+// created by newLimiter, e.g. per client IP:
+//
+//	ratelimit.NewKeyed(ratelimit.ClientIP, func() ratelimit.Limiter {
+//		return ratelimit.NewTokenBucket(time.Second, 10, 100)
+//	}, time.Minute)
+//
+// The limiter of a key is dropped ttl after its creation, and created again
+// by the next call, so that the limiters of gone callers are released.
+func NewKeyed(key KeyFunc, newLimiter func() Limiter, ttl time.Duration) Limiter {
+	return &Keyed{
+		key:        key,
+		newLimiter: newLimiter,
+		ttl:        ttl,
+		limiters:   cache.NewMemCache[string, Limiter](32, 1<<16, ttl),
+	}
+}
+
+func (k *Keyed) Limit(ctx context.Context) error {
+	key := k.key(ctx)
+	limiter, ok := k.limiters.Get(key)
+	if !ok {
+		var err error
+		if limiter, err = k.create(key); err != nil {
+			return err
+		}
+	}
+	return limiter.Limit(ctx)
+}
+
+// create returns the limiter of key, created unless another call did.
+func (k *Keyed) create(key string) (Limiter, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if limiter, ok := k.limiters.Get(key); ok {
+		return limiter, nil
+	}
+	limiter := k.newLimiter()
+	if err := k.limiters.Set(key, limiter, k.ttl); err != nil {
+		return nil, err
+	}
+	return limiter, nil
+}
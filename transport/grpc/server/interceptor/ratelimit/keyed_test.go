@@ -0,0 +1,52 @@
+package ratelimit
+
+import (
+	"context"
+	"net/netip"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/yates-z/easel/transport/realip"
+)
+
+func TestKeyed(t *testing.T) {
+	limiter := NewKeyed(ClientIP, func() Limiter {
+		return NewTokenBucket(time.Hour, 1, 1)
+	}, time.Minute)
+
+	a := realip.NewContext(context.Background(), netip.MustParseAddr("198.51.100.1"))
+	b := realip.NewContext(context.Background(), netip.MustParseAddr("198.51.100.2"))
+	if err := limiter.Limit(a); err != nil {
+		t.Fatal(err)
+	}
+	if err := limiter.Limit(a); err == nil {
+		t.Fatal("expected the limit of the first client to be exceeded")
+	}
+	if err := limiter.Limit(b); err != nil {
+		t.Fatalf("the second client is limited: %v", err)
+	}
+}
+
+func TestKeyedCreatesOnce(t *testing.T) {
+	var created atomic.Int64
+	limiter := NewKeyed(ClientIP, func() Limiter {
+		created.Add(1)
+		return NewTokenBucket(time.Hour, 100, 100)
+	}, time.Minute)
+
+	ctx := realip.NewContext(context.Background(), netip.MustParseAddr("198.51.100.1"))
+	var wg sync.WaitGroup
+	for range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			limiter.Limit(ctx)
+		}()
+	}
+	wg.Wait()
+	if n := created.Load(); n != 1 {
+		t.Fatalf("%d limiters created for a key", n)
+	}
+}
@@ -2,6 +2,9 @@ package ratelimit
 
 import (
 	"context"
+
+	"github.com/yates-z/easel/transport/internal/match"
+	"github.com/yates-z/easel/transport/limiter"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -11,26 +14,102 @@ type Limiter interface {
 	Limit(ctx context.Context) error
 }
 
-func UnaryServerInterceptor(limiter Limiter) grpc.UnaryServerInterceptor {
-	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
-		if err := limiter.Limit(ctx); err != nil {
-			return nil, status.Errorf(
-				codes.ResourceExhausted,
-				"%s unvailable due to rate limit exceeded, please retry later. %s", info.FullMethod, err,
-			)
+// acquirer is a Limiter of the limiter package, e.g. limiter.Adaptive, which
+// is released with the outcome of the call.
+type acquirer interface {
+	Acquire(ctx context.Context) (limiter.ReleaseFunc, error)
+}
+
+type Option func(*options)
+
+type options struct {
+	critical []string
+	low      []string
+}
+
+// CriticalMethods with the methods never limited by the adaptive limiters,
+// the health checks by default. A method is a full method name, e.g.
+// "/pb.Greeter/SayHello", or a service followed by "*", e.g. "/pb.Greeter/*".
+func CriticalMethods(methods ...string) Option {
+	return func(o *options) {
+		o.critical = append(o.critical, methods...)
+	}
+}
+
+// LowPriorityMethods with the methods shed first by the adaptive limiters,
+// e.g. batch jobs, see limiter.LowPriorityShare.
+func LowPriorityMethods(methods ...string) Option {
+	return func(o *options) {
+		o.low = append(o.low, methods...)
+	}
+}
+
+func newOptions(opts ...Option) *options {
+	o := &options{critical: []string{"/grpc.health.v1.Health/*"}}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// limit admits a call with l, and returns the function to call once the
+// call is done with its error.
+func (o *options) limit(ctx context.Context, l Limiter, method string) (func(error), error) {
+	switch {
+	case match.Any(o.critical, method):
+		ctx = limiter.WithPriority(ctx, limiter.PriorityCritical)
+	case match.Any(o.low, method):
+		ctx = limiter.WithPriority(ctx, limiter.PriorityLow)
+	}
+
+	var (
+		done = func(error) {}
+		err  error
+	)
+	if a, ok := l.(acquirer); ok {
+		var release limiter.ReleaseFunc
+		if release, err = a.Acquire(ctx); err == nil {
+			done = func(err error) {
+				release(status.Code(err) == codes.DeadlineExceeded)
+			}
+		}
+	} else {
+		err = l.Limit(ctx)
+	}
+	if err != nil {
+		return nil, status.Errorf(
+			codes.ResourceExhausted,
+			"%s unvailable due to rate limit exceeded, please retry later. %s", method, err,
+		)
+	}
+	return done, nil
+}
+
+// UnaryServerInterceptor returns a new unary server interceptor limiting the
+// calls with limiter. The limiters of the limiter package are released with
+// the outcome of the calls, and don't limit the CriticalMethods.
+func UnaryServerInterceptor(limiter Limiter, opts ...Option) grpc.UnaryServerInterceptor {
+	o := newOptions(opts...)
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (_ any, err error) {
+		done, err := o.limit(ctx, limiter, info.FullMethod)
+		if err != nil {
+			return nil, err
 		}
+		defer func() { done(err) }()
 		return handler(ctx, req)
 	}
 }
 
-func StreamServerInterceptor(limiter Limiter) grpc.StreamServerInterceptor {
-	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
-		if err := limiter.Limit(stream.Context()); err != nil {
-			return status.Errorf(
-				codes.ResourceExhausted,
-				"%s unvailable due to rate limit exceeded, please retry later. %s", info.FullMethod, err,
-			)
+// StreamServerInterceptor returns a new stream server interceptor limiting
+// the calls with limiter, see UnaryServerInterceptor.
+func StreamServerInterceptor(limiter Limiter, opts ...Option) grpc.StreamServerInterceptor {
+	o := newOptions(opts...)
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		done, err := o.limit(stream.Context(), limiter, info.FullMethod)
+		if err != nil {
+			return err
 		}
+		defer func() { done(err) }()
 		return handler(srv, stream)
 	}
 }
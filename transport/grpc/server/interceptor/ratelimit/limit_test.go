@@ -0,0 +1,44 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/yates-z/easel/transport/limiter"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fixed is a limiter.Algorithm with a fixed limit.
+type fixed int
+
+func (f fixed) Limit() int { return int(f) }
+
+func (fixed) Update(time.Duration, int, bool) {}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	l := limiter.New(fixed(0))
+	interceptor := UnaryServerInterceptor(l, LowPriorityMethods("/pb.Batch/*"))
+	call := func(method string) error {
+		_, err := interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: method}, func(ctx context.Context, _ any) (any, error) {
+			if l.Inflight() != 1 {
+				t.Fatalf("unexpected in-flight calls %d", l.Inflight())
+			}
+			return nil, nil
+		})
+		return err
+	}
+
+	if err := call("/pb.Greeter/SayHello"); status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("unexpected error %v", err)
+	}
+	// health checks are never shed
+	if err := call("/grpc.health.v1.Health/Check"); err != nil {
+		t.Fatal(err)
+	}
+	if l.Inflight() != 0 {
+		t.Fatalf("the call isn't released, %d in-flight calls", l.Inflight())
+	}
+}
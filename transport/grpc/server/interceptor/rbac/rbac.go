@@ -0,0 +1,154 @@
+package rbac
+
+import (
+	"context"
+
+	"github.com/yates-z/easel/auth/authorization/rbac"
+	"github.com/yates-z/easel/transport"
+	"github.com/yates-z/easel/transport/grpc/server/interceptor/auth"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ActionCall is the action of the default resource mapping.
+const ActionCall = "call"
+
+// RolesFunc returns the roles of the subject of a call.
+type RolesFunc func(ctx context.Context) []string
+
+// ResourceFunc maps a call to the resource and action checked by the enforcer.
+type ResourceFunc func(ctx context.Context, fullMethod string) (resource, action string)
+
+// DomainFunc returns the domain, e.g. the tenant, of a call.
+type DomainFunc func(ctx context.Context) string
+
+// AttributesFunc returns the attributes the policy conditions of a call are evaluated over.
+type AttributesFunc func(ctx context.Context, fullMethod string) rbac.Attributes
+
+type Option func(*options)
+
+type options struct {
+	roles    RolesFunc
+	resource ResourceFunc
+	domain   DomainFunc
+	attrs    AttributesFunc
+	audit    rbac.AuditFunc
+	auditAll bool
+}
+
+// WithRoles with the function returning the roles of the subject.
+func WithRoles(f RolesFunc) Option {
+	return func(o *options) {
+		o.roles = f
+	}
+}
+
+// WithResource with the function mapping a call to a resource and action.
+func WithResource(f ResourceFunc) Option {
+	return func(o *options) {
+		o.resource = f
+	}
+}
+
+// WithDomain with the function returning the domain the policies are checked in.
+func WithDomain(f DomainFunc) Option {
+	return func(o *options) {
+		o.domain = f
+	}
+}
+
+// WithAttributes with the function returning the attributes of a call.
+func WithAttributes(f AttributesFunc) Option {
+	return func(o *options) {
+		o.attrs = f
+	}
+}
+
+// WithAudit with the function recording denied calls.
+func WithAudit(f rbac.AuditFunc) Option {
+	return func(o *options) {
+		o.audit = f
+	}
+}
+
+// AuditAll records allowed calls too.
+func AuditAll() Option {
+	return func(o *options) {
+		o.auditAll = true
+	}
+}
+
+func newOptions(opts ...Option) *options {
+	o := &options{
+		roles:    defaultRoles,
+		resource: defaultResource,
+		audit:    rbac.LogAudit(transport.Logger),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+func (o *options) authorize(ctx context.Context, e *rbac.Enforcer, fullMethod string) error {
+	resource, action := o.resource(ctx, fullMethod)
+	var domain string
+	if o.domain != nil {
+		domain = o.domain(ctx)
+	}
+	var attrs rbac.Attributes
+	if o.attrs != nil {
+		attrs = o.attrs(ctx, fullMethod)
+	}
+	d := e.AuthorizeAttrs(o.roles(ctx), domain, resource, action, attrs)
+	if !d.Allowed || o.auditAll {
+		o.audit(ctx, d)
+	}
+	if !d.Allowed {
+		return status.Errorf(codes.PermissionDenied, "permission denied to %s", fullMethod)
+	}
+	return nil
+}
+
+// UnaryServerInterceptor returns a new unary server interceptor rejecting calls
+// the subject's roles are not allowed to perform with codes.PermissionDenied.
+// The resource is the full method and the action is ActionCall.
+func UnaryServerInterceptor(e *rbac.Enforcer, opts ...Option) grpc.UnaryServerInterceptor {
+	o := newOptions(opts...)
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := o.authorize(ctx, e, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor returns a new stream server interceptor rejecting calls
+// the subject's roles are not allowed to perform with codes.PermissionDenied.
+func StreamServerInterceptor(e *rbac.Enforcer, opts ...Option) grpc.StreamServerInterceptor {
+	o := newOptions(opts...)
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := o.authorize(stream.Context(), e, info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, stream)
+	}
+}
+
+// defaultRoles looks up the roles in the context, then in the claims put
+// into the context by the auth interceptors. The claims of auth.JWT carry
+// roles once they implement rbac.RoleProvider, *jwt.Payload doesn't.
+func defaultRoles(ctx context.Context) []string {
+	if roles, ok := rbac.RolesFromContext(ctx); ok {
+		return roles
+	}
+	if claims, ok := auth.FromContext(ctx); ok {
+		return rbac.RolesOf(claims)
+	}
+	return nil
+}
+
+func defaultResource(_ context.Context, fullMethod string) (string, string) {
+	return fullMethod, ActionCall
+}
@@ -0,0 +1,113 @@
+package rbac
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/yates-z/easel/auth/authentication/jwt"
+	"github.com/yates-z/easel/auth/authorization/rbac"
+	"github.com/yates-z/easel/transport/grpc/server"
+	"github.com/yates-z/easel/transport/grpc/server/interceptor/auth"
+	"github.com/yates-z/easel/transport/grpc/server/servertest"
+	"github.com/yates-z/easel/transport/grpc/server/test/api"
+	"github.com/yates-z/easel/transport/grpc/utils/metadata"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type greeter struct {
+	api.UnimplementedGreeterServer
+}
+
+func (greeter) SayHello(_ context.Context, in *api.HelloRequest) (*api.HelloResponse, error) {
+	return &api.HelloResponse{Replay: "hello, " + in.Name}, nil
+}
+
+type claims struct {
+	roles []string
+}
+
+func (c claims) GetRoles() []string {
+	return c.roles
+}
+
+func TestInterceptor(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.csv")
+	if err := os.WriteFile(path, []byte("admin,/pb.Greeter/SayHello,call\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	e, err := rbac.NewEnforcer(rbac.NewCSVAdapter(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the role is sent as an API key, to keep the test short.
+	authenticate := auth.APIKey(func(_ context.Context, key string) (any, error) {
+		return claims{roles: []string{key}}, nil
+	})
+	var denied []rbac.Decision
+	s := servertest.New(t, func(s *server.Server) {
+		api.RegisterGreeterServer(s, greeter{})
+	}, servertest.ServerOptions(server.UnaryInterceptor(
+		auth.UnaryServerInterceptor(authenticate),
+		UnaryServerInterceptor(e, WithAudit(func(_ context.Context, d rbac.Decision) {
+			denied = append(denied, d)
+		})),
+	)))
+
+	c := api.NewGreeterClient(s.Conn)
+	for role, code := range map[string]codes.Code{"admin": codes.OK, "user": codes.PermissionDenied} {
+		ctx := metadata.MD{}.Set("x-api-key", role).ToOutgoing(context.Background())
+		if _, err = c.SayHello(ctx, &api.HelloRequest{Name: "easel"}); status.Code(err) != code {
+			t.Fatalf("%s: unexpected error %v", role, err)
+		}
+	}
+	if len(denied) != 1 || denied[0].Resource != "/pb.Greeter/SayHello" || denied[0].Action != ActionCall {
+		t.Fatalf("unexpected audited decisions %+v", denied)
+	}
+}
+
+type jwtClaims struct {
+	jwt.RegisteredClaims
+	Roles []string `json:"roles"`
+}
+
+func (c jwtClaims) GetRoles() []string {
+	return c.Roles
+}
+
+func TestJWTRoles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.csv")
+	if err := os.WriteFile(path, []byte("admin,/pb.Greeter/SayHello,call\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	e, err := rbac.NewEnforcer(rbac.NewCSVAdapter(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret := []byte("secret")
+	s := servertest.New(t, func(s *server.Server) {
+		api.RegisterGreeterServer(s, greeter{})
+	}, servertest.ServerOptions(server.UnaryInterceptor(
+		auth.UnaryServerInterceptor(auth.JWT[jwtClaims](jwt.StaticKey(secret))),
+		UnaryServerInterceptor(e, WithAudit(func(context.Context, rbac.Decision) {})),
+	)))
+
+	c := api.NewGreeterClient(s.Conn)
+	for role, code := range map[string]codes.Code{"admin": codes.OK, "user": codes.PermissionDenied} {
+		raw, err := jwt.Sign(jwt.NewToken(jwt.NewMethodHS256, jwtClaims{
+			RegisteredClaims: jwt.RegisteredClaims{Sub: "easel", Exp: time.Now().Add(time.Hour).Unix()},
+			Roles:            []string{role},
+		}), secret)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx := metadata.MD{}.Set("authorization", "Bearer "+raw).ToOutgoing(context.Background())
+		if _, err = c.SayHello(ctx, &api.HelloRequest{Name: "easel"}); status.Code(err) != code {
+			t.Fatalf("%s: unexpected error %v", role, err)
+		}
+	}
+}
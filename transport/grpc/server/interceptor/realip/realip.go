@@ -2,11 +2,48 @@ package realip
 
 import (
 	"context"
+	"net/netip"
 
 	"github.com/yates-z/easel/logger"
+	"github.com/yates-z/easel/transport/grpc/internal/stream"
+	"github.com/yates-z/easel/transport/realip"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 )
 
+// UnaryServerInterceptor returns a new unary server interceptor that resolves
+// the IP of the client with r, from the peer address and the metadata set by
+// trusted proxies, e.g. "x-forwarded-for", and puts it into the context.
+// Use realip.FromContext to get it.
+func UnaryServerInterceptor(r *realip.Resolver) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		return handler(realip.NewContext(ctx, resolve(ctx, r)), req)
+	}
+}
+
+// StreamServerInterceptor returns a new stream server interceptor that resolves
+// the IP of the client with r and puts it into the context, see UnaryServerInterceptor.
+func StreamServerInterceptor(r *realip.Resolver) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx := ss.Context()
+		return handler(srv, stream.WithContext(ss, realip.NewContext(ctx, resolve(ctx, r))))
+	}
+}
+
+func resolve(ctx context.Context, r *realip.Resolver) netip.Addr {
+	var remoteAddr string
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		remoteAddr = p.Addr.String()
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	return r.Resolve(remoteAddr, md.Get)
+}
+
+// TestInterceptor logs the method and the response of unary calls.
+//
+// Deprecated: use the interceptors of the logging package, which log
+// structured fields and don't log the payloads by default.
 func TestInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
 	// 前置处理：在处理 RPC 之前执行
 	logger.Infof("Unary interceptor: %s", info.FullMethod)
@@ -0,0 +1,49 @@
+package realip
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/yates-z/easel/transport/realip"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	r, err := realip.NewResolver(realip.TrustedProxies("10.0.0.0/8"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	interceptor := UnaryServerInterceptor(r)
+
+	for _, tc := range []struct {
+		name string
+		peer string
+		md   metadata.MD
+		want string
+	}{
+		{"trusted proxy", "10.0.0.1", metadata.Pairs("x-forwarded-for", "198.51.100.1"), "198.51.100.1"},
+		{"forwarded not read", "10.0.0.1", metadata.Pairs("forwarded", "for=198.51.100.1"), "10.0.0.1"},
+		{"untrusted peer", "203.0.113.1", metadata.Pairs("x-forwarded-for", "198.51.100.1"), "203.0.113.1"},
+		{"no metadata", "10.0.0.1", nil, "10.0.0.1"},
+	} {
+		ctx := peer.NewContext(context.Background(), &peer.Peer{
+			Addr: &net.TCPAddr{IP: net.ParseIP(tc.peer), Port: 1234},
+		})
+		if tc.md != nil {
+			ctx = metadata.NewIncomingContext(ctx, tc.md)
+		}
+		_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, _ any) (any, error) {
+			ip, ok := realip.FromContext(ctx)
+			if !ok || ip.String() != tc.want {
+				t.Fatalf("%s: got %s, want %s", tc.name, ip, tc.want)
+			}
+			return nil, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -1,41 +1,105 @@
+// Package timeout bounds the time of the calls handled by a server.
+//
+// The handlers run with a context whose deadline is the earliest of the
+// deadline of the client and the timeout of the method, so that they stop
+// working for clients which gave up. The outgoing calls made with that
+// context send the time left to the next servers, see the client timeout
+// interceptor.
 package timeout
 
 import (
 	"context"
+	"errors"
+	"time"
+
+	"github.com/yates-z/easel/transport/grpc/internal/stream"
+	"github.com/yates-z/easel/transport/internal/match"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
-	"time"
 )
 
-// UnaryServerInterceptor returns a new unary client interceptor that sets a timeout on the request context.
-func UnaryServerInterceptor(timeout time.Duration) grpc.UnaryServerInterceptor {
-	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
-		ctx, cancel := context.WithTimeout(ctx, timeout)
-		defer cancel()
-		var resp interface{}
-		var err error
-		finish := make(chan struct{}, 1)
-		panicChan := make(chan interface{}, 1)
-
-		h := func(c context.Context, r interface{}) {
-			defer func() {
-				if p := recover(); p != nil {
-					panicChan <- p
-				}
-			}()
-			resp, err = handler(c, r)
-			finish <- struct{}{}
+type Option func(*options)
+
+type options struct {
+	timeout time.Duration
+	methods map[string]time.Duration
+}
+
+// Methods with the timeouts of methods, overriding the default timeout. A
+// method is a full method name, e.g. "/pb.Greeter/SayHello", or a service
+// followed by "*", e.g. "/pb.Greeter/*". Full names win over services, and
+// a timeout of 0 disables the timeout of a method, e.g. of a long-lived
+// stream.
+func Methods(timeouts map[string]time.Duration) Option {
+	return func(o *options) {
+		for method, timeout := range timeouts {
+			o.methods[method] = timeout
 		}
+	}
+}
+
+func newOptions(timeout time.Duration, opts ...Option) *options {
+	o := &options{timeout: timeout, methods: make(map[string]time.Duration)}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// lookup returns the timeout of method, the one of its longest pattern.
+func (o *options) lookup(method string) time.Duration {
+	if timeout, ok := match.Longest(o.methods, method); ok {
+		return timeout
+	}
+	return o.timeout
+}
 
-		go h(ctx, req)
-		select {
-		case p := <-panicChan:
-			panic(p)
-		case _ = <-finish:
-			return resp, err
-		case <-ctx.Done():
-			return nil, status.Errorf(codes.DeadlineExceeded, "request %s timed out.", info.FullMethod)
+// context returns the context of a call of method, the deadline of the
+// client is kept if it's earlier.
+func (o *options) context(ctx context.Context, method string) (context.Context, context.CancelFunc) {
+	timeout := o.lookup(method)
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// timedOut replaces the outcome of a call with codes.DeadlineExceeded once
+// its deadline is exceeded, as the client doesn't wait for it anymore.
+func timedOut(ctx context.Context, method string, err error) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return status.Errorf(codes.DeadlineExceeded, "request %s timed out", method)
+	}
+	return err
+}
+
+// UnaryServerInterceptor returns a new unary server interceptor that sets a
+// timeout on the request context, unless the client set an earlier deadline.
+//
+// The handler runs in the goroutine of the call and must return once its
+// context is done.
+func UnaryServerInterceptor(timeout time.Duration, opts ...Option) grpc.UnaryServerInterceptor {
+	o := newOptions(timeout, opts...)
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, cancel := o.context(ctx, info.FullMethod)
+		defer cancel()
+		resp, err := handler(ctx, req)
+		if err = timedOut(ctx, info.FullMethod, err); err != nil {
+			return nil, err
 		}
+		return resp, nil
+	}
+}
+
+// StreamServerInterceptor returns a new stream server interceptor that sets
+// a timeout on the stream context, unless the client set an earlier deadline.
+func StreamServerInterceptor(timeout time.Duration, opts ...Option) grpc.StreamServerInterceptor {
+	o := newOptions(timeout, opts...)
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, cancel := o.context(ss.Context(), info.FullMethod)
+		defer cancel()
+		err := handler(srv, stream.WithContext(ss, ctx))
+		return timedOut(ctx, info.FullMethod, err)
 	}
 }
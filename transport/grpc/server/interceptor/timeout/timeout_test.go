@@ -0,0 +1,104 @@
+package timeout
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/yates-z/easel/transport/grpc/server"
+	"github.com/yates-z/easel/transport/grpc/server/servertest"
+	"github.com/yates-z/easel/transport/grpc/server/test/api"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// greeter replies with the time left before the deadline of the call, or
+// waits for the deadline if the name is "wait".
+type greeter struct {
+	api.UnimplementedGreeterServer
+}
+
+func (greeter) SayHello(ctx context.Context, in *api.HelloRequest) (*api.HelloResponse, error) {
+	if in.Name == "wait" {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return &api.HelloResponse{}, nil
+	}
+	return &api.HelloResponse{Replay: time.Until(deadline).String()}, nil
+}
+
+func newServer(t *testing.T, timeout time.Duration, opts ...Option) *servertest.Server {
+	return servertest.New(t, func(s *server.Server) {
+		api.RegisterGreeterServer(s, greeter{})
+	}, servertest.ServerOptions(
+		server.UnaryInterceptor(UnaryServerInterceptor(timeout, opts...)),
+		server.StreamInterceptor(StreamServerInterceptor(timeout, opts...)),
+	))
+}
+
+// left returns the time left of the call seen by the handler.
+func left(t *testing.T, c api.GreeterClient, ctx context.Context) time.Duration {
+	res, err := c.SayHello(ctx, &api.HelloRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Replay == "" {
+		return 0
+	}
+	d, err := time.ParseDuration(res.Replay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	s := newServer(t, time.Second, Methods(map[string]time.Duration{
+		"/pb.Greeter/*": time.Minute,
+	}))
+	c := api.NewGreeterClient(s.Conn)
+
+	if d := left(t, c, context.Background()); d <= time.Second || d > time.Minute {
+		t.Fatalf("unexpected time left %v", d)
+	}
+	// the client deadline is earlier
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if d := left(t, c, ctx); d <= 0 || d > 100*time.Millisecond {
+		t.Fatalf("unexpected time left %v", d)
+	}
+
+	s = newServer(t, 20*time.Millisecond, Methods(map[string]time.Duration{
+		"/pb.Greeter/*":        time.Minute,
+		"/pb.Greeter/SayHello": 0,
+	}))
+	c = api.NewGreeterClient(s.Conn)
+	if d := left(t, c, context.Background()); d != 0 {
+		t.Fatalf("unexpected time left %v, want no deadline", d)
+	}
+
+	s = newServer(t, 20*time.Millisecond)
+	c = api.NewGreeterClient(s.Conn)
+	if _, err := c.SayHello(context.Background(), &api.HelloRequest{Name: "wait"}); status.Code(err) != codes.DeadlineExceeded {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	s := newServer(t, 20*time.Millisecond)
+	stream, err := grpc_health_v1.NewHealthClient(s.Conn).Watch(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the watch lasts until its context is done
+	if _, err := stream.Recv(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stream.Recv(); status.Code(err) != codes.DeadlineExceeded {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
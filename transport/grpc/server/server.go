@@ -36,6 +36,13 @@ func Address(addr string) ServerOption {
 	}
 }
 
+// Listener with a server listener, network and address are ignored if set.
+func Listener(lis net.Listener) ServerOption {
+	return func(s *Server) {
+		s.listener = lis
+	}
+}
+
 // TLSConfig with TLS config.
 func TLSConfig(c *tls.Config) ServerOption {
 	return func(s *Server) {
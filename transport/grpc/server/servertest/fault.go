@@ -0,0 +1,179 @@
+package servertest
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// AnyMethod matches every method in Injector.Inject.
+const AnyMethod = "*"
+
+// Fault describes a failure injected into a call.
+type Fault struct {
+	// Delay holds the call before it reaches the handler. The call fails with
+	// the status of its context if the context is done first.
+	Delay time.Duration
+	// Code fails the call with Message instead of calling the handler,
+	// unless it is codes.OK.
+	Code    codes.Code
+	Message string
+	// Trailer is sent with the response, e.g. "grpc-retry-pushback-ms".
+	Trailer metadata.MD
+	// Drop fails unary calls with codes.Unavailable, and aborts streams with
+	// codes.Unavailable once the handler sent DropAfter messages.
+	Drop      bool
+	DropAfter int
+	// Times is the number of calls the fault applies to, 0 means every call.
+	Times int
+}
+
+type rule struct {
+	method string
+	fault  Fault
+	left   int
+}
+
+// Injector injects faults into the calls of a server. Faults of a method
+// apply in the order they were injected.
+type Injector struct {
+	mu    sync.Mutex
+	rules []*rule
+	calls map[string]int
+}
+
+// NewInjector creates an Injector without faults.
+func NewInjector() *Injector {
+	return &Injector{calls: make(map[string]int)}
+}
+
+// Inject adds a fault to the calls of method, e.g. "/pb.Greeter/SayHello",
+// or AnyMethod.
+func (i *Injector) Inject(method string, fault Fault) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.rules = append(i.rules, &rule{method: method, fault: fault, left: fault.Times})
+}
+
+// Reset removes all faults and call counts.
+func (i *Injector) Reset() {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.rules = nil
+	clear(i.calls)
+}
+
+// Calls returns the number of calls of method received by the server,
+// including the failed ones.
+func (i *Injector) Calls(method string) int {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if method == AnyMethod {
+		n := 0
+		for _, c := range i.calls {
+			n += c
+		}
+		return n
+	}
+	return i.calls[method]
+}
+
+// next counts a call of method and returns the fault that applies to it.
+func (i *Injector) next(method string) (Fault, bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.calls[method]++
+	for idx, r := range i.rules {
+		if r.method != method && r.method != AnyMethod {
+			continue
+		}
+		if r.fault.Times > 0 {
+			if r.left--; r.left == 0 {
+				i.rules = append(i.rules[:idx:idx], i.rules[idx+1:]...)
+			}
+		}
+		return r.fault, true
+	}
+	return Fault{}, false
+}
+
+// apply runs the part of f before the handler, it returns a non nil error if
+// the call fails without reaching the handler.
+func (f Fault) apply(ctx context.Context, unary bool) error {
+	if len(f.Trailer) > 0 {
+		_ = grpc.SetTrailer(ctx, f.Trailer)
+	}
+	if f.Delay > 0 {
+		timer := time.NewTimer(f.Delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return status.FromContextError(ctx.Err()).Err()
+		case <-timer.C:
+		}
+	}
+	if f.Code != codes.OK {
+		return status.Error(f.Code, f.Message)
+	}
+	if f.Drop && unary {
+		return errDropped
+	}
+	return nil
+}
+
+var errDropped = status.Error(codes.Unavailable, "servertest: dropped")
+
+// UnaryServerInterceptor returns a server interceptor injecting the faults of i.
+func (i *Injector) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if f, ok := i.next(info.FullMethod); ok {
+			if err := f.apply(ctx, true); err != nil {
+				return nil, err
+			}
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor returns a stream server interceptor injecting the faults of i.
+func (i *Injector) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		f, ok := i.next(info.FullMethod)
+		if !ok {
+			return handler(srv, ss)
+		}
+		if err := f.apply(ss.Context(), false); err != nil {
+			return err
+		}
+		if !f.Drop {
+			return handler(srv, ss)
+		}
+		ds := &droppingStream{ServerStream: ss, left: f.DropAfter}
+		err := handler(srv, ds)
+		if ds.dropped {
+			return errDropped
+		}
+		return err
+	}
+}
+
+// droppingStream fails SendMsg once left messages were sent.
+type droppingStream struct {
+	grpc.ServerStream
+	left    int
+	dropped bool
+}
+
+func (s *droppingStream) SendMsg(m any) error {
+	if s.left <= 0 {
+		s.dropped = true
+		return errDropped
+	}
+	s.left--
+	return s.ServerStream.SendMsg(m)
+}
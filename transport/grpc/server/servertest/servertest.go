@@ -0,0 +1,152 @@
+// Package servertest runs a server.Server on an in-memory listener.
+//
+//	s := servertest.New(t, func(s *server.Server) {
+//		api.RegisterGreeterServer(s, &greeter{})
+//	})
+//	s.Faults.Inject("/pb.Greeter/SayHello", servertest.Fault{Code: codes.Unavailable, Times: 2})
+//	reply, err := api.NewGreeterClient(s.Conn).SayHello(ctx, in)
+package servertest
+
+import (
+	"context"
+	"net"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/yates-z/easel/transport/grpc/client"
+	"github.com/yates-z/easel/transport/grpc/server"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type Option func(*options)
+
+type options struct {
+	bufSize      int
+	serverOpts   []server.ServerOption
+	dialOpts     []client.DialOption
+	readyTimeout time.Duration
+}
+
+// ServerOptions with options of the server, e.g. its interceptors.
+func ServerOptions(opts ...server.ServerOption) Option {
+	return func(o *options) {
+		o.serverOpts = append(o.serverOpts, opts...)
+	}
+}
+
+// DialOptions with options of the client connection, e.g. its interceptors.
+func DialOptions(opts ...client.DialOption) Option {
+	return func(o *options) {
+		o.dialOpts = append(o.dialOpts, opts...)
+	}
+}
+
+// BufferSize with the buffer size of the in-memory listener, 1MiB by default.
+func BufferSize(size int) Option {
+	return func(o *options) {
+		o.bufSize = size
+	}
+}
+
+// ReadyTimeout with the time New waits for the connection to be ready.
+func ReadyTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.readyTimeout = timeout
+	}
+}
+
+// Server is a started server and a connection to it.
+type Server struct {
+	*server.Server
+	// Conn is a ready connection to the server.
+	Conn *grpc.ClientConn
+	// Faults injects failures into the calls handled by the server.
+	Faults *Injector
+
+	t        testing.TB
+	listener *bufconn.Listener
+	opts     *options
+}
+
+// New starts a server with the services registered by register, and
+// connects to it. Both are closed when the test finishes.
+//
+// Faults are injected before the interceptors of the server run.
+func New(t testing.TB, register func(*server.Server), opts ...Option) *Server {
+	t.Helper()
+	o := &options{
+		bufSize:      1 << 20,
+		readyTimeout: 5 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	faults := NewInjector()
+	lis := bufconn.Listen(o.bufSize)
+	serverOpts := slices.Concat([]server.ServerOption{
+		server.Listener(lis),
+		server.GRPCOptions(
+			grpc.ChainUnaryInterceptor(faults.UnaryServerInterceptor()),
+			grpc.ChainStreamInterceptor(faults.StreamServerInterceptor()),
+		),
+	}, o.serverOpts)
+	s := &Server{
+		Server:   server.NewServer(serverOpts...),
+		Faults:   faults,
+		t:        t,
+		listener: lis,
+		opts:     o,
+	}
+	if register != nil {
+		register(s.Server)
+	}
+
+	go func() {
+		_ = s.Start(context.Background())
+	}()
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		_ = s.Stop(ctx)
+	})
+
+	conn, err := s.Dial()
+	if err != nil {
+		t.Fatalf("servertest: dial: %v", err)
+	}
+	s.Conn = conn
+	return s
+}
+
+// Dial creates another ready connection to the server, with opts added to
+// the dial options given to New. It is closed when the test finishes.
+func (s *Server) Dial(opts ...client.DialOption) (*grpc.ClientConn, error) {
+	dialer := func(ctx context.Context, _ string) (net.Conn, error) {
+		return s.listener.DialContext(ctx)
+	}
+	conn, err := client.NewInsecureClient("passthrough:///bufconn", slices.Concat(
+		[]client.DialOption{client.GRPCOptions(grpc.WithContextDialer(dialer))},
+		s.opts.dialOpts,
+		opts,
+	)...)
+	if err != nil {
+		return nil, err
+	}
+	s.t.Cleanup(func() {
+		_ = conn.Close()
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.opts.readyTimeout)
+	defer cancel()
+	conn.Connect()
+	for state := conn.GetState(); state != connectivity.Ready; state = conn.GetState() {
+		if !conn.WaitForStateChange(ctx, state) {
+			return nil, ctx.Err()
+		}
+	}
+	return conn, nil
+}
@@ -0,0 +1,130 @@
+package servertest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/yates-z/easel/transport/grpc/client"
+	"github.com/yates-z/easel/transport/grpc/client/interceptor/retry"
+	"github.com/yates-z/easel/transport/grpc/client/interceptor/timeout"
+	"github.com/yates-z/easel/transport/grpc/server"
+	"github.com/yates-z/easel/transport/grpc/server/interceptor/recovery"
+	"github.com/yates-z/easel/transport/grpc/server/test/api"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const sayHello = "/pb.Greeter/SayHello"
+
+type greeter struct {
+	api.UnimplementedGreeterServer
+}
+
+func (greeter) SayHello(_ context.Context, in *api.HelloRequest) (*api.HelloResponse, error) {
+	if in.Name == "panic" {
+		panic("boom")
+	}
+	return &api.HelloResponse{Replay: "hello, " + in.Name}, nil
+}
+
+func register(s *server.Server) {
+	api.RegisterGreeterServer(s, greeter{})
+}
+
+func TestServer(t *testing.T) {
+	s := New(t, register, ServerOptions(server.UnaryInterceptor(recovery.UnaryServerInterceptor())))
+	c := api.NewGreeterClient(s.Conn)
+
+	reply, err := c.SayHello(context.Background(), &api.HelloRequest{Name: "easel"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reply.Replay != "hello, easel" {
+		t.Fatalf("unexpected reply %q", reply.Replay)
+	}
+	// the server interceptors are applied.
+	if _, err = c.SayHello(context.Background(), &api.HelloRequest{Name: "panic"}); status.Code(err) == codes.OK {
+		t.Fatal("expected the recovered panic to fail the call")
+	}
+	if n := s.Faults.Calls(sayHello); n != 2 {
+		t.Fatalf("calls = %d, want 2", n)
+	}
+}
+
+func TestFaultCode(t *testing.T) {
+	s := New(t, register, DialOptions(client.UnaryInterceptor(
+		retry.UnaryClientInterceptor(3, time.Millisecond),
+	)))
+	s.Faults.Inject(sayHello, Fault{Code: codes.Unavailable, Message: "try again", Times: 2})
+
+	_, err := api.NewGreeterClient(s.Conn).SayHello(context.Background(), &api.HelloRequest{Name: "easel"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := s.Faults.Calls(sayHello); n != 3 {
+		t.Fatalf("calls = %d, want 3", n)
+	}
+
+	s.Faults.Reset()
+	s.Faults.Inject(AnyMethod, Fault{Code: codes.PermissionDenied})
+	_, err = api.NewGreeterClient(s.Conn).SayHello(context.Background(), &api.HelloRequest{Name: "easel"})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if n := s.Faults.Calls(sayHello); n != 1 {
+		t.Fatalf("non retriable code was retried, calls = %d", n)
+	}
+}
+
+func TestFaultDelay(t *testing.T) {
+	s := New(t, register)
+	s.Faults.Inject(sayHello, Fault{Delay: time.Minute})
+
+	conn, err := s.Dial(client.UnaryInterceptor(timeout.UnaryClientInterceptor(20 * time.Millisecond)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	_, err = api.NewGreeterClient(conn).SayHello(context.Background(), &api.HelloRequest{Name: "easel"})
+	if status.Code(err) != codes.DeadlineExceeded {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Fatal("delay was not cut short by the deadline")
+	}
+}
+
+func TestFaultTrailerAndDrop(t *testing.T) {
+	s := New(t, register)
+	s.Faults.Inject(sayHello, Fault{Drop: true, Trailer: metadata.Pairs("grpc-retry-pushback-ms", "10"), Times: 1})
+
+	var trailer metadata.MD
+	_, err := api.NewGreeterClient(s.Conn).SayHello(context.Background(), &api.HelloRequest{Name: "easel"}, grpc.Trailer(&trailer))
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if v := trailer.Get("grpc-retry-pushback-ms"); len(v) != 1 || v[0] != "10" {
+		t.Fatalf("unexpected trailer %v", trailer)
+	}
+	// the fault applied once.
+	if _, err = api.NewGreeterClient(s.Conn).SayHello(context.Background(), &api.HelloRequest{Name: "easel"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFaultDropStream(t *testing.T) {
+	s := New(t, nil)
+	s.Faults.Inject("/grpc.health.v1.Health/Watch", Fault{Drop: true})
+
+	stream, err := grpc_health_v1.NewHealthClient(s.Conn).Watch(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = stream.Recv(); status.Code(err) != codes.Unavailable {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
@@ -0,0 +1,43 @@
+// Code generated by protoc-gen-go-easel. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-easel v0.1.0
+// - protoc              v5.26.1
+// source: api/hello.proto
+
+package api
+
+import (
+	context "context"
+	client "github.com/yates-z/easel/transport/http/client"
+	server "github.com/yates-z/easel/transport/http/server"
+	adapter "github.com/yates-z/easel/transport/http/server/adapter"
+)
+
+// RegisterGreeterHTTPServer registers the Greeter service on the router.
+// Requests are bound and replies are encoded by adapter.GRPC,
+// the routes are documented with the service name as tag.
+func RegisterGreeterHTTPServer(router server.IRouter, srv GreeterServer) {
+	adapter.Handle(router, "POST", "/pb.Greeter/SayHello", srv.SayHello).Tags("pb.Greeter")
+}
+
+// GreeterHTTPClient is the HTTP client API for Greeter service.
+type GreeterHTTPClient interface {
+	SayHello(ctx context.Context, in *HelloRequest, opts ...client.CallOption) (*HelloResponse, error)
+}
+
+type greeterHTTPClient struct {
+	cc *client.Client
+}
+
+func NewGreeterHTTPClient(cc *client.Client) GreeterHTTPClient {
+	return &greeterHTTPClient{cc}
+}
+
+func (c *greeterHTTPClient) SayHello(ctx context.Context, in *HelloRequest, opts ...client.CallOption) (*HelloResponse, error) {
+	out := new(HelloResponse)
+	path := client.EncodeURL("/pb.Greeter/SayHello", in, false)
+	if err := c.cc.Invoke(ctx, "POST", path, in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,53 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/yates-z/easel/transport/breaker"
+)
+
+// Breaker with the breakers of the requests, keyed by key, the host of the
+// request by default. The requests rejected by their breakers fail with
+// breaker.ErrOpen, and the requests failing or replying with a 5xx or a 429
+// status count as failures.
+func Breaker(g *breaker.Group, key func(*http.Request) string) ClientOption {
+	return func(c *Client) {
+		c.breakers = g
+		c.breakerKey = key
+	}
+}
+
+// BreakerTransport returns a http.RoundTripper sending the requests with rt
+// while their breakers allow them, to use a breaker.Group from any
+// http.Client. A nil key keys the breakers by host.
+func BreakerTransport(rt http.RoundTripper, g *breaker.Group, key func(*http.Request) string) http.RoundTripper {
+	if key == nil {
+		key = func(req *http.Request) string {
+			return req.URL.Host
+		}
+	}
+	return &breakerTransport{next: rt, group: g, key: key}
+}
+
+type breakerTransport struct {
+	next  http.RoundTripper
+	group *breaker.Group
+	key   func(*http.Request) string
+}
+
+func (t *breakerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	done, err := t.group.Get(t.key(req)).Allow()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		// a canceled request isn't a failure of the server
+		done(errors.Is(err, context.Canceled))
+		return nil, err
+	}
+	done(resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests)
+	return resp, nil
+}
@@ -0,0 +1,243 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/yates-z/easel/transport/breaker"
+	"github.com/yates-z/easel/transport/grpc/encoding/form"
+	_ "github.com/yates-z/easel/transport/grpc/encoding/json"
+	_ "github.com/yates-z/easel/transport/grpc/encoding/proto"
+	_ "github.com/yates-z/easel/transport/grpc/encoding/xml"
+	"google.golang.org/grpc/encoding"
+)
+
+type ClientOption func(*Client)
+
+// Endpoint with client endpoint, e.g. "http://127.0.0.1:8000".
+func Endpoint(endpoint string) ClientOption {
+	return func(c *Client) {
+		c.endpoint = endpoint
+	}
+}
+
+// Timeout with client request timeout.
+func Timeout(timeout time.Duration) ClientOption {
+	return func(c *Client) {
+		c.timeout = timeout
+	}
+}
+
+// TLSConfig with TLS config.
+func TLSConfig(conf *tls.Config) ClientOption {
+	return func(c *Client) {
+		c.tlsConf = conf
+	}
+}
+
+// Transport with a custom http.RoundTripper.
+func Transport(rt http.RoundTripper) ClientOption {
+	return func(c *Client) {
+		c.transport = rt
+	}
+}
+
+// ContentType with the content type used to encode request bodies.
+// The codec is looked up by its subtype, the same way the server adapter does.
+func ContentType(contentType string) ClientOption {
+	return func(c *Client) {
+		c.contentType = contentType
+	}
+}
+
+// Header with a header sent on every request.
+func Header(key, value string) ClientOption {
+	return func(c *Client) {
+		c.header.Add(key, value)
+	}
+}
+
+// Client is an HTTP client speaking the same codecs as adapter.GRPC.
+type Client struct {
+	endpoint    string
+	target      *url.URL
+	timeout     time.Duration
+	tlsConf     *tls.Config
+	transport   http.RoundTripper
+	contentType string
+	header      http.Header
+	breakers    *breaker.Group
+	breakerKey  func(*http.Request) string
+	hc          *http.Client
+}
+
+// NewClient creates an HTTP client.
+func NewClient(opts ...ClientOption) (*Client, error) {
+	c := &Client{
+		contentType: "application/json",
+		header:      make(http.Header),
+		transport:   http.DefaultTransport,
+	}
+	for _, o := range opts {
+		o(c)
+	}
+	endpoint := c.endpoint
+	if !strings.Contains(endpoint, "://") {
+		scheme := "http://"
+		if c.tlsConf != nil {
+			scheme = "https://"
+		}
+		endpoint = scheme + endpoint
+	}
+	target, err := url.Parse(strings.TrimSuffix(endpoint, "/"))
+	if err != nil {
+		return nil, err
+	}
+	c.target = target
+	if c.tlsConf != nil {
+		if t, ok := c.transport.(*http.Transport); ok {
+			t = t.Clone()
+			t.TLSClientConfig = c.tlsConf
+			c.transport = t
+		}
+	}
+	if c.breakers != nil {
+		c.transport = BreakerTransport(c.transport, c.breakers, c.breakerKey)
+	}
+	c.hc = &http.Client{Transport: c.transport, Timeout: c.timeout}
+	return c, nil
+}
+
+// CallOption configures a single Invoke call.
+type CallOption func(*callInfo)
+
+type callInfo struct {
+	header http.Header
+}
+
+// WithCallHeader sets a header on a single call.
+func WithCallHeader(key, value string) CallOption {
+	return func(c *callInfo) {
+		c.header.Add(key, value)
+	}
+}
+
+// Invoke sends the request and decodes the reply into out.
+// A nil in sends no request body.
+func (c *Client) Invoke(ctx context.Context, method, path string, in, out any, opts ...CallOption) error {
+	info := callInfo{header: make(http.Header)}
+	for _, o := range opts {
+		o(&info)
+	}
+	codec := encoding.GetCodec(contentSubtype(c.contentType))
+	if codec == nil {
+		return fmt.Errorf("unregister Content-Type: %s", c.contentType)
+	}
+
+	var body io.Reader
+	if in != nil {
+		data, err := codec.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.target.String()+path, body)
+	if err != nil {
+		return err
+	}
+	for k, v := range c.header {
+		req.Header[k] = v
+	}
+	for k, v := range info.header {
+		req.Header[k] = v
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", c.contentType)
+	}
+	req.Header.Set("Accept", c.contentType)
+
+	resp, err := c.hc.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return newError(resp, data)
+	}
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	if replyCodec := encoding.GetCodec(contentSubtype(resp.Header.Get("Content-Type"))); replyCodec != nil {
+		codec = replyCodec
+	}
+	return codec.Unmarshal(data, out)
+}
+
+// Error is returned by Invoke when the server replies with a non-2xx status.
+type Error struct {
+	// Code is the HTTP status code.
+	Code int `json:"code"`
+	// Message is the error message sent by the server.
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("http error: code = %d message = %s", e.Code, e.Message)
+}
+
+func newError(resp *http.Response, data []byte) *Error {
+	e := &Error{}
+	if contentSubtype(resp.Header.Get("Content-Type")) == "json" {
+		_ = json.Unmarshal(data, e)
+	}
+	if e.Message == "" {
+		e.Message = strings.TrimSpace(string(data))
+	}
+	e.Code = resp.StatusCode
+	return e
+}
+
+// EncodeURL renders the path parameters of pattern from the fields of msg.
+// If needQuery is true, the remaining fields are appended as the query string.
+func EncodeURL(pattern string, msg any, needQuery bool) string {
+	values, _ := form.EncodeValues(msg)
+	path := paramPattern.ReplaceAllStringFunc(pattern, func(s string) string {
+		m := paramPattern.FindStringSubmatch(s)
+		name := strings.TrimSpace(m[1])
+		multi := strings.HasSuffix(name, "...")
+		name = strings.TrimSuffix(name, "...")
+		value := lookupValue(values, name)
+		values.Del(name)
+		values.Del(jsonCamelCase(name))
+		if !multi {
+			return url.PathEscape(value)
+		}
+		// a trailing wildcard keeps its slashes.
+		segments := strings.Split(value, "/")
+		for i, seg := range segments {
+			segments[i] = url.PathEscape(seg)
+		}
+		return strings.Join(segments, "/")
+	})
+	if needQuery && len(values) > 0 {
+		if query := values.Encode(); query != "" {
+			path += "?" + query
+		}
+	}
+	return path
+}
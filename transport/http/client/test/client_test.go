@@ -0,0 +1,116 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/yates-z/easel/transport/breaker"
+	"github.com/yates-z/easel/transport/grpc/server/test/api"
+	"github.com/yates-z/easel/transport/http/client"
+	"github.com/yates-z/easel/transport/http/server"
+)
+
+type greeter struct {
+	api.UnimplementedGreeterServer
+}
+
+func (greeter) SayHello(_ context.Context, in *api.HelloRequest) (*api.HelloResponse, error) {
+	if in.Name == "" {
+		return nil, errors.New("name is required")
+	}
+	return &api.HelloResponse{Replay: "hello, " + in.Name}, nil
+}
+
+func newTestClient(t *testing.T, opts ...client.ClientOption) api.GreeterHTTPClient {
+	s := server.NewServer()
+	api.RegisterGreeterHTTPServer(s, greeter{})
+	ts := httptest.NewServer(s.Handler)
+	t.Cleanup(ts.Close)
+
+	cc, err := client.NewClient(append([]client.ClientOption{client.Endpoint(ts.URL)}, opts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return api.NewGreeterHTTPClient(cc)
+}
+
+func TestGeneratedClient(t *testing.T) {
+	for _, contentType := range []string{"application/json", "application/x-www-form-urlencoded"} {
+		c := newTestClient(t, client.ContentType(contentType))
+		reply, err := c.SayHello(context.Background(), &api.HelloRequest{Name: "easel"})
+		if err != nil {
+			t.Fatal(contentType, err)
+		}
+		if reply.Replay != "hello, easel" {
+			t.Fatalf("%s: unexpected reply %q", contentType, reply.Replay)
+		}
+	}
+}
+
+func TestGeneratedClientError(t *testing.T) {
+	c := newTestClient(t)
+	_, err := c.SayHello(context.Background(), &api.HelloRequest{})
+	var e *client.Error
+	if !errors.As(err, &e) {
+		t.Fatalf("expected *client.Error, got %v", err)
+	}
+	if e.Code != 400 || e.Message != "name is required" {
+		t.Fatalf("unexpected error %+v", e)
+	}
+}
+
+func TestBreaker(t *testing.T) {
+	calls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.URL.Path == "/bad" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(ts.Close)
+	g := breaker.NewGroup(func(name string) breaker.Breaker {
+		return breaker.NewCircuitBreaker(breaker.MinRequests(2))
+	})
+	cc, err := client.NewClient(client.Endpoint(ts.URL), client.Breaker(g, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	// client errors aren't failures
+	for range 2 {
+		if err := cc.Invoke(ctx, http.MethodGet, "/bad", nil, nil); err == nil {
+			t.Fatal("expected an error")
+		}
+	}
+	for range 2 {
+		var e *client.Error
+		if err := cc.Invoke(ctx, http.MethodGet, "/", nil, nil); !errors.As(err, &e) || e.Code != http.StatusServiceUnavailable {
+			t.Fatalf("unexpected error %v", err)
+		}
+	}
+	if err := cc.Invoke(ctx, http.MethodGet, "/", nil, nil); !errors.Is(err, breaker.ErrOpen) {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if calls != 4 {
+		t.Fatalf("unexpected calls %d", calls)
+	}
+}
+
+func TestEncodeURL(t *testing.T) {
+	in := &api.HelloRequest{Name: "a b"}
+	if got := client.EncodeURL("/v1/hello/{name}", in, true); got != "/v1/hello/a%20b" {
+		t.Fatalf("unexpected path %q", got)
+	}
+	if got := client.EncodeURL("/v1/hello", in, true); got != "/v1/hello?name=a+b" {
+		t.Fatalf("unexpected path %q", got)
+	}
+	if got := client.EncodeURL("/v1/hello", in, false); got != "/v1/hello" {
+		t.Fatalf("unexpected path %q", got)
+	}
+}
@@ -0,0 +1,51 @@
+package client
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// paramPattern matches path parameters the same way adapter.GRPC binds them.
+var paramPattern = regexp.MustCompile(`(?i){([a-z.0-9_\s]*)=?([^{}]*)}`)
+
+// lookupValue finds a path parameter by its proto name or its json name.
+func lookupValue(values url.Values, name string) string {
+	if v, ok := values[name]; ok && len(v) > 0 {
+		return v[0]
+	}
+	return values.Get(jsonCamelCase(name))
+}
+
+// jsonCamelCase converts a snake_case identifier to a camelCase identifier,
+// according to the protobuf JSON specification.
+func jsonCamelCase(s string) string {
+	var b []byte
+	var wasUnderscore bool
+	for i := 0; i < len(s); i++ { // proto identifiers are always ASCII
+		c := s[i]
+		if c != '_' {
+			if wasUnderscore && 'a' <= c && c <= 'z' {
+				c -= 'a' - 'A' // convert to uppercase
+			}
+			b = append(b, c)
+		}
+		wasUnderscore = c == '_'
+	}
+	return string(b)
+}
+
+func contentSubtype(contentType string) string {
+	left := strings.Index(contentType, "/")
+	if left == -1 {
+		return ""
+	}
+	right := strings.Index(contentType, ";")
+	if right == -1 {
+		right = len(contentType)
+	}
+	if right < left {
+		return ""
+	}
+	return contentType[left+1 : right]
+}
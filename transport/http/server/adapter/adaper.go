@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"net/url"
+	"reflect"
 	"regexp"
 	"strings"
 
@@ -25,6 +26,14 @@ var (
 	_ = proto.Name
 )
 
+// Handle registers f on the router through GRPC, and documents the route with
+// the request and reply types of f.
+func Handle[T1 any, T2 any](router server.IRoute, method, path string, f func(context.Context, *T1) (*T2, error), middlewares ...server.Middleware) *server.Route {
+	return router.Handle(method, path, GRPC(f), middlewares...).
+		Request(reflect.TypeFor[T1]()).
+		Reply(reflect.TypeFor[T2]())
+}
+
 func GRPC[T1 any, T2 any](f func(context.Context, *T1) (*T2, error)) server.HandlerFunc {
 	return func(ctx *server.Context) error {
 		var in T1
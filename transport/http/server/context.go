@@ -16,6 +16,7 @@ import (
 	"time"
 
 	"github.com/yates-z/easel/logger"
+	"github.com/yates-z/easel/transport/realip"
 	"google.golang.org/protobuf/proto"
 )
 
@@ -339,8 +340,12 @@ func (c *Context) IsWebsocket() bool {
 	return false
 }
 
-// RemoteIP parses the IP from Request.RemoteAddr, normalizes and returns the IP (without the port).
+// RemoteIP returns the IP of the client resolved by the RealIP option, or
+// parses the IP from Request.RemoteAddr, normalizes and returns the IP (without the port).
 func (c *Context) RemoteIP() string {
+	if ip, ok := realip.FromContext(c); ok {
+		return ip.String()
+	}
 	ip, _, err := net.SplitHostPort(strings.TrimSpace(c.Request.RemoteAddr))
 	if err != nil {
 		return ""
@@ -26,6 +26,7 @@ func Middleware() server.Middleware {
 				codeField,
 				logger.String("path", ctx.Request.URL.Path),
 				logger.String("query", ctx.Request.URL.RawQuery),
+				logger.String("client_ip", ctx.RemoteIP()),
 				logger.String("duration", time.Since(startTime).String()),
 			}
 			if err != nil {
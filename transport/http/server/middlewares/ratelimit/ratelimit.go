@@ -0,0 +1,101 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/yates-z/easel/transport/http/server"
+	"github.com/yates-z/easel/transport/internal/match"
+	"github.com/yates-z/easel/transport/limiter"
+)
+
+// PriorityFunc returns the priority of a request.
+type PriorityFunc func(ctx *server.Context) limiter.Priority
+
+type Option func(*options)
+
+type options struct {
+	critical   []string
+	low        []string
+	priority   PriorityFunc
+	retryAfter time.Duration
+}
+
+// CriticalPaths with the paths never shed, e.g. "/healthz". A pattern ending
+// with "*" matches the paths with its prefix.
+func CriticalPaths(patterns ...string) Option {
+	return func(o *options) {
+		o.critical = append(o.critical, patterns...)
+	}
+}
+
+// LowPriorityPaths with the paths shed first, e.g. "/reports/*", see
+// limiter.LowPriorityShare.
+func LowPriorityPaths(patterns ...string) Option {
+	return func(o *options) {
+		o.low = append(o.low, patterns...)
+	}
+}
+
+// WithPriority with the function returning the priority of the requests
+// matching neither CriticalPaths nor LowPriorityPaths.
+func WithPriority(f PriorityFunc) Option {
+	return func(o *options) {
+		o.priority = f
+	}
+}
+
+// RetryAfter with the Retry-After header of the responses of shed requests,
+// 1 second by default. Zero means no header.
+func RetryAfter(d time.Duration) Option {
+	return func(o *options) {
+		o.retryAfter = d
+	}
+}
+
+func newOptions(opts ...Option) *options {
+	o := &options{retryAfter: time.Second}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+func (o *options) priorityOf(ctx *server.Context) limiter.Priority {
+	switch {
+	case match.Any(o.critical, ctx.Request.URL.Path):
+		return limiter.PriorityCritical
+	case match.Any(o.low, ctx.Request.URL.Path):
+		return limiter.PriorityLow
+	case o.priority != nil:
+		return o.priority(ctx)
+	}
+	return limiter.PriorityNormal
+}
+
+// Middleware sheds the requests rejected by l with 503 Service Unavailable.
+// The admitted requests are released once served, and count as dropped when
+// their deadline is exceeded or they respond 504 Gateway Timeout.
+func Middleware(l limiter.Limiter, opts ...Option) server.Middleware {
+	o := newOptions(opts...)
+
+	return func(next server.HandlerFunc) server.HandlerFunc {
+		return func(ctx *server.Context) error {
+			release, err := l.Acquire(limiter.WithPriority(ctx, o.priorityOf(ctx)))
+			if err != nil {
+				if o.retryAfter > 0 {
+					seconds := int((o.retryAfter + time.Second - 1) / time.Second)
+					ctx.Response.Header().Set("Retry-After", strconv.Itoa(seconds))
+				}
+				return ctx.String(http.StatusServiceUnavailable, http.StatusText(http.StatusServiceUnavailable))
+			}
+			defer func() {
+				release(errors.Is(ctx.Err(), context.DeadlineExceeded) || ctx.Response.StatusCode() == http.StatusGatewayTimeout)
+			}()
+			return next(ctx)
+		}
+	}
+}
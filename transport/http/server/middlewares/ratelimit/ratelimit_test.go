@@ -0,0 +1,46 @@
+package ratelimit
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/yates-z/easel/transport/http/server"
+	"github.com/yates-z/easel/transport/http/server/servertest"
+	"github.com/yates-z/easel/transport/limiter"
+)
+
+// fixed is a limiter.Algorithm with a fixed limit.
+type fixed int
+
+func (f fixed) Limit() int { return int(f) }
+
+func (fixed) Update(time.Duration, int, bool) {}
+
+func TestMiddleware(t *testing.T) {
+	l := limiter.New(fixed(1))
+	s := server.NewServer(server.Middlewares(Middleware(l, CriticalPaths("/healthz"), LowPriorityPaths("/reports/*"))))
+	var nested *servertest.Response
+	h := servertest.New(t, s)
+	s.GET("/slow", func(c *server.Context) error {
+		// the limit is reached while this request is in flight
+		nested = h.GET("/slow").Expect()
+		return c.String(http.StatusOK, "ok")
+	})
+	s.GET("/healthz", func(c *server.Context) error {
+		return c.String(http.StatusOK, "ok")
+	})
+	s.GET("/reports/daily", func(c *server.Context) error {
+		return c.String(http.StatusOK, "ok")
+	})
+
+	h.GET("/slow").Expect().Status(http.StatusOK)
+	nested.Status(http.StatusServiceUnavailable).Header("Retry-After", "1")
+	if l.Inflight() != 0 {
+		t.Fatalf("the request isn't released, %d in-flight requests", l.Inflight())
+	}
+
+	h.GET("/healthz").Expect().Status(http.StatusOK)
+	// low priority requests are admitted within half the limit
+	h.GET("/reports/daily").Expect().Status(http.StatusServiceUnavailable)
+}
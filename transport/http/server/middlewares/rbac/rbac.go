@@ -0,0 +1,136 @@
+package rbac
+
+import (
+	"net/http"
+
+	"github.com/yates-z/easel/auth/authentication/session"
+	"github.com/yates-z/easel/auth/authorization/rbac"
+	"github.com/yates-z/easel/transport"
+	"github.com/yates-z/easel/transport/http/server"
+)
+
+// RolesFunc returns the roles of the subject of a request.
+type RolesFunc func(ctx *server.Context) []string
+
+// ResourceFunc maps a request to the resource and action checked by the enforcer.
+type ResourceFunc func(ctx *server.Context) (resource, action string)
+
+// DomainFunc returns the domain, e.g. the tenant, of a request.
+type DomainFunc func(ctx *server.Context) string
+
+// AttributesFunc returns the attributes the policy conditions of a request are evaluated over.
+type AttributesFunc func(ctx *server.Context) rbac.Attributes
+
+type Option func(*options)
+
+type options struct {
+	roles    RolesFunc
+	resource ResourceFunc
+	domain   DomainFunc
+	attrs    AttributesFunc
+	audit    rbac.AuditFunc
+	auditAll bool
+}
+
+// WithRoles with the function returning the roles of the subject.
+func WithRoles(f RolesFunc) Option {
+	return func(o *options) {
+		o.roles = f
+	}
+}
+
+// WithResource with the function mapping a request to a resource and action.
+func WithResource(f ResourceFunc) Option {
+	return func(o *options) {
+		o.resource = f
+	}
+}
+
+// WithDomain with the function returning the domain the policies are checked in.
+func WithDomain(f DomainFunc) Option {
+	return func(o *options) {
+		o.domain = f
+	}
+}
+
+// WithAttributes with the function returning the attributes of a request.
+func WithAttributes(f AttributesFunc) Option {
+	return func(o *options) {
+		o.attrs = f
+	}
+}
+
+// WithAudit with the function recording denied requests.
+func WithAudit(f rbac.AuditFunc) Option {
+	return func(o *options) {
+		o.audit = f
+	}
+}
+
+// AuditAll records allowed requests too.
+func AuditAll() Option {
+	return func(o *options) {
+		o.auditAll = true
+	}
+}
+
+// Middleware rejects requests the subject's roles are not allowed to perform
+// with 403. The resource is the route pattern and the action is the method,
+// so the middleware should be used on routes or groups, where the pattern is
+// known; otherwise the request path is used.
+func Middleware(e *rbac.Enforcer, opts ...Option) server.Middleware {
+	o := &options{
+		roles:    defaultRoles,
+		resource: defaultResource,
+		audit:    rbac.LogAudit(transport.Logger),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return func(next server.HandlerFunc) server.HandlerFunc {
+		return func(ctx *server.Context) error {
+			resource, action := o.resource(ctx)
+			var domain string
+			if o.domain != nil {
+				domain = o.domain(ctx)
+			}
+			var attrs rbac.Attributes
+			if o.attrs != nil {
+				attrs = o.attrs(ctx)
+			}
+			d := e.AuthorizeAttrs(o.roles(ctx), domain, resource, action, attrs)
+			if !d.Allowed || o.auditAll {
+				o.audit(ctx, d)
+			}
+			if !d.Allowed {
+				return ctx.String(http.StatusForbidden, http.StatusText(http.StatusForbidden))
+			}
+			return next(ctx)
+		}
+	}
+}
+
+// defaultRoles looks up the roles in the context, then in the "roles" key
+// of the context storage, then in the "roles" key of the session.
+func defaultRoles(ctx *server.Context) []string {
+	if roles, ok := rbac.RolesFromContext(ctx); ok {
+		return roles
+	}
+	if v, ok := ctx.Get("roles"); ok {
+		return rbac.RolesOf(v)
+	}
+	if v, ok := ctx.Get("session"); ok {
+		if sess, ok := v.(*session.Session); ok {
+			return rbac.RolesOf(sess.Data["roles"])
+		}
+	}
+	return nil
+}
+
+func defaultResource(ctx *server.Context) (string, string) {
+	resource := ctx.FullPath()
+	if resource == "" {
+		resource = ctx.Request.URL.Path
+	}
+	return resource, ctx.Request.Method
+}
@@ -0,0 +1,73 @@
+package rbac
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/yates-z/easel/auth/authentication/session"
+	"github.com/yates-z/easel/auth/authorization/rbac"
+	"github.com/yates-z/easel/transport/http/server"
+	sessionmw "github.com/yates-z/easel/transport/http/server/middlewares/session"
+	"github.com/yates-z/easel/transport/http/server/servertest"
+)
+
+func newEnforcer(t *testing.T) *rbac.Enforcer {
+	path := filepath.Join(t.TempDir(), "policy.csv")
+	if err := os.WriteFile(path, []byte("admin,/users/{id},DELETE\nuser,/users/{id},GET\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	e, err := rbac.NewEnforcer(rbac.NewCSVAdapter(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func TestMiddleware(t *testing.T) {
+	var denied []rbac.Decision
+	audit := func(_ context.Context, d rbac.Decision) {
+		denied = append(denied, d)
+	}
+	sm := session.NewSessionManager(session.NewCacheSessionBackend(1, 10, time.Minute))
+	s := server.NewServer(server.Middlewares(sessionmw.Middleware(sm)))
+	users := s.Group("/users", Middleware(newEnforcer(t), WithAudit(audit)))
+	ok := func(c *server.Context) error {
+		return c.String(http.StatusOK, "ok")
+	}
+	users.GET("/{id}", ok)
+	users.DELETE("/{id}", ok)
+
+	h := servertest.New(t, s)
+	h.Session(sm, map[string]any{"roles": []string{"user"}})
+	h.GET("/users/1").Expect().Status(http.StatusOK)
+	h.DELETE("/users/1").Expect().Status(http.StatusForbidden)
+
+	if len(denied) != 1 {
+		t.Fatalf("expected 1 audited denial, got %d", len(denied))
+	}
+	if d := denied[0]; d.Resource != "/users/{id}" || d.Action != http.MethodDelete || d.Reason == "" {
+		t.Fatalf("unexpected decision %+v", d)
+	}
+
+	h.Session(sm, map[string]any{"roles": []any{"user", "admin"}})
+	h.DELETE("/users/1").Expect().Status(http.StatusOK)
+}
+
+func TestMiddlewareRolesFromStorage(t *testing.T) {
+	s := server.NewServer(server.Middlewares(func(next server.HandlerFunc) server.HandlerFunc {
+		return func(ctx *server.Context) error {
+			ctx.Set("roles", []string{"admin"})
+			return next(ctx)
+		}
+	}))
+	s.DELETE("/users/{id}", func(c *server.Context) error {
+		return c.String(http.StatusOK, "ok")
+	}, Middleware(newEnforcer(t), WithAudit(func(context.Context, rbac.Decision) {})))
+
+	h := servertest.New(t, s)
+	h.DELETE("/users/1").Expect().Status(http.StatusOK)
+}
@@ -0,0 +1,62 @@
+package session
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/yates-z/easel/auth/authentication/session"
+	"github.com/yates-z/easel/logger"
+	"github.com/yates-z/easel/transport/http/server"
+)
+
+// CookieMiddleware keeps the sessions in encrypted cookies sealed by store,
+// and puts them into the context like Middleware. Handlers change the data
+// of the session in the context, the cookie is sent again when they do, or
+// when it was sealed under an older key.
+func CookieMiddleware(store *session.CookieStore, opts ...Option) server.Middleware {
+	o := newOptions(opts...)
+
+	return func(next server.HandlerFunc) server.HandlerFunc {
+		return func(ctx *server.Context) error {
+			if o.skipped(ctx) {
+				return next(ctx)
+			}
+
+			var _session *session.Session
+			value := o.getCookie(ctx)
+			if value != "" {
+				_session, _ = store.Decode(value)
+			}
+			if _session == nil {
+				if !o.isAnonymous(ctx) {
+					return o.unauthorized(ctx)
+				}
+				_session, value = store.New(), ""
+			}
+
+			before := deepCopy(_session.Data)
+			w := &hookWriter{ResponseWriter: ctx.Response.ResponseWriter}
+			w.hook = func() {
+				switch {
+				case !reflect.DeepEqual(before, _session.Data):
+				case value != "" && store.Stale(value):
+				case value != "" && o.sliding && time.Until(_session.ExpiresAt) < store.TTL()/2:
+				default:
+					return
+				}
+				encoded, err := store.Encode(_session)
+				if err != nil {
+					logger.Context(ctx).Errorf("[session] encode session: %v", err)
+					return
+				}
+				o.setCookie(ctx, encoded)
+			}
+			ctx.Response.ResponseWriter = w
+			defer w.runHook()
+
+			// Inject session into context
+			ctx.Set(contextKey, _session)
+			return next(ctx)
+		}
+	}
+}
@@ -2,38 +2,403 @@ package session
 
 import (
 	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
 
 	"github.com/yates-z/easel/auth/authentication/session"
+	"github.com/yates-z/easel/logger"
 	"github.com/yates-z/easel/transport/http/server"
+	"github.com/yates-z/easel/transport/internal/match"
 )
 
+// CookieName is the default name of the session cookie.
 const CookieName = "session_id"
+
+// RedirectUrl is the default URL of the login page.
 const RedirectUrl = "/login"
 
-func Middleware(sm *session.SessionManager) server.Middleware {
+// contextKey is the key of the session in the context storage.
+const contextKey = "session"
+
+type Option func(*options)
+
+type options struct {
+	cookie    http.Cookie
+	loginURL  string
+	loginPath string
+	skip      []string
+	skipper   func(ctx *server.Context) bool
+	anonymous []string
+	sliding   bool
+	interval  time.Duration
+	keys      []string
+}
+
+// WithCookieName with the name of the session cookie, CookieName by default.
+func WithCookieName(name string) Option {
+	return func(o *options) {
+		o.cookie.Name = name
+	}
+}
+
+// WithCookiePath with the path of the session cookie, "/" by default.
+func WithCookiePath(path string) Option {
+	return func(o *options) {
+		o.cookie.Path = path
+	}
+}
+
+// WithCookieDomain with the domain of the session cookie.
+func WithCookieDomain(domain string) Option {
+	return func(o *options) {
+		o.cookie.Domain = domain
+	}
+}
+
+// WithSameSite with the SameSite attribute of the session cookie,
+// http.SameSiteLaxMode by default.
+func WithSameSite(sameSite http.SameSite) Option {
+	return func(o *options) {
+		o.cookie.SameSite = sameSite
+	}
+}
+
+// WithSecure sends the session cookie over HTTPS only.
+func WithSecure() Option {
+	return func(o *options) {
+		o.cookie.Secure = true
+	}
+}
+
+// WithLoginURL with the URL of the login page, RedirectUrl by default.
+// Requests to it are served without session, which is created when the
+// handler stores data in it.
+func WithLoginURL(url string) Option {
+	return func(o *options) {
+		o.loginURL = url
+	}
+}
+
+// SkipPaths with the paths the middleware doesn't handle, e.g. "/static/*".
+// A pattern ending with "*" matches the paths with its prefix.
+func SkipPaths(patterns ...string) Option {
+	return func(o *options) {
+		o.skip = append(o.skip, patterns...)
+	}
+}
+
+// WithSkipper with a function reporting whether the middleware doesn't
+// handle a request.
+func WithSkipper(f func(ctx *server.Context) bool) Option {
+	return func(o *options) {
+		o.skipper = f
+	}
+}
+
+// Anonymous with the paths served without session like the login page,
+// e.g. "/signup". A pattern ending with "*" matches the paths with its prefix.
+func Anonymous(patterns ...string) Option {
+	return func(o *options) {
+		o.anonymous = append(o.anonymous, patterns...)
+	}
+}
+
+// SlidingExpiration renews sessions used after half of their lifetime,
+// so that they only expire when they are not used.
+func SlidingExpiration() Option {
+	return func(o *options) {
+		o.sliding = true
+	}
+}
+
+// RotateEvery rotates the session ID of requests whose ID is older than interval.
+func RotateEvery(interval time.Duration) Option {
+	return func(o *options) {
+		o.interval = interval
+	}
+}
+
+// RotateOnChange rotates the session ID when a request changes any of the
+// session keys holding privileges, e.g. "user_id" or "roles", as a login does.
+// The new ID is sent before the response headers are written.
+func RotateOnChange(keys ...string) Option {
+	return func(o *options) {
+		o.keys = append(o.keys, keys...)
+	}
+}
+
+func newOptions(opts ...Option) *options {
+	o := &options{
+		cookie: http.Cookie{
+			Name:     CookieName,
+			Path:     "/",
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		},
+		loginURL: RedirectUrl,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	// the login page is anonymous whatever its query
+	o.loginPath = o.loginURL
+	if u, err := url.Parse(o.loginURL); err == nil {
+		o.loginPath = u.Path
+	}
+	return o
+}
+
+func (o *options) skipped(ctx *server.Context) bool {
+	return match.Any(o.skip, ctx.Request.URL.Path) || (o.skipper != nil && o.skipper(ctx))
+}
+
+func (o *options) isAnonymous(ctx *server.Context) bool {
+	return ctx.Request.URL.Path == o.loginPath || match.Any(o.anonymous, ctx.Request.URL.Path)
+}
+
+// getCookie returns the value of the session cookie of the request.
+func (o *options) getCookie(ctx *server.Context) string {
+	value, err := ctx.GetCookie(o.cookie.Name)
+	if err != nil {
+		return ""
+	}
+	return value
+}
+
+func (o *options) setCookie(ctx *server.Context, value string) {
+	cookie := o.cookie
+	cookie.Value = value
+	http.SetCookie(ctx.Response, &cookie)
+}
+
+// unauthorized redirects browsers to the login page, with the requested URL
+// as "next" query parameter, and responds 401 to other clients.
+func (o *options) unauthorized(ctx *server.Context) error {
+	if !acceptsHTML(ctx.Request) {
+		return ctx.String(http.StatusUnauthorized, "Unauthorized")
+	}
+	target, err := url.Parse(o.loginURL)
+	if err != nil {
+		return err
+	}
+	query := target.Query()
+	query.Set("next", ctx.Request.URL.RequestURI())
+	target.RawQuery = query.Encode()
+	http.Redirect(ctx.Response, ctx.Request, target.String(), http.StatusFound)
+	return nil
+}
+
+// acceptsHTML reports whether the request accepts an HTML response, as the
+// page navigations of browsers do.
+func acceptsHTML(r *http.Request) bool {
+	for _, accept := range r.Header.Values("Accept") {
+		for _, mediaRange := range strings.Split(accept, ",") {
+			mediaType, params, _ := strings.Cut(mediaRange, ";")
+			mediaType = strings.TrimSpace(mediaType)
+			if mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+				continue
+			}
+			if !rejected(params) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// rejected reports whether the parameters of a media range have a zero quality.
+func rejected(params string) bool {
+	for _, param := range strings.Split(params, ";") {
+		name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+		if strings.EqualFold(name, "q") {
+			return strings.Trim(value, "0.") == ""
+		}
+	}
+	return false
+}
+
+// FromContext returns the session put into ctx by the middleware.
+func FromContext(ctx *server.Context) (*session.Session, bool) {
+	v, ok := ctx.Get(contextKey)
+	if !ok {
+		return nil, false
+	}
+	sess, ok := v.(*session.Session)
+	return sess, ok
+}
+
+// Middleware loads the session of the request from sm and puts it into the
+// context, see FromContext. Requests without session are redirected to the
+// login page or rejected with 401, see WithLoginURL and Anonymous.
+//
+// Handlers may change the session data in place, nested maps and slices
+// included: the session is saved, and created if the request had none, before
+// the response headers are written. The values behind pointers are compared
+// by address, replace them to save their changes.
+func Middleware(sm *session.SessionManager, opts ...Option) server.Middleware {
+	o := newOptions(opts...)
 
 	return func(next server.HandlerFunc) server.HandlerFunc {
 		return func(ctx *server.Context) error {
-			if ctx.Request.RequestURI == RedirectUrl {
+			if o.skipped(ctx) {
 				return next(ctx)
 			}
-			// Get session_id from cookies
-			sessionID, err := ctx.GetCookie(CookieName)
-			if err != nil || sessionID == "" {
-				ctx.String(http.StatusUnauthorized, "Unauthorized")
-				return nil
+
+			// Load the session of the cookie
+			sessionID := o.getCookie(ctx)
+			var _session *session.Session
+			if sessionID != "" {
+				_session, _ = sm.GetSession(sessionID)
+			}
+			loaded := _session != nil
+			if !loaded {
+				if !o.isAnonymous(ctx) {
+					return o.unauthorized(ctx)
+				}
+				// created when the handler stores data in it
+				_session = &session.Session{Data: make(map[string]interface{})}
+			}
+
+			if loaded {
+				// Renew the session after half of its lifetime
+				if o.sliding && time.Until(_session.ExpiresAt) < sm.TTL()/2 {
+					if renewed, err := sm.GetAndRenewSession(_session.ID); err == nil {
+						_session = renewed
+					}
+				}
+				// Rotate the session ID once it is too old
+				if o.interval > 0 && time.Since(_session.RotatedAt) >= o.interval {
+					if id, err := sm.RotateSession(_session.ID); err == nil {
+						if rotated, err := sm.GetSession(id); err == nil {
+							_session = rotated
+						}
+					}
+				}
+				// The cookie holds a rotated ID, or the session was just rotated
+				if _session.ID != sessionID {
+					o.setCookie(ctx, _session.ID)
+				}
 			}
 
-			// Load the session
-			_session, err := sm.GetSession(sessionID)
-			if err != nil {
-				ctx.String(http.StatusUnauthorized, "Unauthorized")
-				return nil
+			before := deepCopy(_session.Data)
+			w := &hookWriter{ResponseWriter: ctx.Response.ResponseWriter}
+			w.hook = func() {
+				if !reflect.DeepEqual(before, _session.Data) {
+					if _session.ID == "" {
+						id, err := sm.CreateSession()
+						if err != nil {
+							logger.Context(ctx).Errorf("[session] create session: %v", err)
+							return
+						}
+						// keeps the issue time of the created session
+						created, err := sm.GetSession(id)
+						if err != nil {
+							logger.Context(ctx).Errorf("[session] get session: %v", err)
+							return
+						}
+						_session.ID, _session.RotatedAt = created.ID, created.RotatedAt
+						o.setCookie(ctx, id)
+					}
+					if err := sm.SaveSession(_session); err != nil {
+						logger.Context(ctx).Errorf("[session] save session: %v", err)
+						return
+					}
+				}
+				// a session created by this request has no privilege to fix
+				if !loaded || len(o.keys) == 0 {
+					return
+				}
+				current, err := sm.GetSession(_session.ID)
+				if err != nil || reflect.DeepEqual(privileges(before, o.keys), privileges(current.Data, o.keys)) {
+					return
+				}
+				if id, err := sm.RotateSession(current.ID); err == nil {
+					o.setCookie(ctx, id)
+				}
 			}
+			ctx.Response.ResponseWriter = w
+			defer w.runHook()
 
 			// Inject session into context
-			ctx.Set("session", _session)
+			ctx.Set(contextKey, _session)
 			return next(ctx)
 		}
 	}
 }
+
+// deepCopy copies v with its maps and slices, so that the changes made in
+// place to the session data are detected.
+func deepCopy[T any](v T) T {
+	c, _ := copyValue(reflect.ValueOf(v)).Interface().(T)
+	return c
+}
+
+func copyValue(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeMapWithSize(v.Type(), v.Len())
+		for iter := v.MapRange(); iter.Next(); {
+			c.SetMapIndex(iter.Key(), copyValue(iter.Value()))
+		}
+		return c
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := range v.Len() {
+			c.Index(i).Set(copyValue(v.Index(i)))
+		}
+		return c
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type()).Elem()
+		c.Set(copyValue(v.Elem()))
+		return c
+	}
+	return v
+}
+
+// privileges returns the values of keys in the session data.
+func privileges(data map[string]interface{}, keys []string) []any {
+	values := make([]any, len(keys))
+	for i, key := range keys {
+		values[i] = data[key]
+	}
+	return values
+}
+
+// hookWriter runs a hook once, before the response headers are written.
+type hookWriter struct {
+	http.ResponseWriter
+	hook func()
+	done bool
+}
+
+func (w *hookWriter) runHook() {
+	if !w.done {
+		w.done = true
+		w.hook()
+	}
+}
+
+func (w *hookWriter) WriteHeader(code int) {
+	w.runHook()
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *hookWriter) Write(b []byte) (int, error) {
+	w.runHook()
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *hookWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
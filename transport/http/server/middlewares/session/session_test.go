@@ -0,0 +1,265 @@
+package session_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/yates-z/easel/auth/authentication/session"
+	"github.com/yates-z/easel/transport/http/server"
+	sessionmw "github.com/yates-z/easel/transport/http/server/middlewares/session"
+	"github.com/yates-z/easel/transport/http/server/servertest"
+	"github.com/yates-z/easel/utils/crypto/aes"
+)
+
+func newSessionManager() *session.SessionManager {
+	return session.NewSessionManager(session.NewCacheSessionBackend(1, 10, time.Minute), session.WithRotationGrace(time.Minute))
+}
+
+func TestRotateOnChange(t *testing.T) {
+	sm := newSessionManager()
+	s := server.NewServer(server.Middlewares(sessionmw.Middleware(sm, sessionmw.RotateOnChange("user"))))
+	s.POST("/signin", func(c *server.Context) error {
+		if err := sm.UpdateSession(c.MustGet("session").(*session.Session).ID, "user", "easel"); err != nil {
+			return err
+		}
+		return c.String(http.StatusOK, "ok")
+	})
+	s.GET("/profile", func(c *server.Context) error {
+		return c.String(http.StatusOK, "ok")
+	})
+
+	h := servertest.New(t, s)
+	id := h.Session(sm, nil)
+	h.GET("/profile").Expect().Status(http.StatusOK)
+	if h.Cookie(sessionmw.CookieName).Value != id {
+		t.Fatal("session rotated without a privilege change")
+	}
+	h.POST("/signin").Expect().Status(http.StatusOK)
+	rotated := h.Cookie(sessionmw.CookieName).Value
+	if rotated == id {
+		t.Fatal("session not rotated on a privilege change")
+	}
+	sess, err := sm.GetSession(rotated)
+	if err != nil || sess.Data["user"] != "easel" {
+		t.Fatalf("unexpected rotated session %+v, error %v", sess, err)
+	}
+}
+
+func TestRotateEvery(t *testing.T) {
+	sm := newSessionManager()
+	s := server.NewServer(server.Middlewares(sessionmw.Middleware(sm, sessionmw.RotateEvery(time.Millisecond))))
+	s.GET("/profile", func(c *server.Context) error {
+		return c.String(http.StatusOK, c.MustGet("session").(*session.Session).ID)
+	})
+
+	h := servertest.New(t, s)
+	id := h.Session(sm, map[string]any{"user": "easel"})
+	time.Sleep(2 * time.Millisecond)
+	h.GET("/profile").Expect().Status(http.StatusOK)
+	rotated := h.Cookie(sessionmw.CookieName).Value
+	if rotated == id {
+		t.Fatal("session not rotated after the interval")
+	}
+
+	// a request in flight with the old ID is redirected to the new session.
+	h.GET("/profile").WithCookie(sessionmw.CookieName, id).Expect().Status(http.StatusOK)
+	if h.Cookie(sessionmw.CookieName).Value == id {
+		t.Fatal("rotated session ID sent back")
+	}
+}
+
+func TestCookieMiddleware(t *testing.T) {
+	oldKey, newKey := []byte("0123456789abcdef"), []byte("fedcba9876543210")
+	keyring, _ := aes.NewKeyring(oldKey)
+	store := session.NewCookieStore(keyring, time.Minute)
+	newServer := func(store *session.CookieStore) *server.Server {
+		s := server.NewServer(server.Middlewares(sessionmw.CookieMiddleware(store)))
+		s.POST(sessionmw.RedirectUrl, func(c *server.Context) error {
+			c.MustGet("session").(*session.Session).Data["user"] = "easel"
+			return c.String(http.StatusOK, "ok")
+		})
+		s.GET("/profile", func(c *server.Context) error {
+			return c.String(http.StatusOK, c.MustGet("session").(*session.Session).Data["user"].(string))
+		})
+		return s
+	}
+
+	h := servertest.New(t, newServer(store))
+	h.GET("/profile").Expect().Status(http.StatusUnauthorized)
+	h.POST(sessionmw.RedirectUrl).Expect().Status(http.StatusOK)
+	value := h.Cookie(sessionmw.CookieName).Value
+	h.GET("/profile").Expect().Status(http.StatusOK).BodyEqual("easel")
+	if h.Cookie(sessionmw.CookieName).Value != value {
+		t.Fatal("unchanged session sent again")
+	}
+
+	// the cookie is sealed again under the new key.
+	keyring, _ = aes.NewKeyring(newKey, oldKey)
+	h = servertest.New(t, newServer(session.NewCookieStore(keyring, time.Minute)))
+	h.SetCookie(&http.Cookie{Name: sessionmw.CookieName, Value: value})
+	h.GET("/profile").Expect().Status(http.StatusOK).BodyEqual("easel")
+	if h.Cookie(sessionmw.CookieName).Value == value {
+		t.Fatal("stale cookie not sealed again")
+	}
+	h.GET("/profile").WithCookie(sessionmw.CookieName, "forged").Expect().Status(http.StatusUnauthorized)
+}
+
+func TestUnauthenticated(t *testing.T) {
+	sm := newSessionManager()
+	s := server.NewServer(server.Middlewares(sessionmw.Middleware(sm, sessionmw.WithLoginURL("/signin?lang=en"), sessionmw.SkipPaths("/static/*"))))
+	ok := func(c *server.Context) error {
+		return c.String(http.StatusOK, "ok")
+	}
+	s.GET("/profile", ok)
+	s.GET("/static/app.js", ok)
+
+	h := servertest.New(t, s)
+	h.GET("/profile").WithHeader("Accept", "application/json").Expect().Status(http.StatusUnauthorized)
+	h.GET("/profile").WithHeader("Accept", "text/html;q=0, */*").Expect().Status(http.StatusUnauthorized)
+	h.GET("/profile?tab=1").WithHeader("Accept", "text/html,application/xhtml+xml,*/*;q=0.8").Expect().
+		Status(http.StatusFound).
+		Header("Location", "/signin?lang=en&next=%2Fprofile%3Ftab%3D1")
+	h.GET("/static/app.js").Expect().Status(http.StatusOK)
+}
+
+func TestLazySession(t *testing.T) {
+	sm := newSessionManager()
+	s := server.NewServer(server.Middlewares(sessionmw.Middleware(sm,
+		sessionmw.WithCookieName("sid"),
+		sessionmw.WithCookiePath("/app"),
+		sessionmw.WithCookieDomain("example.com"),
+		sessionmw.WithSameSite(http.SameSiteStrictMode),
+		sessionmw.WithSecure(),
+	)))
+	s.GET(sessionmw.RedirectUrl, func(c *server.Context) error {
+		return c.String(http.StatusOK, "login")
+	})
+	s.POST(sessionmw.RedirectUrl, func(c *server.Context) error {
+		sess, _ := sessionmw.FromContext(c)
+		sess.Data["user"] = "easel"
+		return c.String(http.StatusOK, "ok")
+	})
+	s.GET("/profile", func(c *server.Context) error {
+		sess, _ := sessionmw.FromContext(c)
+		return c.String(http.StatusOK, sess.Data["user"].(string))
+	})
+
+	h := servertest.New(t, s)
+	resp := h.GET(sessionmw.RedirectUrl).Expect().Status(http.StatusOK)
+	if len(resp.Raw().Cookies()) != 0 {
+		t.Fatal("session created without data")
+	}
+	resp = h.POST(sessionmw.RedirectUrl).Expect().Status(http.StatusOK)
+	cookies := resp.Raw().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("unexpected cookies %v", cookies)
+	}
+	c := cookies[0]
+	if c.Name != "sid" || c.Path != "/app" || c.Domain != "example.com" || c.SameSite != http.SameSiteStrictMode || !c.Secure || !c.HttpOnly {
+		t.Fatalf("unexpected cookie %v", c)
+	}
+	sess, err := sm.GetSession(c.Value)
+	if err != nil || sess.Data["user"] != "easel" {
+		t.Fatalf("unexpected session %+v, error %v", sess, err)
+	}
+	h.GET("/profile").WithCookie("sid", c.Value).Expect().Status(http.StatusOK).BodyEqual("easel")
+}
+
+func TestSlidingExpiration(t *testing.T) {
+	sm := session.NewSessionManager(session.NewCacheSessionBackend(1, 10, time.Minute), session.WithTTL(100*time.Millisecond))
+	s := server.NewServer(server.Middlewares(sessionmw.Middleware(sm, sessionmw.SlidingExpiration())))
+	s.GET("/profile", func(c *server.Context) error {
+		return c.String(http.StatusOK, "ok")
+	})
+
+	h := servertest.New(t, s)
+	id := h.Session(sm, map[string]any{"user": "easel"})
+	for range 4 {
+		time.Sleep(60 * time.Millisecond)
+		h.GET("/profile").Expect().Status(http.StatusOK)
+	}
+	if _, err := sm.GetSession(id); err != nil {
+		t.Fatalf("used session expired: %v", err)
+	}
+}
+
+func TestLoginURLQuery(t *testing.T) {
+	sm := newSessionManager()
+	s := server.NewServer(server.Middlewares(sessionmw.Middleware(sm, sessionmw.WithLoginURL("/signin?lang=en"))))
+	s.GET("/signin", func(c *server.Context) error {
+		return c.String(http.StatusOK, "login")
+	})
+
+	h := servertest.New(t, s)
+	h.GET("/signin?lang=en").WithHeader("Accept", "text/html").Expect().Status(http.StatusOK).BodyEqual("login")
+}
+
+func TestLazySessionRotation(t *testing.T) {
+	sm := newSessionManager()
+	s := server.NewServer(server.Middlewares(sessionmw.Middleware(sm, sessionmw.RotateEvery(time.Hour))))
+	s.POST(sessionmw.RedirectUrl, func(c *server.Context) error {
+		sess, _ := sessionmw.FromContext(c)
+		sess.Data["user"] = "easel"
+		return c.String(http.StatusOK, "ok")
+	})
+	s.GET("/profile", func(c *server.Context) error {
+		return c.String(http.StatusOK, "ok")
+	})
+
+	h := servertest.New(t, s)
+	h.POST(sessionmw.RedirectUrl).Expect().Status(http.StatusOK)
+	id := h.Cookie(sessionmw.CookieName).Value
+	h.GET("/profile").Expect().Status(http.StatusOK)
+	if h.Cookie(sessionmw.CookieName).Value != id {
+		t.Fatal("session created by the request rotated on the next one")
+	}
+}
+
+func TestNestedChanges(t *testing.T) {
+	sm := newSessionManager()
+	s := server.NewServer(server.Middlewares(sessionmw.Middleware(sm)))
+	s.POST("/cart", func(c *server.Context) error {
+		sess, _ := sessionmw.FromContext(c)
+		cart := sess.Data["cart"].(map[string]any)
+		cart["items"] = append(cart["items"].([]any), "book")
+		return c.String(http.StatusOK, "ok")
+	})
+
+	h := servertest.New(t, s)
+	id := h.Session(sm, map[string]any{"cart": map[string]any{"items": []any{}}})
+	h.POST("/cart").Expect().Status(http.StatusOK)
+	sess, err := sm.GetSession(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if items := sess.Data["cart"].(map[string]any)["items"].([]any); len(items) != 1 {
+		t.Fatalf("nested change not saved: %v", items)
+	}
+}
+
+func TestCookieNestedChanges(t *testing.T) {
+	keyring, _ := aes.NewKeyring([]byte("0123456789abcdef"))
+	s := server.NewServer(server.Middlewares(sessionmw.CookieMiddleware(session.NewCookieStore(keyring, time.Minute))))
+	s.POST(sessionmw.RedirectUrl, func(c *server.Context) error {
+		sess, _ := sessionmw.FromContext(c)
+		sess.Data["cart"] = map[string]any{"items": []any{}}
+		return c.String(http.StatusOK, "ok")
+	})
+	s.POST("/cart", func(c *server.Context) error {
+		sess, _ := sessionmw.FromContext(c)
+		cart := sess.Data["cart"].(map[string]any)
+		cart["items"] = append(cart["items"].([]any), "book")
+		return c.String(http.StatusOK, "ok")
+	})
+	s.GET("/cart", func(c *server.Context) error {
+		sess, _ := sessionmw.FromContext(c)
+		return c.String(http.StatusOK, fmt.Sprint(len(sess.Data["cart"].(map[string]any)["items"].([]any))))
+	})
+
+	h := servertest.New(t, s)
+	h.POST(sessionmw.RedirectUrl).Expect().Status(http.StatusOK)
+	h.POST("/cart").Expect().Status(http.StatusOK)
+	h.GET("/cart").Expect().Status(http.StatusOK).BodyEqual("1")
+}
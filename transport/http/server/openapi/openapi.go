@@ -0,0 +1,355 @@
+package openapi
+
+import (
+	"cmp"
+	"io/fs"
+	"net/http"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/yates-z/easel/transport/http/server"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+type Option func(*options)
+
+type options struct {
+	info      Info
+	servers   []Server
+	path      string
+	uiPath    string
+	uiAssets  string
+	uiFS      fs.FS
+	filter    func(*server.Route) bool
+	errSchema *Schema
+}
+
+// Title with document title.
+func Title(title string) Option {
+	return func(o *options) {
+		o.info.Title = title
+	}
+}
+
+// Description with document description.
+func Description(description string) Option {
+	return func(o *options) {
+		o.info.Description = description
+	}
+}
+
+// APIVersion with the version of the API, not of the specification.
+func APIVersion(version string) Option {
+	return func(o *options) {
+		o.info.Version = version
+	}
+}
+
+// Servers with the urls the API is served from.
+func Servers(urls ...string) Option {
+	return func(o *options) {
+		for _, u := range urls {
+			o.servers = append(o.servers, Server{URL: u})
+		}
+	}
+}
+
+// Path with the path the document is served at, "/openapi.json" by default.
+func Path(path string) Option {
+	return func(o *options) {
+		o.path = path
+	}
+}
+
+// SwaggerUI serves the Swagger UI at path, e.g. "/swagger". Only its page is
+// embedded: the swagger-ui-dist assets are loaded from unpkg.com by default,
+// which requires network access and a Content-Security-Policy allowing it.
+// See SwaggerUIAssets and SwaggerUIFS to serve them from elsewhere.
+func SwaggerUI(path string) Option {
+	return func(o *options) {
+		o.uiPath = path
+	}
+}
+
+// SwaggerUIAssets with the base url of the swagger-ui-dist assets,
+// "https://unpkg.com/swagger-ui-dist@5" by default.
+func SwaggerUIAssets(url string) Option {
+	return func(o *options) {
+		o.uiAssets = url
+	}
+}
+
+// SwaggerUIFS serves the swagger-ui-dist assets from fsys, at the path of the
+// Swagger UI followed by "/assets", e.g. to embed them in the binary. fsys
+// holds swagger-ui.css and swagger-ui-bundle.js at its root.
+func SwaggerUIFS(fsys fs.FS) Option {
+	return func(o *options) {
+		o.uiFS = fsys
+	}
+}
+
+// Filter with a function that reports whether a route is documented.
+func Filter(f func(*server.Route) bool) Option {
+	return func(o *options) {
+		o.filter = f
+	}
+}
+
+func newOptions(opts ...Option) *options {
+	o := &options{
+		info:     Info{Title: "API", Version: "0.0.1"},
+		path:     "/openapi.json",
+		uiAssets: "https://unpkg.com/swagger-ui-dist@5",
+		// errSchema is the body written by the default server error handler.
+		errSchema: &Schema{
+			Type: "object",
+			Properties: map[string]*Schema{
+				"code":    {Type: "integer", Format: "int32"},
+				"message": {Type: "string"},
+			},
+		},
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// Generate builds an OpenAPI document from routes.
+func Generate(routes []*server.Route, opts ...Option) *Document {
+	return generate(routes, newOptions(opts...))
+}
+
+func generate(routes []*server.Route, o *options) *Document {
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    o.info,
+		Servers: o.servers,
+		Paths:   make(map[string]*PathItem),
+	}
+	s := newSchemas()
+	s.components["Error"] = o.errSchema
+
+	// the routes of any method are documented for the methods without a
+	// route of their own
+	routes = slices.Clone(routes)
+	slices.SortStableFunc(routes, func(a, b *server.Route) int {
+		return cmp.Compare(boolInt(a.Method() == ""), boolInt(b.Method() == ""))
+	})
+	var tags []string
+	for _, route := range routes {
+		if o.filter != nil && !o.filter(route) {
+			continue
+		}
+		path, params := documentPath(route.Path())
+		item, ok := doc.Paths[path]
+		if !ok {
+			item = &PathItem{}
+		}
+		methods := []string{route.Method()}
+		if route.Method() == "" {
+			methods = documentedMethods
+		}
+		documented := false
+		for _, method := range methods {
+			slot := item.operation(method)
+			if slot == nil || (route.Method() == "" && *slot != nil) {
+				continue
+			}
+			*slot = operation(s, route, method, path, params)
+			documented = true
+		}
+		if !documented {
+			continue
+		}
+		doc.Paths[path] = item
+		for _, tag := range route.Meta().Tags {
+			if !slices.Contains(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	for _, tag := range tags {
+		doc.Tags = append(doc.Tags, Tag{Name: tag})
+	}
+	doc.Components = &Components{Schemas: s.components}
+	return doc
+}
+
+// documentedMethods are the methods of the routes of any method.
+var documentedMethods = []string{
+	http.MethodGet, http.MethodPut, http.MethodPost, http.MethodDelete,
+	http.MethodOptions, http.MethodHead, http.MethodPatch, http.MethodTrace,
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func (p *PathItem) operation(method string) **Operation {
+	switch method {
+	case http.MethodGet:
+		return &p.Get
+	case http.MethodPut:
+		return &p.Put
+	case http.MethodPost:
+		return &p.Post
+	case http.MethodDelete:
+		return &p.Delete
+	case http.MethodOptions:
+		return &p.Options
+	case http.MethodHead:
+		return &p.Head
+	case http.MethodPatch:
+		return &p.Patch
+	case http.MethodTrace:
+		return &p.Trace
+	default:
+		return nil
+	}
+}
+
+func operation(s *schemas, route *server.Route, method, path string, params []string) *Operation {
+	meta := route.Meta()
+	op := &Operation{
+		Tags:        meta.Tags,
+		Summary:     meta.Summary,
+		Description: meta.Description,
+		OperationID: operationID(method, path),
+		Deprecated:  meta.Deprecated,
+		Responses: map[string]*Response{
+			"default": {
+				Description: "Error",
+				Content:     map[string]*MediaType{"application/json": {Schema: ref("Error")}},
+			},
+		},
+	}
+
+	var request *Schema
+	if meta.Request != nil {
+		request = s.typeSchema(meta.Request)
+	}
+	fields := s.resolve(request)
+	for _, name := range params {
+		param := &Parameter{Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"}}
+		if field := lookupField(fields, meta.Request, name); field != nil {
+			param.Schema = field
+		}
+		op.Parameters = append(op.Parameters, param)
+	}
+
+	// GET, DELETE and HEAD requests have no body, see server.Context.HasBody,
+	// the other fields of the request are bound from the query.
+	if request != nil {
+		if hasBody(method) {
+			op.RequestBody = &RequestBody{
+				Required: true,
+				Content:  map[string]*MediaType{"application/json": {Schema: request}},
+			}
+		} else if fields != nil {
+			for _, name := range sortedKeys(fields.Properties) {
+				field := fields.Properties[name]
+				if slices.Contains(params, name) || !isQueryable(s.resolve(field)) {
+					continue
+				}
+				op.Parameters = append(op.Parameters, &Parameter{Name: name, In: "query", Schema: field})
+			}
+		}
+	}
+
+	reply := &Response{Description: "OK"}
+	if meta.Reply != nil {
+		reply.Content = map[string]*MediaType{"application/json": {Schema: s.typeSchema(meta.Reply)}}
+	}
+	op.Responses["200"] = reply
+	return op
+}
+
+// wildcardPattern matches the wildcards of a http.ServeMux pattern.
+var wildcardPattern = regexp.MustCompile(`{([^{}]*)}`)
+
+// documentPath converts a http.ServeMux pattern into an OpenAPI path template,
+// and returns the names of its path parameters.
+func documentPath(pattern string) (string, []string) {
+	// patterns may start with a host, e.g. "example.com/users".
+	if i := strings.IndexByte(pattern, '/'); i > 0 {
+		pattern = pattern[i:]
+	}
+	var params []string
+	path := wildcardPattern.ReplaceAllStringFunc(pattern, func(s string) string {
+		name := strings.TrimSpace(s[1 : len(s)-1])
+		if name == "$" {
+			return ""
+		}
+		name, _, _ = strings.Cut(name, "=")
+		name = strings.TrimSuffix(name, "...")
+		params = append(params, name)
+		return "{" + name + "}"
+	})
+	return path, params
+}
+
+// lookupField finds the schema of a path parameter in the request.
+func lookupField(fields *Schema, t reflect.Type, name string) *Schema {
+	if fields == nil {
+		return nil
+	}
+	if field, ok := fields.Properties[name]; ok {
+		return field
+	}
+	// proto messages are documented by json name, path parameters may use the proto name.
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t != nil && reflect.PointerTo(t).Implements(protoMessageType) {
+		md := reflect.New(t).Interface().(proto.Message).ProtoReflect().Descriptor()
+		if fd := md.Fields().ByName(protoreflect.Name(name)); fd != nil {
+			return fields.Properties[fd.JSONName()]
+		}
+	}
+	return nil
+}
+
+func isQueryable(schema *Schema) bool {
+	if schema == nil {
+		return false
+	}
+	switch schema.Type {
+	case "object", "":
+		return false
+	case "array":
+		return isQueryable(schema.Items)
+	default:
+		return true
+	}
+}
+
+func hasBody(method string) bool {
+	return !slices.Contains([]string{http.MethodGet, http.MethodDelete, http.MethodHead}, method)
+}
+
+func operationID(method, path string) string {
+	id := strings.ToLower(method)
+	for _, seg := range strings.Split(path, "/") {
+		seg = strings.Trim(seg, "{}")
+		if seg != "" {
+			id += "_" + seg
+		}
+	}
+	return id
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,259 @@
+package openapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"github.com/yates-z/easel/transport/grpc/server/test/api"
+	"github.com/yates-z/easel/transport/http/server"
+	"github.com/yates-z/easel/transport/http/server/adapter"
+)
+
+type User struct {
+	ID       int64    `json:"id"`
+	Name     string   `json:"name"`
+	Email    string   `json:"email,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+	Internal string   `json:"-"`
+}
+
+type GetUserRequest struct {
+	ID    int64 `json:"id"`
+	Extra bool  `json:"extra,omitempty"`
+}
+
+func getUser(_ context.Context, in *GetUserRequest) (*User, error) {
+	return &User{ID: in.ID}, nil
+}
+
+func createUser(_ context.Context, in *User) (*User, error) {
+	return in, nil
+}
+
+func sayHello(_ context.Context, in *api.HelloRequest) (*api.HelloResponse, error) {
+	return &api.HelloResponse{Replay: in.Name}, nil
+}
+
+func newServer() *server.Server {
+	s := server.NewServer()
+	users := s.Group("/v1/users")
+	adapter.Handle(users, http.MethodGet, "/{id}", getUser).Tags("users").Summary("Get a user")
+	adapter.Handle(users, http.MethodPost, "/", createUser).Tags("users").Deprecated()
+	adapter.Handle(s, http.MethodPost, "/hello/{name...}", sayHello)
+	s.GET("/healthz", func(c *server.Context) error {
+		return c.String(http.StatusOK, "ok")
+	}).Summary("Health check")
+	return s
+}
+
+func TestGenerate(t *testing.T) {
+	doc := Generate(newServer().Routes(), Title("users"), APIVersion("v1"))
+	if doc.OpenAPI != Version || doc.Info.Title != "users" || doc.Info.Version != "v1" {
+		t.Fatalf("unexpected document header %+v", doc.Info)
+	}
+
+	get := doc.Paths["/v1/users/{id}"].Get
+	if get == nil || get.Summary != "Get a user" || len(get.Tags) != 1 {
+		t.Fatalf("unexpected get operation %+v", get)
+	}
+	if len(get.Parameters) != 2 {
+		t.Fatalf("expected path and query parameters, got %d", len(get.Parameters))
+	}
+	if p := get.Parameters[0]; p.Name != "id" || p.In != "path" || !p.Required || p.Schema.Type != "integer" {
+		t.Fatalf("unexpected path parameter %+v", p)
+	}
+	if p := get.Parameters[1]; p.Name != "extra" || p.In != "query" || p.Schema.Type != "boolean" {
+		t.Fatalf("unexpected query parameter %+v", p)
+	}
+	if ref := get.Responses["200"].Content["application/json"].Schema.Ref; ref != refPrefix+"openapi.User" {
+		t.Fatalf("unexpected reply schema %q", ref)
+	}
+
+	post := doc.Paths["/v1/users/"].Post
+	if post == nil || !post.Deprecated || post.RequestBody == nil {
+		t.Fatalf("unexpected post operation %+v", post)
+	}
+	user := doc.Components.Schemas["openapi.User"]
+	if _, ok := user.Properties["Internal"]; ok || len(user.Properties) != 4 {
+		t.Fatalf("unexpected user schema %+v", user.Properties)
+	}
+	if strings.Join(user.Required, ",") != "id,name" {
+		t.Fatalf("unexpected required fields %v", user.Required)
+	}
+
+	hello := doc.Paths["/hello/{name}"].Post
+	if hello == nil || hello.Parameters[0].Name != "name" {
+		t.Fatalf("unexpected hello operation %+v", hello)
+	}
+	if _, ok := doc.Components.Schemas["pb.HelloRequest"].Properties["name"]; !ok {
+		t.Fatal("proto message schema is missing field name")
+	}
+
+	health := doc.Paths["/healthz"].Get
+	if health == nil || health.Summary != "Health check" || health.Responses["200"].Content != nil {
+		t.Fatalf("unexpected health operation %+v", health)
+	}
+	if len(doc.Tags) != 1 || doc.Tags[0].Name != "users" {
+		t.Fatalf("unexpected tags %+v", doc.Tags)
+	}
+}
+
+func TestDocumentPath(t *testing.T) {
+	for pattern, want := range map[string]string{
+		"/users/{id}":              "/users/{id}",
+		"/files/{path...}":         "/files/{path}",
+		"/{$}":                     "/",
+		"example.com/users/{id}/x": "/users/{id}/x",
+	} {
+		if got, _ := documentPath(pattern); got != want {
+			t.Fatalf("documentPath(%q) = %q, want %q", pattern, got, want)
+		}
+	}
+}
+
+func TestRegister(t *testing.T) {
+	s := newServer()
+	Register(s, Path("/docs/openapi.json"), SwaggerUI("/docs"))
+	ts := httptest.NewServer(s.Handler)
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/docs/openapi.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var doc Document
+	if err = json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := doc.Paths["/v1/users/{id}"]; !ok {
+		t.Fatal("document is missing registered route")
+	}
+	if _, ok := doc.Paths["/docs/openapi.json"]; ok {
+		t.Fatal("document should not describe itself")
+	}
+
+	resp, err = http.Get(ts.URL + "/docs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	page, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(page), `url: "/docs/openapi.json"`) {
+		t.Fatalf("swagger ui does not load the document:\n%s", page)
+	}
+}
+
+func getDocument(t *testing.T, url string) Document {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var doc Document
+	if err = json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+func TestRegisterLateRoutes(t *testing.T) {
+	s := newServer()
+	Register(s)
+	s.ANY("/echo", func(c *server.Context) error {
+		return c.String(http.StatusOK, c.Request.Method)
+	})
+	s.POST("/echo", func(c *server.Context) error {
+		return c.String(http.StatusCreated, "created")
+	}).Summary("Create an echo")
+	ts := httptest.NewServer(s.Handler)
+	defer ts.Close()
+
+	doc := getDocument(t, ts.URL+"/openapi.json")
+	item, ok := doc.Paths["/echo"]
+	if !ok {
+		t.Fatal("document is missing a route registered after Register")
+	}
+	if item.Get == nil || item.Delete == nil {
+		t.Fatalf("ANY route is not documented for every method: %+v", item)
+	}
+	if item.Post == nil || item.Post.Summary != "Create an echo" {
+		t.Fatalf("ANY route overrides the POST route: %+v", item.Post)
+	}
+
+	resp, err := http.Post(ts.URL+"/echo", "text/plain", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("unexpected status %d", resp.StatusCode)
+	}
+}
+
+func TestRegisterConcurrentRoutes(t *testing.T) {
+	s := newServer()
+	Register(s)
+	ts := httptest.NewServer(s.Handler)
+	defer ts.Close()
+
+	if _, ok := getDocument(t, ts.URL+"/openapi.json").Paths["/late/0"]; ok {
+		t.Fatal("unexpected route")
+	}
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := range 10 {
+			s.GET(fmt.Sprintf("/late/%d", i), func(c *server.Context) error { return nil })
+		}
+	}()
+	for range 10 {
+		getDocument(t, ts.URL+"/openapi.json")
+	}
+	wg.Wait()
+	if _, ok := getDocument(t, ts.URL+"/openapi.json").Paths["/late/9"]; !ok {
+		t.Fatal("the document isn't generated again for the new routes")
+	}
+}
+
+func TestSwaggerUIFS(t *testing.T) {
+	s := newServer()
+	Register(s, SwaggerUI("/docs"), SwaggerUIFS(fstest.MapFS{
+		"swagger-ui.css": &fstest.MapFile{Data: []byte("body {}")},
+	}))
+	ts := httptest.NewServer(s.Handler)
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/docs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	page, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(page), "/docs/assets/swagger-ui.css") {
+		t.Fatalf("swagger ui does not load the embedded assets:\n%s", page)
+	}
+
+	resp, err = http.Get(ts.URL + "/docs/assets/swagger-ui.css")
+	if err != nil {
+		t.Fatal(err)
+	}
+	css, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(css) != "body {}" {
+		t.Fatalf("unexpected asset %q", css)
+	}
+	if _, ok := getDocument(t, ts.URL+"/openapi.json").Paths["/docs/assets/{file}"]; ok {
+		t.Fatal("document should not describe the assets")
+	}
+}
@@ -0,0 +1,78 @@
+package openapi
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/yates-z/easel/transport/http/server"
+)
+
+//go:embed swagger.html
+var swaggerHTML string
+
+var swaggerTemplate = template.Must(template.New("swagger").Parse(swaggerHTML))
+
+// Register serves the OpenAPI document of s, and the Swagger UI if enabled.
+// The document is generated again once routes are registered after it, so
+// that routes registered after Register are documented too.
+func Register(s *server.Server, opts ...Option) {
+	o := newOptions(opts...)
+
+	var (
+		own []*server.Route
+		mu  sync.Mutex
+		// doc is the document of the first n routes of s.
+		doc []byte
+		n   int
+	)
+	own = append(own, s.GET(o.path, func(c *server.Context) error {
+		mu.Lock()
+		defer mu.Unlock()
+		// routes are only appended, so their number tells whether the
+		// document is stale.
+		if all := s.Routes(); doc == nil || len(all) != n {
+			var routes []*server.Route
+			for _, route := range all {
+				if !slices.Contains(own, route) {
+					routes = append(routes, route)
+				}
+			}
+			data, err := json.Marshal(generate(routes, o))
+			if err != nil {
+				return err
+			}
+			doc, n = data, len(all)
+		}
+		return c.Data(http.StatusOK, "application/json", doc)
+	}))
+
+	if o.uiPath == "" {
+		return
+	}
+	assets := o.uiAssets
+	if o.uiFS != nil {
+		assets = strings.TrimSuffix(o.uiPath, "/") + "/assets"
+		files := http.StripPrefix(assets, http.FileServerFS(o.uiFS))
+		own = append(own, s.GET(assets+"/{file}", func(c *server.Context) error {
+			files.ServeHTTP(c.Response, c.Request)
+			return nil
+		}))
+	}
+	var page bytes.Buffer
+	if err := swaggerTemplate.Execute(&page, map[string]string{
+		"Title":  o.info.Title,
+		"Assets": assets,
+		"URL":    o.path,
+	}); err != nil {
+		panic(err)
+	}
+	own = append(own, s.GET(o.uiPath, func(c *server.Context) error {
+		return c.Data(http.StatusOK, "text/html; charset=utf-8", page.Bytes())
+	}))
+}
@@ -0,0 +1,275 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+var (
+	protoMessageType  = reflect.TypeFor[proto.Message]()
+	jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+	timeType          = reflect.TypeFor[time.Time]()
+	durationType      = reflect.TypeFor[time.Duration]()
+)
+
+// wellKnownTypes maps google.protobuf well known types to their JSON schema.
+var wellKnownTypes = map[protoreflect.FullName]func() *Schema{
+	"google.protobuf.Timestamp":   func() *Schema { return &Schema{Type: "string", Format: "date-time"} },
+	"google.protobuf.Duration":    func() *Schema { return &Schema{Type: "string", Format: "duration"} },
+	"google.protobuf.FieldMask":   func() *Schema { return &Schema{Type: "string"} },
+	"google.protobuf.Struct":      func() *Schema { return &Schema{Type: "object"} },
+	"google.protobuf.Value":       func() *Schema { return &Schema{} },
+	"google.protobuf.ListValue":   func() *Schema { return &Schema{Type: "array", Items: &Schema{}} },
+	"google.protobuf.Empty":       func() *Schema { return &Schema{Type: "object"} },
+	"google.protobuf.Any":         func() *Schema { return &Schema{Type: "object"} },
+	"google.protobuf.DoubleValue": func() *Schema { return &Schema{Type: "number", Format: "double"} },
+	"google.protobuf.FloatValue":  func() *Schema { return &Schema{Type: "number", Format: "float"} },
+	"google.protobuf.Int64Value":  func() *Schema { return &Schema{Type: "string", Format: "int64"} },
+	"google.protobuf.UInt64Value": func() *Schema { return &Schema{Type: "string", Format: "uint64"} },
+	"google.protobuf.Int32Value":  func() *Schema { return &Schema{Type: "integer", Format: "int32"} },
+	"google.protobuf.UInt32Value": func() *Schema { return &Schema{Type: "integer", Format: "uint32"} },
+	"google.protobuf.BoolValue":   func() *Schema { return &Schema{Type: "boolean"} },
+	"google.protobuf.StringValue": func() *Schema { return &Schema{Type: "string"} },
+	"google.protobuf.BytesValue":  func() *Schema { return &Schema{Type: "string", Format: "byte"} },
+}
+
+// schemas collects the component schemas referenced by a document.
+type schemas struct {
+	components map[string]*Schema
+	// names keeps the component name of each go type, so that two types
+	// with the same short name don't overwrite each other.
+	names map[reflect.Type]string
+}
+
+func newSchemas() *schemas {
+	return &schemas{
+		components: make(map[string]*Schema),
+		names:      make(map[reflect.Type]string),
+	}
+}
+
+// typeSchema returns the schema of t. Messages and structs are added to the
+// components and referenced.
+func (s *schemas) typeSchema(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if reflect.PointerTo(t).Implements(protoMessageType) {
+		md := reflect.New(t).Interface().(proto.Message).ProtoReflect().Descriptor()
+		return s.messageSchema(md)
+	}
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == durationType:
+		return &Schema{Type: "integer", Format: "int64"}
+	case t.Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(jsonMarshalerType):
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: s.typeSchema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: s.typeSchema(t.Elem())}
+	case reflect.Struct:
+		return s.structSchema(t)
+	default:
+		return &Schema{}
+	}
+}
+
+func (s *schemas) structSchema(t reflect.Type) *Schema {
+	name, ok := s.names[t]
+	if ok {
+		return ref(name)
+	}
+	name = s.uniqueName(componentName(t))
+	s.names[t] = name
+
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	s.components[name] = schema
+	s.structFields(t, schema)
+	return ref(name)
+}
+
+func (s *schemas) structFields(t reflect.Type, schema *Schema) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, omitempty, skip := jsonField(f)
+		if skip {
+			continue
+		}
+		if f.Anonymous && f.Tag.Get("json") == "" {
+			ft := f.Type
+			for ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			// embedded structs are flattened like encoding/json does.
+			if ft.Kind() == reflect.Struct {
+				s.structFields(ft, schema)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		schema.Properties[name] = s.typeSchema(f.Type)
+		if !omitempty && f.Type.Kind() != reflect.Pointer {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
+
+func (s *schemas) messageSchema(md protoreflect.MessageDescriptor) *Schema {
+	if wkt, ok := wellKnownTypes[md.FullName()]; ok {
+		return wkt()
+	}
+	name := string(md.FullName())
+	if _, ok := s.components[name]; ok {
+		return ref(name)
+	}
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	s.components[name] = schema
+
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		schema.Properties[fd.JSONName()] = s.fieldSchema(fd)
+	}
+	return ref(name)
+}
+
+func (s *schemas) fieldSchema(fd protoreflect.FieldDescriptor) *Schema {
+	switch {
+	case fd.IsMap():
+		return &Schema{Type: "object", AdditionalProperties: s.kindSchema(fd.MapValue())}
+	case fd.IsList():
+		return &Schema{Type: "array", Items: s.kindSchema(fd)}
+	default:
+		return s.kindSchema(fd)
+	}
+}
+
+// kindSchema follows the protobuf JSON mapping.
+func (s *schemas) kindSchema(fd protoreflect.FieldDescriptor) *Schema {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return &Schema{Type: "boolean"}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return &Schema{Type: "integer", Format: "int32"}
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return &Schema{Type: "integer", Format: "uint32"}
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return &Schema{Type: "string", Format: "int64"}
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return &Schema{Type: "string", Format: "uint64"}
+	case protoreflect.FloatKind:
+		return &Schema{Type: "number", Format: "float"}
+	case protoreflect.DoubleKind:
+		return &Schema{Type: "number", Format: "double"}
+	case protoreflect.StringKind:
+		return &Schema{Type: "string"}
+	case protoreflect.BytesKind:
+		return &Schema{Type: "string", Format: "byte"}
+	case protoreflect.EnumKind:
+		values := fd.Enum().Values()
+		schema := &Schema{Type: "string"}
+		for i := 0; i < values.Len(); i++ {
+			schema.Enum = append(schema.Enum, string(values.Get(i).Name()))
+		}
+		return schema
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return s.messageSchema(fd.Message())
+	default:
+		return &Schema{}
+	}
+}
+
+func (s *schemas) uniqueName(name string) string {
+	if _, ok := s.components[name]; !ok {
+		return name
+	}
+	for i := 2; ; i++ {
+		n := name + "_" + strconv.Itoa(i)
+		if _, ok := s.components[n]; !ok {
+			return n
+		}
+	}
+}
+
+// resolve follows a $ref to its component schema.
+func (s *schemas) resolve(schema *Schema) *Schema {
+	if schema == nil || schema.Ref == "" {
+		return schema
+	}
+	return s.components[strings.TrimPrefix(schema.Ref, refPrefix)]
+}
+
+const refPrefix = "#/components/schemas/"
+
+func ref(name string) *Schema {
+	return &Schema{Ref: refPrefix + name}
+}
+
+// componentName returns a name like "package.Type" for a go type.
+func componentName(t reflect.Type) string {
+	name := t.Name()
+	if name == "" {
+		return "Object"
+	}
+	// instantiated generic types look like "Page[github.com/x/y.User]".
+	replacer := strings.NewReplacer("[", "_", "]", "", ",", "_", "*", "", " ", "")
+	if i := strings.IndexByte(name, '['); i >= 0 {
+		args := strings.Split(name[i+1:len(name)-1], ",")
+		for j, arg := range args {
+			args[j] = arg[strings.LastIndexByte(arg, '/')+1:]
+		}
+		name = name[:i] + "[" + strings.Join(args, ",") + "]"
+	}
+	name = replacer.Replace(name)
+	pkg := t.PkgPath()
+	if pkg == "" {
+		return name
+	}
+	return pkg[strings.LastIndexByte(pkg, '/')+1:] + "." + name
+}
+
+// jsonField returns the name of a struct field as encoding/json would.
+func jsonField(f reflect.StructField) (name string, omitempty, skip bool) {
+	tag := f.Tag.Get("json")
+	if tag == "-" {
+		return "", false, true
+	}
+	name, opts, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = f.Name
+	}
+	for _, opt := range strings.Split(opts, ",") {
+		if opt == "omitempty" || opt == "omitzero" {
+			omitempty = true
+		}
+	}
+	return name, omitempty, false
+}
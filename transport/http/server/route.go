@@ -0,0 +1,81 @@
+package server
+
+import "reflect"
+
+// Route is a registered route. Its metadata is only used for documentation,
+// e.g. by the openapi package.
+type Route struct {
+	method string
+	path   string
+	meta   RouteMeta
+}
+
+// RouteMeta describes a route.
+type RouteMeta struct {
+	Summary     string
+	Description string
+	Tags        []string
+	Deprecated  bool
+	// Request is the type bound from the request, nil if unknown.
+	Request reflect.Type
+	// Reply is the type written to the response, nil if unknown.
+	Reply reflect.Type
+}
+
+// Method returns the http method of the route.
+func (r *Route) Method() string {
+	return r.method
+}
+
+// Path returns the full path pattern of the route.
+func (r *Route) Path() string {
+	return r.path
+}
+
+// Meta returns the metadata of the route.
+func (r *Route) Meta() RouteMeta {
+	return r.meta
+}
+
+// Summary sets a short summary of the route.
+func (r *Route) Summary(summary string) *Route {
+	r.meta.Summary = summary
+	return r
+}
+
+// Description sets a verbose explanation of the route.
+func (r *Route) Description(description string) *Route {
+	r.meta.Description = description
+	return r
+}
+
+// Tags adds tags for grouping the route.
+func (r *Route) Tags(tags ...string) *Route {
+	r.meta.Tags = append(r.meta.Tags, tags...)
+	return r
+}
+
+// Deprecated marks the route as deprecated.
+func (r *Route) Deprecated() *Route {
+	r.meta.Deprecated = true
+	return r
+}
+
+// Request sets the type bound from the request, v is a value or a reflect.Type.
+func (r *Route) Request(v any) *Route {
+	r.meta.Request = typeOf(v)
+	return r
+}
+
+// Reply sets the type written to the response, v is a value or a reflect.Type.
+func (r *Route) Reply(v any) *Route {
+	r.meta.Reply = typeOf(v)
+	return r
+}
+
+func typeOf(v any) reflect.Type {
+	if t, ok := v.(reflect.Type); ok {
+		return t
+	}
+	return reflect.TypeOf(v)
+}
@@ -23,16 +23,19 @@ type IRouter interface {
 	Group(path string, middleware ...Middleware) IRoute
 }
 
+// IRoute registers routes. The registration methods return the Route, to
+// describe it for the documentation, e.g. with Summary or Tags. Types of
+// other packages implementing IRoute must return it too.
 type IRoute interface {
-	Handle(method, path string, handler HandlerFunc, middlewares ...Middleware)
-	ANY(path string, handler HandlerFunc, middlewares ...Middleware)
-	GET(path string, handler HandlerFunc, middlewares ...Middleware)
-	POST(path string, handler HandlerFunc, middlewares ...Middleware)
-	PUT(path string, handler HandlerFunc, middlewares ...Middleware)
-	PATCH(path string, handler HandlerFunc, middlewares ...Middleware)
-	DELETE(path string, handler HandlerFunc, middlewares ...Middleware)
-	HEAD(path string, handler HandlerFunc, middlewares ...Middleware)
-	OPTIONS(path string, handler HandlerFunc, middlewares ...Middleware)
+	Handle(method, path string, handler HandlerFunc, middlewares ...Middleware) *Route
+	ANY(path string, handler HandlerFunc, middlewares ...Middleware) *Route
+	GET(path string, handler HandlerFunc, middlewares ...Middleware) *Route
+	POST(path string, handler HandlerFunc, middlewares ...Middleware) *Route
+	PUT(path string, handler HandlerFunc, middlewares ...Middleware) *Route
+	PATCH(path string, handler HandlerFunc, middlewares ...Middleware) *Route
+	DELETE(path string, handler HandlerFunc, middlewares ...Middleware) *Route
+	HEAD(path string, handler HandlerFunc, middlewares ...Middleware) *Route
+	OPTIONS(path string, handler HandlerFunc, middlewares ...Middleware) *Route
 
 	StaticFile(string, string)
 	StaticFileFS(string, string, http.FileSystem)
@@ -57,13 +60,14 @@ func NewRouter(s *Server) *Router {
 	return r
 }
 
-func (r *Router) Handle(method, path string, handler HandlerFunc, middlewares ...Middleware) {
-	if matched := regEnLetter.MatchString(method); !matched {
+// Handle registers a route of method, or of any method if method is empty.
+func (r *Router) Handle(method, path string, handler HandlerFunc, middlewares ...Middleware) *Route {
+	if matched := regEnLetter.MatchString(method); method != "" && !matched {
 		panic("http method " + method + " is not valid")
 	}
 
 	fullPath := r.joinPaths(r.basePath, path)
-	pattern := fmt.Sprintf("%s %s", method, fullPath)
+	pattern := strings.TrimSpace(fmt.Sprintf("%s %s", method, fullPath))
 
 	if r.server.showInfo {
 		r.server.log.Info(pattern)
@@ -81,6 +85,12 @@ func (r *Router) Handle(method, path string, handler HandlerFunc, middlewares ..
 	})
 
 	r.mux.Handle(pattern, entrance)
+
+	route := &Route{method: method, path: fullPath}
+	r.server.routesMu.Lock()
+	r.server.routes = append(r.server.routes, route)
+	r.server.routesMu.Unlock()
+	return route
 }
 
 // Group implements IRouter.
@@ -93,36 +103,36 @@ func (r *Router) Group(path string, middleware ...Middleware) IRoute {
 	}
 }
 
-func (r *Router) HEAD(path string, handler HandlerFunc, middlewares ...Middleware) {
-	r.Handle(http.MethodHead, path, handler, middlewares...)
+func (r *Router) HEAD(path string, handler HandlerFunc, middlewares ...Middleware) *Route {
+	return r.Handle(http.MethodHead, path, handler, middlewares...)
 }
 
-func (r *Router) GET(path string, handler HandlerFunc, middlewares ...Middleware) {
-	r.Handle(http.MethodGet, path, handler, middlewares...)
+func (r *Router) GET(path string, handler HandlerFunc, middlewares ...Middleware) *Route {
+	return r.Handle(http.MethodGet, path, handler, middlewares...)
 }
 
-func (r *Router) POST(path string, handler HandlerFunc, middlewares ...Middleware) {
-	r.Handle(http.MethodPost, path, handler, middlewares...)
+func (r *Router) POST(path string, handler HandlerFunc, middlewares ...Middleware) *Route {
+	return r.Handle(http.MethodPost, path, handler, middlewares...)
 }
 
-func (r *Router) PUT(path string, handler HandlerFunc, middlewares ...Middleware) {
-	r.Handle(http.MethodPut, path, handler, middlewares...)
+func (r *Router) PUT(path string, handler HandlerFunc, middlewares ...Middleware) *Route {
+	return r.Handle(http.MethodPut, path, handler, middlewares...)
 }
 
-func (r *Router) PATCH(path string, handler HandlerFunc, middlewares ...Middleware) {
-	r.Handle(http.MethodPatch, path, handler, middlewares...)
+func (r *Router) PATCH(path string, handler HandlerFunc, middlewares ...Middleware) *Route {
+	return r.Handle(http.MethodPatch, path, handler, middlewares...)
 }
 
-func (r *Router) DELETE(path string, handler HandlerFunc, middlewares ...Middleware) {
-	r.Handle(http.MethodDelete, path, handler, middlewares...)
+func (r *Router) DELETE(path string, handler HandlerFunc, middlewares ...Middleware) *Route {
+	return r.Handle(http.MethodDelete, path, handler, middlewares...)
 }
 
-func (r *Router) OPTIONS(path string, handler HandlerFunc, middlewares ...Middleware) {
-	r.Handle(http.MethodOptions, path, handler, middlewares...)
+func (r *Router) OPTIONS(path string, handler HandlerFunc, middlewares ...Middleware) *Route {
+	return r.Handle(http.MethodOptions, path, handler, middlewares...)
 }
 
-func (r *Router) ANY(path string, handler HandlerFunc, middlewares ...Middleware) {
-	r.Handle("", path, handler, middlewares...)
+func (r *Router) ANY(path string, handler HandlerFunc, middlewares ...Middleware) *Route {
+	return r.Handle("", path, handler, middlewares...)
 }
 
 // StaticFile registers a single route in order to serve a single file of the local filesystem.
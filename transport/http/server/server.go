@@ -8,11 +8,15 @@ import (
 	"github.com/yates-z/easel/logger"
 	"github.com/yates-z/easel/transport"
 	templ "github.com/yates-z/easel/transport/http/server/template"
+	"github.com/yates-z/easel/transport/realip"
 	"github.com/yates-z/easel/utils/host"
 	"html/template"
 	"net"
 	"net/http"
 	"net/url"
+	"slices"
+	"strings"
+	"sync"
 )
 
 type ServerOption func(*Server)
@@ -31,6 +35,22 @@ func Address(addr string) ServerOption {
 	}
 }
 
+// Listener with a server listener, network and address are ignored if set,
+// e.g. a realip.Resolver listener accepting the PROXY protocol.
+func Listener(lis net.Listener) ServerOption {
+	return func(s *Server) {
+		s.listener = lis
+	}
+}
+
+// RealIP resolves the IP of the clients with r, from the headers of trusted
+// proxies, and puts it into the context of the requests, see Context.RemoteIP.
+func RealIP(r *realip.Resolver) ServerOption {
+	return func(s *Server) {
+		s.realIP = r
+	}
+}
+
 // TLSConfig with TLS config.
 func TLSConfig(c *tls.Config) ServerOption {
 	return func(s *Server) {
@@ -66,6 +86,7 @@ type Server struct {
 	network  string
 	address  string
 	tlsConf  *tls.Config
+	realIP   *realip.Resolver
 
 	log          logger.Logger
 	ctxPool      *pool.Pool[*Context]
@@ -73,6 +94,9 @@ type Server struct {
 	showInfo     bool
 	htmlTempl    *templ.HTMLTemplate
 	errorHandler func(ctx *Context, err error)
+	// routesMu guards routes, which may be registered while serving.
+	routesMu sync.RWMutex
+	routes   []*Route
 }
 
 func NewServer(opts ...ServerOption) *Server {
@@ -110,7 +134,11 @@ func NewServer(opts ...ServerOption) *Server {
 		TLSConfig: server.tlsConf,
 		Handler: http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
 			ctx := server.ctxPool.Get()
-			ctx.WithBaseContext(req.Context())
+			if server.realIP != nil {
+				ctx.WithBaseContext(realip.NewContext(req.Context(), server.realIP.FromRequest(req)))
+			} else {
+				ctx.WithBaseContext(req.Context())
+			}
 			req = req.Clone(ctx)
 			ctx.init(req, resp)
 			if err := handler(ctx); err != nil {
@@ -143,6 +171,32 @@ func (s *Server) LoadHTMLFiles(files ...string) {
 	s.htmlTempl.LoadHTMLFiles(files...)
 }
 
+// Routes returns all registered routes in registration order.
+func (s *Server) Routes() []*Route {
+	s.routesMu.RLock()
+	defer s.routesMu.RUnlock()
+	return slices.Clone(s.routes)
+}
+
+// NewContext creates a Context for req outside of the context pool, so that
+// handlers can be called directly, e.g. in tests. The full path of the context
+// is taken from req.Pattern.
+func (s *Server) NewContext(w http.ResponseWriter, req *http.Request) *Context {
+	ctx := newContext(s)
+	ctx.WithBaseContext(req.Context())
+	ctx.init(req.Clone(ctx), w)
+	ctx.fullPath = req.Pattern
+	if _, path, ok := strings.Cut(req.Pattern, " "); ok {
+		ctx.fullPath = path
+	}
+	return ctx
+}
+
+// HandleError handles err returned by a handler with the server error handler.
+func (s *Server) HandleError(ctx *Context, err error) {
+	s.errorHandler(ctx, err)
+}
+
 // SetErrorHandler sets custom http error handler.
 func (s *Server) SetErrorHandler(f func(*Context, error)) {
 	s.errorHandler = f
@@ -0,0 +1,81 @@
+package servertest
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var errInvalidPath = errors.New("invalid json path")
+
+// lookup evaluates a JSON path on a decoded document. Only child names
+// (".name" or "['name']") and array indexes ("[0]", "[-1]" for the last
+// element) are supported.
+func lookup(doc any, path string) (any, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, errInvalidPath
+	}
+	path = path[1:]
+	v := doc
+	for path != "" {
+		var (
+			key   string
+			index int
+			isKey bool
+		)
+		switch {
+		case path[0] == '.':
+			end := strings.IndexAny(path[1:], ".[")
+			if end < 0 {
+				end = len(path) - 1
+			}
+			key, isKey = path[1:end+1], true
+			path = path[end+1:]
+		case strings.HasPrefix(path, "['"):
+			end := strings.Index(path, "']")
+			if end < 0 {
+				return nil, errInvalidPath
+			}
+			key, isKey = path[2:end], true
+			path = path[end+2:]
+		case path[0] == '[':
+			end := strings.IndexByte(path, ']')
+			if end < 0 {
+				return nil, errInvalidPath
+			}
+			i, err := strconv.Atoi(path[1:end])
+			if err != nil {
+				return nil, errInvalidPath
+			}
+			index = i
+			path = path[end+1:]
+		default:
+			return nil, errInvalidPath
+		}
+
+		if isKey {
+			obj, ok := v.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("%q: not an object", key)
+			}
+			if v, ok = obj[key]; !ok {
+				return nil, fmt.Errorf("%q: no such key", key)
+			}
+			continue
+		}
+		arr, ok := v.([]any)
+		if !ok {
+			return nil, fmt.Errorf("[%d]: not an array", index)
+		}
+		i := index
+		if i < 0 {
+			i += len(arr)
+		}
+		if i < 0 || i >= len(arr) {
+			return nil, fmt.Errorf("[%d]: index out of range", index)
+		}
+		v = arr[i]
+	}
+	return v, nil
+}
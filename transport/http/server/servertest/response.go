@@ -0,0 +1,152 @@
+package servertest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Response is a recorded response. Assertions report failures with
+// t.Errorf and return the response, so that they can be chained.
+type Response struct {
+	t    testing.TB
+	resp *http.Response
+	body []byte
+	err  error
+}
+
+// Raw returns the recorded http.Response.
+func (r *Response) Raw() *http.Response {
+	return r.resp
+}
+
+// Body returns the response body.
+func (r *Response) Body() []byte {
+	return r.body
+}
+
+// Err returns the error returned by the handler passed to Request.Call.
+func (r *Response) Err() error {
+	return r.err
+}
+
+// Status asserts the status code.
+func (r *Response) Status(code int) *Response {
+	r.t.Helper()
+	if r.resp.StatusCode != code {
+		r.t.Errorf("servertest: status = %d, want %d, body: %s", r.resp.StatusCode, code, r.body)
+	}
+	return r
+}
+
+// Header asserts the value of a response header.
+func (r *Response) Header(key, value string) *Response {
+	r.t.Helper()
+	if got := r.resp.Header.Get(key); got != value {
+		r.t.Errorf("servertest: header %s = %q, want %q", key, got, value)
+	}
+	return r
+}
+
+// ContentType asserts the media type of the response, parameters are ignored.
+func (r *Response) ContentType(mediaType string) *Response {
+	r.t.Helper()
+	got, _, _ := strings.Cut(r.resp.Header.Get("Content-Type"), ";")
+	if strings.TrimSpace(got) != mediaType {
+		r.t.Errorf("servertest: content type = %q, want %q", got, mediaType)
+	}
+	return r
+}
+
+// BodyEqual asserts the response body.
+func (r *Response) BodyEqual(body string) *Response {
+	r.t.Helper()
+	if string(r.body) != body {
+		r.t.Errorf("servertest: body = %q, want %q", r.body, body)
+	}
+	return r
+}
+
+// BodyContains asserts that the response body contains s.
+func (r *Response) BodyContains(s string) *Response {
+	r.t.Helper()
+	if !bytes.Contains(r.body, []byte(s)) {
+		r.t.Errorf("servertest: body %q does not contain %q", r.body, s)
+	}
+	return r
+}
+
+// Cookie asserts that the response sets the named cookie to value.
+func (r *Response) Cookie(name, value string) *Response {
+	r.t.Helper()
+	for _, c := range r.resp.Cookies() {
+		if c.Name == name {
+			if c.Value != value {
+				r.t.Errorf("servertest: cookie %s = %q, want %q", name, c.Value, value)
+			}
+			return r
+		}
+	}
+	r.t.Errorf("servertest: cookie %s is not set", name)
+	return r
+}
+
+// NoError asserts that the handler passed to Request.Call returned no error.
+func (r *Response) NoError() *Response {
+	r.t.Helper()
+	if r.err != nil {
+		r.t.Errorf("servertest: unexpected handler error: %v", r.err)
+	}
+	return r
+}
+
+// Error asserts that the handler passed to Request.Call returned target,
+// as reported by errors.Is.
+func (r *Response) Error(target error) *Response {
+	r.t.Helper()
+	if !errors.Is(r.err, target) {
+		r.t.Errorf("servertest: handler error = %v, want %v", r.err, target)
+	}
+	return r
+}
+
+// JSON decodes the response body into v.
+func (r *Response) JSON(v any) *Response {
+	r.t.Helper()
+	if err := json.Unmarshal(r.body, v); err != nil {
+		r.t.Errorf("servertest: decode json: %v, body: %s", err, r.body)
+	}
+	return r
+}
+
+// JSONPath asserts the value at path in the JSON body, e.g. "$.data.items[0].id".
+// want is compared with the JSON value after a JSON round trip, so 200 matches
+// the number 200 and structs match objects with the same fields.
+func (r *Response) JSONPath(path string, want any) *Response {
+	r.t.Helper()
+	var doc any
+	if err := json.Unmarshal(r.body, &doc); err != nil {
+		r.t.Errorf("servertest: decode json: %v, body: %s", err, r.body)
+		return r
+	}
+	got, err := lookup(doc, path)
+	if err != nil {
+		r.t.Errorf("servertest: %s: %v, body: %s", path, err, r.body)
+		return r
+	}
+	data, err := json.Marshal(want)
+	if err != nil {
+		r.t.Errorf("servertest: encode %v: %v", want, err)
+		return r
+	}
+	var expected any
+	_ = json.Unmarshal(data, &expected)
+	if !reflect.DeepEqual(got, expected) {
+		r.t.Errorf("servertest: %s = %v, want %v", path, got, want)
+	}
+	return r
+}
@@ -0,0 +1,288 @@
+// Package servertest runs requests against a server.Server in process.
+//
+//	h := servertest.New(t, s)
+//	h.POST("/users").WithJSON(user).Expect().Status(http.StatusOK).JSONPath("$.name", "easel")
+//
+// Handlers can be called directly too, without registering them:
+//
+//	h.GET("/users/1").WithParam("id", "1").Call(getUser).Status(http.StatusOK)
+package servertest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/yates-z/easel/auth/authentication/session"
+	"github.com/yates-z/easel/transport/http/server"
+	sessionmw "github.com/yates-z/easel/transport/http/server/middlewares/session"
+)
+
+type Option func(*options)
+
+type options struct {
+	sessionCookie string
+}
+
+// SessionCookie with the name of the cookie set by Session, the CookieName of
+// the session middleware by default, for a middleware using WithCookieName.
+func SessionCookie(name string) Option {
+	return func(o *options) {
+		o.sessionCookie = name
+	}
+}
+
+// Harness sends requests to a server without a listener. Cookies set by
+// responses are kept and sent with the following requests, like a browser does.
+type Harness struct {
+	t       testing.TB
+	server  *server.Server
+	opts    *options
+	cookies map[string]*http.Cookie
+}
+
+// New creates a Harness for s.
+func New(t testing.TB, s *server.Server, opts ...Option) *Harness {
+	o := &options{sessionCookie: sessionmw.CookieName}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return &Harness{
+		t:       t,
+		server:  s,
+		opts:    o,
+		cookies: make(map[string]*http.Cookie),
+	}
+}
+
+// SetCookie adds a cookie sent with every following request.
+func (h *Harness) SetCookie(cookie *http.Cookie) {
+	h.cookies[cookie.Name] = cookie
+}
+
+// Cookie returns the named cookie kept by the harness, or nil.
+func (h *Harness) Cookie(name string) *http.Cookie {
+	return h.cookies[name]
+}
+
+// ClearCookies removes all cookies kept by the harness.
+func (h *Harness) ClearCookies() {
+	clear(h.cookies)
+}
+
+// Session creates a session holding data and sends its ID with every
+// following request in the session cookie, see SessionCookie.
+func (h *Harness) Session(sm *session.SessionManager, data map[string]any) string {
+	h.t.Helper()
+	id, err := sm.CreateSession()
+	if err != nil {
+		h.t.Fatalf("servertest: create session: %v", err)
+	}
+	for k, v := range data {
+		if err = sm.UpdateSession(id, k, v); err != nil {
+			h.t.Fatalf("servertest: update session: %v", err)
+		}
+	}
+	h.SetCookie(&http.Cookie{Name: h.opts.sessionCookie, Value: id})
+	return id
+}
+
+func (h *Harness) storeCookies(cookies []*http.Cookie) {
+	now := time.Now()
+	for _, c := range cookies {
+		if c.MaxAge < 0 || (!c.Expires.IsZero() && c.Expires.Before(now)) {
+			delete(h.cookies, c.Name)
+			continue
+		}
+		h.cookies[c.Name] = c
+	}
+}
+
+// Request creates a request with method to target, e.g. "/users?page=1".
+func (h *Harness) Request(method, target string) *Request {
+	return &Request{
+		h:      h,
+		method: method,
+		target: target,
+		header: make(http.Header),
+		query:  make(url.Values),
+		params: make(map[string]string),
+		ctx:    context.Background(),
+	}
+}
+
+func (h *Harness) GET(target string) *Request {
+	return h.Request(http.MethodGet, target)
+}
+
+func (h *Harness) POST(target string) *Request {
+	return h.Request(http.MethodPost, target)
+}
+
+func (h *Harness) PUT(target string) *Request {
+	return h.Request(http.MethodPut, target)
+}
+
+func (h *Harness) PATCH(target string) *Request {
+	return h.Request(http.MethodPatch, target)
+}
+
+func (h *Harness) DELETE(target string) *Request {
+	return h.Request(http.MethodDelete, target)
+}
+
+func (h *Harness) HEAD(target string) *Request {
+	return h.Request(http.MethodHead, target)
+}
+
+func (h *Harness) OPTIONS(target string) *Request {
+	return h.Request(http.MethodOptions, target)
+}
+
+// Request is a request under construction.
+type Request struct {
+	h       *Harness
+	method  string
+	target  string
+	header  http.Header
+	query   url.Values
+	cookies []*http.Cookie
+	body    []byte
+	params  map[string]string
+	pattern string
+	ctx     context.Context
+}
+
+// WithHeader sets a request header.
+func (r *Request) WithHeader(key, value string) *Request {
+	r.header.Set(key, value)
+	return r
+}
+
+// WithQuery adds a query parameter.
+func (r *Request) WithQuery(key, value string) *Request {
+	r.query.Add(key, value)
+	return r
+}
+
+// WithCookie sends a cookie with this request only.
+func (r *Request) WithCookie(name, value string) *Request {
+	r.cookies = append(r.cookies, &http.Cookie{Name: name, Value: value})
+	return r
+}
+
+// WithBody sets the request body and its Content-Type.
+func (r *Request) WithBody(contentType string, body []byte) *Request {
+	r.header.Set("Content-Type", contentType)
+	r.body = body
+	return r
+}
+
+// WithJSON sets v encoded as JSON as the request body.
+func (r *Request) WithJSON(v any) *Request {
+	r.h.t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		r.h.t.Fatalf("servertest: encode json: %v", err)
+	}
+	return r.WithBody("application/json", data)
+}
+
+// WithForm sets values as an url encoded form body.
+func (r *Request) WithForm(values url.Values) *Request {
+	return r.WithBody("application/x-www-form-urlencoded", []byte(values.Encode()))
+}
+
+// WithContext sets the context of the request.
+func (r *Request) WithContext(ctx context.Context) *Request {
+	r.ctx = ctx
+	return r
+}
+
+// WithParam sets a path wildcard, it is only used by Call and Context,
+// requests sent by Expect are matched by the router.
+func (r *Request) WithParam(name, value string) *Request {
+	r.params[name] = value
+	return r
+}
+
+// WithPattern sets the route pattern returned by Context.FullPath, the
+// request path by default. It is only used by Call and Context.
+func (r *Request) WithPattern(pattern string) *Request {
+	r.pattern = pattern
+	return r
+}
+
+func (r *Request) build() *http.Request {
+	var body io.Reader
+	if r.body != nil {
+		body = bytes.NewReader(r.body)
+	}
+	req := httptest.NewRequestWithContext(r.ctx, r.method, r.target, body)
+	if len(r.query) > 0 {
+		query := req.URL.Query()
+		for k, v := range r.query {
+			query[k] = append(query[k], v...)
+		}
+		req.URL.RawQuery = query.Encode()
+		req.RequestURI = req.URL.RequestURI()
+	}
+	for k, v := range r.header {
+		req.Header[k] = v
+	}
+	// cookies of the request override the ones kept by the harness.
+	cookies := maps.Clone(r.h.cookies)
+	for _, c := range r.cookies {
+		cookies[c.Name] = c
+	}
+	for _, c := range cookies {
+		req.AddCookie(&http.Cookie{Name: c.Name, Value: c.Value})
+	}
+	return req
+}
+
+// Expect sends the request to the server and returns its response.
+func (r *Request) Expect() *Response {
+	rec := httptest.NewRecorder()
+	r.h.server.Handler.ServeHTTP(rec, r.build())
+	return r.h.response(rec, nil)
+}
+
+// Context builds a server.Context for the request, for calling a handler
+// directly. The response is written to the returned recorder.
+func (r *Request) Context() (*server.Context, *httptest.ResponseRecorder) {
+	req := r.build()
+	for name, value := range r.params {
+		req.SetPathValue(name, value)
+	}
+	req.Pattern = r.pattern
+	if req.Pattern == "" {
+		req.Pattern = req.URL.Path
+	}
+	rec := httptest.NewRecorder()
+	return r.h.server.NewContext(rec, req), rec
+}
+
+// Call calls handler with the request. An error returned by handler is
+// handled by the server error handler, and is available from Response.Err.
+func (r *Request) Call(handler server.HandlerFunc) *Response {
+	ctx, rec := r.Context()
+	err := handler(ctx)
+	if err != nil {
+		r.h.server.HandleError(ctx, err)
+	}
+	return r.h.response(rec, err)
+}
+
+func (h *Harness) response(rec *httptest.ResponseRecorder, err error) *Response {
+	resp := rec.Result()
+	h.storeCookies(resp.Cookies())
+	return &Response{t: h.t, resp: resp, body: rec.Body.Bytes(), err: err}
+}
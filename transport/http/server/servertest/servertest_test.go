@@ -0,0 +1,188 @@
+package servertest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/yates-z/easel/auth/authentication/session"
+	"github.com/yates-z/easel/transport/grpc/server/test/api"
+	"github.com/yates-z/easel/transport/http/server"
+	"github.com/yates-z/easel/transport/http/server/adapter"
+	sessionmw "github.com/yates-z/easel/transport/http/server/middlewares/session"
+	"github.com/yates-z/easel/transport/realip"
+)
+
+func sayHello(_ context.Context, in *api.HelloRequest) (*api.HelloResponse, error) {
+	if in.Name == "" {
+		return nil, errors.New("name is required")
+	}
+	return &api.HelloResponse{Replay: "hello, " + in.Name}, nil
+}
+
+func getUser(c *server.Context) error {
+	return c.JSON(http.StatusOK, map[string]any{
+		"code": 200,
+		"data": map[string]any{"id": c.Param("id"), "path": c.FullPath(), "tags": []string{"a", "b"}},
+	})
+}
+
+func newServer() *server.Server {
+	s := server.NewServer()
+	s.POST("/hello", adapter.GRPC(sayHello))
+	s.GET("/users/{id}", getUser)
+	s.POST("/login", func(c *server.Context) error {
+		c.SetCookie("token", c.Request.FormValue("user"), 3600, "/", "", false, true)
+		return c.String(http.StatusOK, "ok")
+	})
+	s.GET("/me", func(c *server.Context) error {
+		token, err := c.GetCookie("token")
+		if err != nil {
+			return c.String(http.StatusUnauthorized, "unauthorized")
+		}
+		return c.String(http.StatusOK, token)
+	})
+	return s
+}
+
+func TestExpect(t *testing.T) {
+	h := New(t, newServer())
+	h.POST("/hello").WithJSON(map[string]string{"name": "easel"}).Expect().
+		Status(http.StatusOK).
+		ContentType("application/json").
+		JSONPath("$.replay", "hello, easel")
+
+	h.POST("/hello").WithHeader("Content-Type", "application/json").WithBody("application/json", []byte(`{}`)).Expect().
+		Status(http.StatusBadRequest).
+		JSONPath("$.code", 400).
+		JSONPath("$.message", "name is required")
+
+	h.GET("/users/7").WithQuery("verbose", "1").Expect().
+		Status(http.StatusOK).
+		JSONPath("$.code", 200).
+		JSONPath("$.data.id", "7").
+		JSONPath("$.data.path", "/users/{id}").
+		JSONPath("$['data'].tags[-1]", "b").
+		JSONPath("$.data.tags", []string{"a", "b"})
+}
+
+func TestCookies(t *testing.T) {
+	h := New(t, newServer())
+	h.GET("/me").Expect().Status(http.StatusUnauthorized)
+	h.POST("/login").WithForm(url.Values{"user": {"easel"}}).Expect().
+		Status(http.StatusOK).
+		Cookie("token", "easel")
+	h.GET("/me").Expect().Status(http.StatusOK).BodyEqual("easel")
+	h.GET("/me").WithCookie("token", "other").Expect().BodyEqual("other")
+
+	h.ClearCookies()
+	h.GET("/me").Expect().Status(http.StatusUnauthorized)
+}
+
+func TestSession(t *testing.T) {
+	sm := session.NewSessionManager(session.NewCacheSessionBackend(1, 10, time.Minute))
+	s := server.NewServer(server.Middlewares(sessionmw.Middleware(sm)))
+	s.GET("/profile", func(c *server.Context) error {
+		sess := c.MustGet("session").(*session.Session)
+		return c.JSON(http.StatusOK, sess.Data)
+	})
+
+	h := New(t, s)
+	h.GET("/profile").Expect().Status(http.StatusUnauthorized)
+	id := h.Session(sm, map[string]any{"user": "easel"})
+	if h.Cookie(sessionmw.CookieName).Value != id {
+		t.Fatal("session cookie is not kept")
+	}
+	h.GET("/profile").Expect().Status(http.StatusOK).JSONPath("$.user", "easel")
+}
+
+func TestSessionCookie(t *testing.T) {
+	sm := session.NewSessionManager(session.NewCacheSessionBackend(1, 10, time.Minute))
+	s := server.NewServer(server.Middlewares(sessionmw.Middleware(sm, sessionmw.WithCookieName("sid"))))
+	s.GET("/profile", func(c *server.Context) error {
+		sess := c.MustGet("session").(*session.Session)
+		return c.JSON(http.StatusOK, sess.Data)
+	})
+
+	h := New(t, s, SessionCookie("sid"))
+	id := h.Session(sm, map[string]any{"user": "easel"})
+	if h.Cookie("sid").Value != id {
+		t.Fatal("session cookie is not kept")
+	}
+	h.GET("/profile").Expect().Status(http.StatusOK).JSONPath("$.user", "easel")
+}
+
+func TestCall(t *testing.T) {
+	h := New(t, server.NewServer())
+	h.GET("/users/1").WithParam("id", "1").WithPattern("/users/{id}").Call(getUser).
+		NoError().
+		Status(http.StatusOK).
+		JSONPath("$.data.id", "1").
+		JSONPath("$.data.path", "/users/{id}")
+
+	resp := h.POST("/hello").WithJSON(map[string]string{}).Call(adapter.GRPC(sayHello)).
+		Status(http.StatusBadRequest)
+	if resp.Err() == nil || resp.Err().Error() != "name is required" {
+		t.Fatalf("unexpected handler error %v", resp.Err())
+	}
+
+	ctx, rec := h.GET("/users/2").WithParam("id", "2").Context()
+	if ctx.Param("id") != "2" || ctx.FullPath() != "/users/2" {
+		t.Fatalf("unexpected context %q %q", ctx.Param("id"), ctx.FullPath())
+	}
+	if err := ctx.String(http.StatusAccepted, "accepted"); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusAccepted || rec.Body.String() != "accepted" {
+		t.Fatalf("unexpected recorded response %d %q", rec.Code, rec.Body.String())
+	}
+}
+
+func TestRealIP(t *testing.T) {
+	// httptest requests are sent from 192.0.2.1
+	r, err := realip.NewResolver(realip.TrustedProxies("192.0.2.0/24"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := server.NewServer(server.RealIP(r))
+	s.GET("/ip", func(c *server.Context) error {
+		return c.String(http.StatusOK, c.RemoteIP())
+	})
+	h := New(t, s)
+	h.GET("/ip").Expect().BodyEqual("192.0.2.1")
+	h.GET("/ip").WithHeader("X-Forwarded-For", "198.51.100.1, 192.0.2.2").Expect().BodyEqual("198.51.100.1")
+
+	// without the option, the headers aren't trusted
+	h = New(t, newServer())
+	h.server.GET("/ip", func(c *server.Context) error {
+		return c.String(http.StatusOK, c.RemoteIP())
+	})
+	h.GET("/ip").WithHeader("X-Forwarded-For", "198.51.100.1").Expect().BodyEqual("192.0.2.1")
+}
+
+func TestLookup(t *testing.T) {
+	doc := map[string]any{"a": []any{map[string]any{"b.c": 1.0}}}
+	for _, tc := range []struct {
+		path string
+		want any
+		ok   bool
+	}{
+		{"$", doc, true},
+		{"$.a[0]['b.c']", 1.0, true},
+		{"$.a[1]", nil, false},
+		{"$.x", nil, false},
+		{"a", nil, false},
+		{"$.a.b", nil, false},
+	} {
+		got, err := lookup(doc, tc.path)
+		if (err == nil) != tc.ok {
+			t.Fatalf("lookup(%q) error = %v", tc.path, err)
+		}
+		if tc.ok && tc.path != "$" && got != tc.want {
+			t.Fatalf("lookup(%q) = %v, want %v", tc.path, got, tc.want)
+		}
+	}
+}
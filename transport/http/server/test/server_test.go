@@ -11,6 +11,7 @@ import (
 	"github.com/yates-z/easel/transport/http/server/adapter"
 	"github.com/yates-z/easel/transport/http/server/middlewares/logging"
 	"github.com/yates-z/easel/transport/http/server/middlewares/recovery"
+	"github.com/yates-z/easel/transport/http/server/servertest"
 )
 
 type HelloService struct {
@@ -37,3 +38,16 @@ func TestServer(t *testing.T) {
 	s.GET("/user/test", adapter.GRPC(service.SayHello))
 	s.MustStart(context.Background())
 }
+
+func TestServerInProcess(t *testing.T) {
+	s := server.NewServer(server.Middlewares(recovery.Middleware()))
+	service := &HelloService{}
+	s.GET("/hello/{name}", Hello)
+	s.POST("/hello/{$}", adapter.GRPC(service.SayHello))
+
+	h := servertest.New(t, s)
+	h.GET("/hello/easel").Expect().Status(http.StatusOK).JSONPath("$.hello", "easel")
+	h.POST("/hello/").WithJSON(map[string]string{"name": "easel"}).Expect().
+		Status(http.StatusOK).
+		JSONPath("$.replay", "hello, easel")
+}
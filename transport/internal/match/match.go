@@ -0,0 +1,37 @@
+// Package match matches names, e.g. gRPC methods or URL paths, against
+// patterns. A pattern matches the name equal to it, or, if it ends with "*",
+// the names it prefixes without the "*", e.g. "/pb.Greeter/*".
+package match
+
+import "strings"
+
+// Any reports whether name matches one of patterns.
+func Any(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if p == name {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(p, "*"); ok && strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Longest returns the value of the pattern of values matching name, the
+// name itself winning over the longest prefix.
+func Longest[V any](values map[string]V, name string) (V, bool) {
+	if v, ok := values[name]; ok {
+		return v, true
+	}
+	var (
+		value   V
+		longest = -1
+	)
+	for p, v := range values {
+		if prefix, ok := strings.CutSuffix(p, "*"); ok && strings.HasPrefix(name, prefix) && len(prefix) > longest {
+			value, longest = v, len(prefix)
+		}
+	}
+	return value, longest >= 0
+}
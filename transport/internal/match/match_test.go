@@ -0,0 +1,33 @@
+package match
+
+import "testing"
+
+func TestAny(t *testing.T) {
+	patterns := []string{"/pb.Greeter/SayHello", "/grpc.health.v1.Health/*"}
+	for name, want := range map[string]bool{
+		"/pb.Greeter/SayHello":          true,
+		"/pb.Greeter/SayBye":            false,
+		"/grpc.health.v1.Health/Watch":  true,
+		"/grpc.health.v1.HealthX/Watch": false,
+	} {
+		if Any(patterns, name) != want {
+			t.Fatalf("Any(%q) != %v", name, want)
+		}
+	}
+}
+
+func TestLongest(t *testing.T) {
+	values := map[string]int{"/pb.*": 1, "/pb.Greeter/*": 2, "/pb.Greeter/SayHello": 3}
+	for name, want := range map[string]int{
+		"/pb.Greeter/SayHello": 3,
+		"/pb.Greeter/SayBye":   2,
+		"/pb.Other/Call":       1,
+	} {
+		if got, ok := Longest(values, name); !ok || got != want {
+			t.Fatalf("Longest(%q) = %d, want %d", name, got, want)
+		}
+	}
+	if _, ok := Longest(values, "/other"); ok {
+		t.Fatal("unexpected match")
+	}
+}
@@ -0,0 +1,53 @@
+// Package window aggregates the events of a rolling period in buckets.
+package window
+
+import "time"
+
+// Window is a rolling period split in buckets of type B. It isn't safe for
+// concurrent use.
+type Window[B any] struct {
+	buckets []bucket[B]
+	size    time.Duration
+}
+
+type bucket[B any] struct {
+	// epoch is the index of the bucket since the Unix epoch.
+	epoch int64
+	value B
+}
+
+// New creates a Window of period split in n buckets.
+func New[B any](period time.Duration, n int) *Window[B] {
+	return &Window[B]{buckets: make([]bucket[B], n), size: period / time.Duration(n)}
+}
+
+// Size returns the period of a bucket.
+func (w *Window[B]) Size() time.Duration {
+	return w.size
+}
+
+// Bucket returns the bucket of now, reset if it was of an earlier period.
+func (w *Window[B]) Bucket(now time.Time) *B {
+	epoch := now.UnixNano() / int64(w.size)
+	b := &w.buckets[epoch%int64(len(w.buckets))]
+	if b.epoch != epoch {
+		*b = bucket[B]{epoch: epoch}
+	}
+	return &b.value
+}
+
+// Each calls f with the buckets of the period ending at now, current is
+// true for the bucket of now, which isn't complete yet.
+func (w *Window[B]) Each(now time.Time, f func(b B, current bool)) {
+	epoch := now.UnixNano() / int64(w.size)
+	for _, b := range w.buckets {
+		if age := epoch - b.epoch; age >= 0 && age < int64(len(w.buckets)) {
+			f(b.value, age == 0)
+		}
+	}
+}
+
+// Reset empties the buckets.
+func (w *Window[B]) Reset() {
+	clear(w.buckets)
+}
@@ -0,0 +1,267 @@
+package limiter
+
+import (
+	"math"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// bounds are the bounds of a limit.
+type bounds struct {
+	initial, min, max int
+}
+
+func (b *bounds) defaults(initial, min, max int) {
+	if b.initial <= 0 {
+		b.initial = initial
+	}
+	if b.min <= 0 {
+		b.min = min
+	}
+	if b.max <= 0 {
+		b.max = max
+	}
+}
+
+func (b *bounds) clamp(limit float64) float64 {
+	return math.Min(math.Max(limit, float64(b.min)), float64(b.max))
+}
+
+// AIMDConfig is the config of NewAIMD, zero values are the defaults.
+type AIMDConfig struct {
+	// InitialLimit is the limit before the first sample, 20 by default.
+	InitialLimit int
+	// MinLimit and MaxLimit bound the limit, 1 and 1000 by default.
+	MinLimit, MaxLimit int
+	// BackoffRatio multiplies the limit when a request is dropped, 0.9 by default.
+	BackoffRatio float64
+	// Timeout is the latency of a request counted as dropped, 5 seconds by default.
+	Timeout time.Duration
+}
+
+type aimd struct {
+	mu      sync.Mutex
+	bounds  bounds
+	backoff float64
+	timeout time.Duration
+	limit   float64
+}
+
+// NewAIMD returns an Algorithm increasing the limit by one while it's used,
+// and decreasing it by BackoffRatio when a request is dropped or times out.
+func NewAIMD(cfg AIMDConfig) Algorithm {
+	a := &aimd{
+		bounds:  bounds{cfg.InitialLimit, cfg.MinLimit, cfg.MaxLimit},
+		backoff: cfg.BackoffRatio,
+		timeout: cfg.Timeout,
+	}
+	a.bounds.defaults(20, 1, 1000)
+	if a.backoff <= 0 || a.backoff >= 1 {
+		a.backoff = 0.9
+	}
+	if a.timeout <= 0 {
+		a.timeout = 5 * time.Second
+	}
+	a.limit = a.bounds.clamp(float64(a.bounds.initial))
+	return a
+}
+
+func (a *aimd) Limit() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return int(a.limit)
+}
+
+func (a *aimd) Update(rtt time.Duration, inflight int, dropped bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	switch {
+	case dropped || rtt > a.timeout:
+		a.limit = a.bounds.clamp(math.Floor(a.limit * a.backoff))
+	case float64(inflight)*2 >= a.limit:
+		// increase only when the limit is used, not by an idle server
+		a.limit = a.bounds.clamp(a.limit + 1)
+	}
+}
+
+// VegasConfig is the config of NewVegas, zero values are the defaults.
+type VegasConfig struct {
+	// InitialLimit is the limit before the first sample, 20 by default.
+	InitialLimit int
+	// MinLimit and MaxLimit bound the limit, 1 and 1000 by default.
+	MinLimit, MaxLimit int
+	// Smoothing weights the new limit against the current one, in (0, 1],
+	// 1 by default.
+	Smoothing float64
+	// ProbeMultiplier sets how often, in samples per unit of the limit, the
+	// latency without load is probed again, 30 by default.
+	ProbeMultiplier int
+}
+
+type vegas struct {
+	mu        sync.Mutex
+	bounds    bounds
+	smoothing float64
+	probe     int
+	limit     float64
+	rttNoLoad time.Duration
+	countdown int
+}
+
+// NewVegas returns an Algorithm estimating the queue of the server from the
+// latency without load, the lowest one, as TCP Vegas does. The limit
+// increases while the queue is short and decreases once it is long.
+func NewVegas(cfg VegasConfig) Algorithm {
+	v := &vegas{
+		bounds:    bounds{cfg.InitialLimit, cfg.MinLimit, cfg.MaxLimit},
+		smoothing: cfg.Smoothing,
+		probe:     cfg.ProbeMultiplier,
+	}
+	v.bounds.defaults(20, 1, 1000)
+	if v.smoothing <= 0 || v.smoothing > 1 {
+		v.smoothing = 1
+	}
+	if v.probe <= 0 {
+		v.probe = 30
+	}
+	v.limit = v.bounds.clamp(float64(v.bounds.initial))
+	v.countdown = v.nextProbe()
+	return v
+}
+
+// nextProbe returns the samples before the next probe, jittered so that the
+// instances of a service don't probe together.
+func (v *vegas) nextProbe() int {
+	return int(float64(v.probe) * v.limit * (0.5 + rand.Float64()))
+}
+
+func (v *vegas) Limit() int {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return int(v.limit)
+}
+
+func (v *vegas) Update(rtt time.Duration, inflight int, dropped bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.countdown--; v.countdown <= 0 {
+		// the latency without load may have changed, e.g. after a deployment
+		v.countdown = v.nextProbe()
+		v.rttNoLoad = 0
+	}
+	if v.rttNoLoad == 0 || rtt < v.rttNoLoad {
+		v.rttNoLoad = rtt
+		return
+	}
+
+	step := math.Max(1, math.Log10(v.limit))
+	var limit float64
+	if dropped {
+		limit = v.limit - step
+	} else {
+		if float64(inflight)*2 < v.limit {
+			return
+		}
+		queue := math.Ceil(v.limit * (1 - float64(v.rttNoLoad)/float64(rtt)))
+		alpha, beta := 3*step, 6*step
+		switch {
+		case queue <= step:
+			limit = v.limit + beta
+		case queue < alpha:
+			limit = v.limit + step
+		case queue > beta:
+			limit = v.limit - step
+		default:
+			return
+		}
+	}
+	limit = v.bounds.clamp(limit)
+	v.limit = v.bounds.clamp((1-v.smoothing)*v.limit + v.smoothing*limit)
+}
+
+// GradientConfig is the config of NewGradient, zero values are the defaults.
+type GradientConfig struct {
+	// InitialLimit is the limit before the first sample, 20 by default.
+	InitialLimit int
+	// MinLimit and MaxLimit bound the limit, 1 and 1000 by default.
+	MinLimit, MaxLimit int
+	// Smoothing weights the new limit against the current one, in (0, 1],
+	// 0.2 by default.
+	Smoothing float64
+	// Tolerance is the ratio of the short term latency to the long term one
+	// before the limit decreases, 1.5 by default.
+	Tolerance float64
+	// LongWindow is the number of samples of the long term latency, 600 by default.
+	LongWindow int
+}
+
+type gradient struct {
+	mu        sync.Mutex
+	bounds    bounds
+	smoothing float64
+	tolerance float64
+	alpha     float64
+	limit     float64
+	longRTT   float64
+}
+
+// NewGradient returns an Algorithm comparing the latency of each request to
+// the long term average latency: the limit decreases with their gradient
+// once the latency rises beyond Tolerance, and grows by a queue of the
+// square root of the limit otherwise.
+func NewGradient(cfg GradientConfig) Algorithm {
+	g := &gradient{
+		bounds:    bounds{cfg.InitialLimit, cfg.MinLimit, cfg.MaxLimit},
+		smoothing: cfg.Smoothing,
+		tolerance: cfg.Tolerance,
+	}
+	g.bounds.defaults(20, 1, 1000)
+	if g.smoothing <= 0 || g.smoothing > 1 {
+		g.smoothing = 0.2
+	}
+	if g.tolerance < 1 {
+		g.tolerance = 1.5
+	}
+	window := cfg.LongWindow
+	if window <= 0 {
+		window = 600
+	}
+	g.alpha = 2 / (float64(window) + 1)
+	g.limit = g.bounds.clamp(float64(g.bounds.initial))
+	return g
+}
+
+func (g *gradient) Limit() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return int(g.limit)
+}
+
+func (g *gradient) Update(rtt time.Duration, inflight int, dropped bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	short := float64(rtt)
+	if g.longRTT == 0 {
+		g.longRTT = short
+	} else {
+		g.longRTT += g.alpha * (short - g.longRTT)
+	}
+	// recover faster from a long spike of latency
+	if g.longRTT/short > 2 {
+		g.longRTT *= 0.95
+	}
+	if !dropped && float64(inflight)*2 < g.limit {
+		return
+	}
+
+	grad := 0.5
+	if !dropped && short > 0 {
+		grad = math.Max(0.5, math.Min(1, g.tolerance*g.longRTT/short))
+	}
+	limit := g.limit*grad + math.Sqrt(g.limit)
+	limit = g.bounds.clamp(limit)
+	g.limit = g.bounds.clamp((1-g.smoothing)*g.limit + g.smoothing*limit)
+}
@@ -0,0 +1,177 @@
+package limiter
+
+import (
+	"context"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/yates-z/easel/transport/internal/window"
+)
+
+// BBRConfig is the config of NewBBR, zero values are the defaults.
+type BBRConfig struct {
+	// Window is the period of the samples, 10 seconds by default.
+	Window time.Duration
+	// Buckets is the number of buckets of the window, 100 by default.
+	Buckets int
+	// CPUThreshold is the CPU usage, in [0, 1], beyond which requests are
+	// shed, 0.8 by default.
+	CPUThreshold float64
+	// CoolDown is the time requests are still shed after the CPU usage
+	// falls below CPUThreshold, 1 second by default.
+	CoolDown time.Duration
+	// CPU returns the CPU usage in [0, 1], the usage of the system by
+	// default, which is only known on Linux.
+	CPU func() float64
+}
+
+// BBR sheds the requests beyond the capacity of the server once its CPU is
+// overloaded, as the BBR congestion control does: the capacity is the
+// highest throughput by the lowest latency seen during a window.
+type BBR struct {
+	opts      *options
+	threshold float64
+	coolDown  time.Duration
+	cpu       func() float64
+	samples   *samples
+
+	inflight atomic.Int64
+	// dropped is the last time a request was shed, in nanoseconds.
+	dropped atomic.Int64
+}
+
+// NewBBR creates a BBR limiter.
+func NewBBR(cfg BBRConfig, opts ...Option) *BBR {
+	if cfg.Window <= 0 {
+		cfg.Window = 10 * time.Second
+	}
+	if cfg.Buckets <= 0 {
+		cfg.Buckets = 100
+	}
+	if cfg.CPUThreshold <= 0 {
+		cfg.CPUThreshold = 0.8
+	}
+	if cfg.CoolDown <= 0 {
+		cfg.CoolDown = time.Second
+	}
+	if cfg.CPU == nil {
+		cfg.CPU = systemCPU
+	}
+	o := newOptions(opts...)
+	return &BBR{
+		opts:      o,
+		threshold: cfg.CPUThreshold,
+		coolDown:  cfg.CoolDown,
+		cpu:       cfg.CPU,
+		samples:   newSamples(cfg.Window, cfg.Buckets, o.now),
+	}
+}
+
+// Acquire admits a request unless the server is overloaded, or returns
+// ErrLimitExceeded.
+func (l *BBR) Acquire(ctx context.Context) (ReleaseFunc, error) {
+	inflight := l.inflight.Add(1)
+	if l.shed(PriorityFromContext(ctx), inflight) {
+		l.inflight.Add(-1)
+		return nil, ErrLimitExceeded
+	}
+	start := l.opts.now()
+	var once sync.Once
+	return func(bool) {
+		once.Do(func() {
+			l.samples.add(l.opts.now().Sub(start))
+			l.inflight.Add(-1)
+		})
+	}, nil
+}
+
+// Limit admits a request and releases it once ctx is done, so that l is a
+// Limiter of the gRPC ratelimit package. A ctx never done leaks the request.
+func (l *BBR) Limit(ctx context.Context) error {
+	return limitContext(ctx, l)
+}
+
+// Inflight returns the number of in-flight requests.
+func (l *BBR) Inflight() int {
+	return int(l.inflight.Load())
+}
+
+// CurrentLimit returns the capacity of the server, the number of in-flight
+// requests admitted once the CPU is overloaded.
+func (l *BBR) CurrentLimit() int {
+	limit := l.samples.maxInflight()
+	if math.IsInf(limit, 1) {
+		return math.MaxInt
+	}
+	return int(limit)
+}
+
+func (l *BBR) shed(p Priority, inflight int64) bool {
+	if p == PriorityCritical || inflight <= 1 {
+		return false
+	}
+	now := l.opts.now().UnixNano()
+	if l.cpu() < l.threshold {
+		// keep shedding for a while, the CPU usage lags behind the load
+		dropped := l.dropped.Load()
+		if dropped == 0 || time.Duration(now-dropped) > l.coolDown {
+			return false
+		}
+		return !l.opts.admit(p, inflight, l.samples.maxInflight())
+	}
+	if l.opts.admit(p, inflight, l.samples.maxInflight()) {
+		return false
+	}
+	l.dropped.Store(now)
+	return true
+}
+
+// samples counts the requests done and their latency in a rolling window.
+type samples struct {
+	mu     sync.Mutex
+	window *window.Window[bucket]
+	now    func() time.Time
+}
+
+type bucket struct {
+	count int64
+	rtt   time.Duration
+}
+
+func newSamples(period time.Duration, n int, now func() time.Time) *samples {
+	return &samples{window: window.New[bucket](period, n), now: now}
+}
+
+func (s *samples) add(rtt time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b := s.window.Bucket(s.now())
+	b.count++
+	b.rtt += rtt
+}
+
+// maxInflight returns the highest throughput by the lowest latency of the
+// complete buckets of the window, or +Inf if there is none.
+func (s *samples) maxInflight() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var (
+		maxPass int64
+		minRTT  = time.Duration(math.MaxInt64)
+	)
+	s.window.Each(s.now(), func(b bucket, current bool) {
+		// the current bucket isn't complete
+		if b.count == 0 || current {
+			return
+		}
+		maxPass = max(maxPass, b.count)
+		minRTT = min(minRTT, b.rtt/time.Duration(b.count))
+	})
+	if maxPass == 0 {
+		return math.Inf(1)
+	}
+	// requests done per bucket, times the buckets of the lowest latency
+	return math.Ceil(float64(maxPass) * float64(minRTT) / float64(s.window.Size()))
+}
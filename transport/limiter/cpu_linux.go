@@ -0,0 +1,71 @@
+package limiter
+
+import (
+	"bufio"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// cpuInterval is the minimum interval between two samples of /proc/stat.
+const cpuInterval = 250 * time.Millisecond
+
+var cpuSampler struct {
+	mu          sync.Mutex
+	at          time.Time
+	total, idle uint64
+	usage       float64
+}
+
+// systemCPU returns the CPU usage of the system since the previous sample,
+// read from /proc/stat at most every cpuInterval.
+func systemCPU() float64 {
+	s := &cpuSampler
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if time.Since(s.at) < cpuInterval {
+		return s.usage
+	}
+	total, idle, ok := readStat()
+	if !ok {
+		return 0
+	}
+	if !s.at.IsZero() && total > s.total {
+		s.usage = 1 - float64(idle-s.idle)/float64(total-s.total)
+	}
+	s.at, s.total, s.idle = time.Now(), total, idle
+	return s.usage
+}
+
+// readStat reads the CPU times of the "cpu" line of /proc/stat.
+func readStat() (total, idle uint64, ok bool) {
+	f, err := os.Open("/proc/stat")
+	if err != nil {
+		return 0, 0, false
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	if !scanner.Scan() {
+		return 0, 0, false
+	}
+	fields := strings.Fields(scanner.Text())
+	if len(fields) < 5 || fields[0] != "cpu" {
+		return 0, 0, false
+	}
+	// user, nice, system, idle, iowait, irq, softirq and steal, the guest
+	// times are already part of user and nice
+	for i, field := range fields[1:min(len(fields), 9)] {
+		v, err := strconv.ParseUint(field, 10, 64)
+		if err != nil {
+			return 0, 0, false
+		}
+		total += v
+		// idle and iowait
+		if i == 3 || i == 4 {
+			idle += v
+		}
+	}
+	return total, idle, true
+}
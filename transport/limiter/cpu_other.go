@@ -0,0 +1,9 @@
+//go:build !linux
+
+package limiter
+
+// systemCPU returns 0, the CPU usage is only known on Linux: provide
+// BBRConfig.CPU on the other systems.
+func systemCPU() float64 {
+	return 0
+}
@@ -0,0 +1,177 @@
+// Package limiter sheds the load of servers with adaptive concurrency limits.
+//
+// Instead of a fixed rate, the limiters allow a number of in-flight requests
+// adjusted from their observed latency, so that the server stays responsive
+// when it or its dependencies slow down:
+//
+//	l := limiter.New(limiter.NewGradient(limiter.GradientConfig{}))
+//	release, err := l.Acquire(ctx)
+//	if err != nil {
+//		// shed the request
+//	}
+//	defer release(false)
+//
+// The requests of PriorityCritical, e.g. health checks, are never shed, and
+// the ones of PriorityLow are shed first, see WithPriority.
+package limiter
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var ErrLimitExceeded = errors.New("limiter: concurrency limit exceeded")
+
+// ReleaseFunc ends an admitted request. dropped reports whether it failed
+// because of the overload, e.g. it timed out, which lowers the limit.
+type ReleaseFunc func(dropped bool)
+
+// Limiter admits requests while the server isn't overloaded.
+type Limiter interface {
+	// Acquire admits a request, which must be released once done, or
+	// returns ErrLimitExceeded.
+	Acquire(ctx context.Context) (ReleaseFunc, error)
+}
+
+// Priority is the priority of a request.
+type Priority int
+
+const (
+	// PriorityNormal requests are admitted within the limit.
+	PriorityNormal Priority = iota
+	// PriorityLow requests are admitted within a share of the limit, so that
+	// they are shed before the others, see LowPriorityShare.
+	PriorityLow
+	// PriorityCritical requests are never shed, e.g. health checks.
+	PriorityCritical
+)
+
+type priorityKey struct{}
+
+// WithPriority returns a new Context carrying the priority of a request.
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+// PriorityFromContext returns the priority of a request, PriorityNormal by default.
+func PriorityFromContext(ctx context.Context) Priority {
+	p, _ := ctx.Value(priorityKey{}).(Priority)
+	return p
+}
+
+// limitContext implements the Limit method of the fixed-rate limiters of the
+// gRPC ratelimit package: the request is released once ctx is done, which
+// happens when the call ends, and dropped if its deadline was exceeded.
+func limitContext(ctx context.Context, l Limiter) error {
+	release, err := l.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	context.AfterFunc(ctx, func() {
+		release(errors.Is(ctx.Err(), context.DeadlineExceeded))
+	})
+	return nil
+}
+
+type Option func(*options)
+
+type options struct {
+	lowShare float64
+	now      func() time.Time
+}
+
+// LowPriorityShare with the share of the limit PriorityLow requests are
+// admitted within, 0.5 by default.
+func LowPriorityShare(share float64) Option {
+	return func(o *options) {
+		o.lowShare = share
+	}
+}
+
+// WithTimeFunc with the clock measuring the latency, time.Now by default.
+func WithTimeFunc(now func() time.Time) Option {
+	return func(o *options) {
+		o.now = now
+	}
+}
+
+func newOptions(opts ...Option) *options {
+	o := &options{
+		lowShare: 0.5,
+		now:      time.Now,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// admit reports whether a request of priority p is admitted with inflight
+// requests, itself included.
+func (o *options) admit(p Priority, inflight int64, limit float64) bool {
+	switch p {
+	case PriorityCritical:
+		return true
+	case PriorityLow:
+		limit *= o.lowShare
+	}
+	return float64(inflight) <= limit
+}
+
+// Algorithm adjusts a concurrency limit from the samples of the requests,
+// e.g. NewAIMD, NewVegas or NewGradient. It must be safe for concurrent use.
+type Algorithm interface {
+	// Limit returns the current number of allowed in-flight requests.
+	Limit() int
+	// Update updates the limit with the latency of a request, the number of
+	// in-flight requests when it started, and whether it was dropped.
+	Update(rtt time.Duration, inflight int, dropped bool)
+}
+
+// Adaptive limits the in-flight requests with the limit of an Algorithm.
+type Adaptive struct {
+	alg      Algorithm
+	opts     *options
+	inflight atomic.Int64
+}
+
+// New creates an Adaptive limiter.
+func New(alg Algorithm, opts ...Option) *Adaptive {
+	return &Adaptive{alg: alg, opts: newOptions(opts...)}
+}
+
+// Acquire admits a request within the limit, or returns ErrLimitExceeded.
+func (l *Adaptive) Acquire(ctx context.Context) (ReleaseFunc, error) {
+	inflight := l.inflight.Add(1)
+	if !l.opts.admit(PriorityFromContext(ctx), inflight, float64(l.alg.Limit())) {
+		l.inflight.Add(-1)
+		return nil, ErrLimitExceeded
+	}
+	start := l.opts.now()
+	var once sync.Once
+	return func(dropped bool) {
+		once.Do(func() {
+			l.alg.Update(l.opts.now().Sub(start), int(inflight), dropped)
+			l.inflight.Add(-1)
+		})
+	}, nil
+}
+
+// Limit admits a request and releases it once ctx is done, so that l is a
+// Limiter of the gRPC ratelimit package. A ctx never done leaks the request.
+func (l *Adaptive) Limit(ctx context.Context) error {
+	return limitContext(ctx, l)
+}
+
+// Inflight returns the number of in-flight requests.
+func (l *Adaptive) Inflight() int {
+	return int(l.inflight.Load())
+}
+
+// CurrentLimit returns the current limit of in-flight requests.
+func (l *Adaptive) CurrentLimit() int {
+	return l.alg.Limit()
+}
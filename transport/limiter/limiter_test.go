@@ -0,0 +1,219 @@
+package limiter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// clock is a manual clock.
+type clock struct {
+	t time.Time
+}
+
+func (c *clock) now() time.Time {
+	return c.t
+}
+
+func (c *clock) advance(d time.Duration) {
+	c.t = c.t.Add(d)
+}
+
+func TestAIMD(t *testing.T) {
+	a := NewAIMD(AIMDConfig{InitialLimit: 10, MaxLimit: 12, Timeout: time.Second})
+
+	// an idle server doesn't raise the limit
+	a.Update(time.Millisecond, 1, false)
+	if a.Limit() != 10 {
+		t.Fatalf("unexpected limit %d", a.Limit())
+	}
+	for range 5 {
+		a.Update(time.Millisecond, 10, false)
+	}
+	if a.Limit() != 12 {
+		t.Fatalf("unexpected limit %d, want the max", a.Limit())
+	}
+	a.Update(time.Millisecond, 10, true)
+	if a.Limit() != 10 {
+		t.Fatalf("unexpected limit %d after a drop", a.Limit())
+	}
+	a.Update(2*time.Second, 10, false)
+	if a.Limit() != 9 {
+		t.Fatalf("unexpected limit %d after a timeout", a.Limit())
+	}
+}
+
+func TestVegas(t *testing.T) {
+	v := NewVegas(VegasConfig{InitialLimit: 100, ProbeMultiplier: 1000})
+	v.Update(10*time.Millisecond, 100, false)
+
+	// no queue, the limit increases
+	for range 10 {
+		v.Update(10*time.Millisecond, 100, false)
+	}
+	grown := v.Limit()
+	if grown <= 100 {
+		t.Fatalf("unexpected limit %d", grown)
+	}
+	// the latency doubles, the queue is long
+	for range 10 {
+		v.Update(20*time.Millisecond, grown, false)
+	}
+	if v.Limit() >= grown {
+		t.Fatalf("unexpected limit %d, want less than %d", v.Limit(), grown)
+	}
+}
+
+func TestGradient(t *testing.T) {
+	g := NewGradient(GradientConfig{InitialLimit: 100, Smoothing: 1})
+	for range 100 {
+		g.Update(10*time.Millisecond, 100, false)
+	}
+	steady := g.Limit()
+	if steady <= 100 {
+		t.Fatalf("unexpected limit %d", steady)
+	}
+	for range 10 {
+		g.Update(100*time.Millisecond, steady, false)
+	}
+	if g.Limit() >= steady/2 {
+		t.Fatalf("unexpected limit %d, want less than %d", g.Limit(), steady/2)
+	}
+}
+
+// fixed is an Algorithm with a fixed limit, recording the samples.
+type fixed struct {
+	limit   int
+	samples []time.Duration
+	dropped int
+}
+
+func (f *fixed) Limit() int { return f.limit }
+
+func (f *fixed) Update(rtt time.Duration, _ int, dropped bool) {
+	f.samples = append(f.samples, rtt)
+	if dropped {
+		f.dropped++
+	}
+}
+
+func TestAdaptive(t *testing.T) {
+	c := &clock{t: time.Now()}
+	alg := &fixed{limit: 2}
+	l := New(alg, WithTimeFunc(c.now))
+	ctx := context.Background()
+
+	release1, err := l.Acquire(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// low priority requests are admitted within half the limit
+	if _, err := l.Acquire(WithPriority(ctx, PriorityLow)); !errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if _, err := l.Acquire(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.Acquire(ctx); !errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("unexpected error %v", err)
+	}
+	// critical requests are never shed
+	releaseCritical, err := l.Acquire(WithPriority(ctx, PriorityCritical))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.Inflight() != 3 {
+		t.Fatalf("unexpected in-flight requests %d", l.Inflight())
+	}
+
+	c.advance(50 * time.Millisecond)
+	release1(true)
+	release1(true)
+	releaseCritical(false)
+	if l.Inflight() != 1 || len(alg.samples) != 2 || alg.samples[0] != 50*time.Millisecond || alg.dropped != 1 {
+		t.Fatalf("unexpected samples %v, dropped %d, in-flight %d", alg.samples, alg.dropped, l.Inflight())
+	}
+}
+
+func TestLimit(t *testing.T) {
+	alg := &fixed{limit: 1}
+	l := New(alg)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := l.Limit(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Limit(context.Background()); !errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("unexpected error %v", err)
+	}
+	cancel()
+	deadline := time.Now().Add(time.Second)
+	for l.Inflight() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("the request isn't released once its context is done")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestBBR(t *testing.T) {
+	c := &clock{t: time.Unix(1000, 0)}
+	cpu := 0.0
+	l := NewBBR(BBRConfig{
+		Window:   time.Second,
+		Buckets:  10,
+		CoolDown: time.Second,
+		CPU:      func() float64 { return cpu },
+	}, WithTimeFunc(c.now))
+	ctx := context.Background()
+
+	// 10 requests of 100ms per bucket of 100ms: the capacity is 10
+	for range 5 {
+		var releases []ReleaseFunc
+		for range 10 {
+			release, err := l.Acquire(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			releases = append(releases, release)
+		}
+		c.advance(100 * time.Millisecond)
+		for _, release := range releases {
+			release(false)
+		}
+	}
+	c.advance(100 * time.Millisecond)
+	if l.CurrentLimit() != 10 {
+		t.Fatalf("unexpected limit %d", l.CurrentLimit())
+	}
+	// the CPU isn't overloaded, nothing is shed
+	for range 20 {
+		if _, err := l.Acquire(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cpu = 0.9
+	if _, err := l.Acquire(ctx); !errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if _, err := l.Acquire(WithPriority(ctx, PriorityCritical)); err != nil {
+		t.Fatal(err)
+	}
+	// still shed during the cool down
+	cpu = 0.1
+	if _, err := l.Acquire(ctx); !errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("unexpected error %v", err)
+	}
+	c.advance(2 * time.Second)
+	if _, err := l.Acquire(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSystemCPU(t *testing.T) {
+	if usage := systemCPU(); usage < 0 || usage > 1 {
+		t.Fatalf("unexpected usage %f", usage)
+	}
+}
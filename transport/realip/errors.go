@@ -0,0 +1,8 @@
+package realip
+
+import "errors"
+
+var (
+	ErrInvalidProxyHeader = errors.New("realip: invalid PROXY protocol header")
+	ErrMissingProxyHeader = errors.New("realip: missing PROXY protocol header")
+)
@@ -0,0 +1,89 @@
+package realip
+
+import "strings"
+
+// splitList splits the comma separated values of a header,
+// e.g. X-Forwarded-For: 203.0.113.195, 70.41.3.18.
+func splitList(values []string) []string {
+	var items []string
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+	}
+	return items
+}
+
+// forwardedFor returns the "for" parameters of the Forwarded header of
+// RFC 7239, e.g. Forwarded: for=192.0.2.60;proto=http, for="[2001:db8::17]:4711".
+// An element without "for" is returned as an empty hop.
+func forwardedFor(values []string) []string {
+	var hops []string
+	for _, value := range values {
+		for _, element := range splitElements(value) {
+			var hop string
+			for _, pair := range splitQuoted(element, ';') {
+				name, v, ok := strings.Cut(strings.TrimSpace(pair), "=")
+				if ok && strings.EqualFold(strings.TrimSpace(name), "for") {
+					hop = unquote(strings.TrimSpace(v))
+					break
+				}
+			}
+			hops = append(hops, hop)
+		}
+	}
+	return hops
+}
+
+// splitElements splits the comma separated elements of a Forwarded header.
+func splitElements(value string) []string {
+	var elements []string
+	for _, element := range splitQuoted(value, ',') {
+		if strings.TrimSpace(element) != "" {
+			elements = append(elements, element)
+		}
+	}
+	return elements
+}
+
+// splitQuoted splits s by sep outside of quoted strings.
+func splitQuoted(s string, sep byte) []string {
+	var (
+		parts  []string
+		quoted bool
+		start  int
+	)
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && quoted:
+			i++
+		case s[i] == '"':
+			quoted = !quoted
+		case s[i] == sep && !quoted:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// unquote removes the quotes of a quoted string and its escapes.
+func unquote(s string) string {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return s
+	}
+	s = s[1 : len(s)-1]
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
@@ -0,0 +1,232 @@
+package realip
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// v1Prefix starts the header of the version 1 of the PROXY protocol.
+	v1Prefix = []byte("PROXY ")
+	// v2Signature starts the header of the version 2 of the PROXY protocol.
+	v2Signature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+)
+
+// v1MaxLength is the maximum length of a version 1 header, CRLF included.
+const v1MaxLength = 107
+
+// Listener returns a listener accepting the PROXY protocol, version 1 and 2,
+// on the connections of trusted proxies. The RemoteAddr and LocalAddr of
+// their connections are the addresses of the header, if any.
+//
+// The header is read by the first call to Read, RemoteAddr or LocalAddr of
+// a connection, not by Accept, so a slow proxy doesn't block the listener.
+// The header sent from an untrusted address is left unread, so that the
+// server fails to parse the request.
+func (r *Resolver) Listener(l net.Listener) net.Listener {
+	return &proxyListener{Listener: l, resolver: r}
+}
+
+type proxyListener struct {
+	net.Listener
+	resolver *Resolver
+}
+
+func (l *proxyListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return &proxyConn{Conn: conn, resolver: l.resolver, reader: bufio.NewReader(conn)}, nil
+}
+
+// proxyConn is a connection starting with a PROXY protocol header.
+type proxyConn struct {
+	net.Conn
+	resolver *Resolver
+	reader   *bufio.Reader
+
+	once   sync.Once
+	err    error
+	remote net.Addr
+	local  net.Addr
+}
+
+func (c *proxyConn) init() {
+	c.once.Do(func() {
+		addr, ok := addrOf(c.Conn.RemoteAddr())
+		if !ok || !c.resolver.Trusted(addr) {
+			return
+		}
+		if c.resolver.headerTimeout > 0 {
+			_ = c.Conn.SetReadDeadline(time.Now().Add(c.resolver.headerTimeout))
+			defer func() { _ = c.Conn.SetReadDeadline(time.Time{}) }()
+		}
+		c.err = c.readHeader()
+	})
+}
+
+func (c *proxyConn) Read(b []byte) (int, error) {
+	c.init()
+	if c.err != nil {
+		return 0, c.err
+	}
+	return c.reader.Read(b)
+}
+
+func (c *proxyConn) RemoteAddr() net.Addr {
+	c.init()
+	if c.remote != nil {
+		return c.remote
+	}
+	return c.Conn.RemoteAddr()
+}
+
+func (c *proxyConn) LocalAddr() net.Addr {
+	c.init()
+	if c.local != nil {
+		return c.local
+	}
+	return c.Conn.LocalAddr()
+}
+
+func (c *proxyConn) readHeader() error {
+	first, err := c.reader.Peek(1)
+	if err != nil {
+		return err
+	}
+	switch first[0] {
+	case v1Prefix[0]:
+		if prefix, err := c.reader.Peek(len(v1Prefix)); err == nil && bytes.Equal(prefix, v1Prefix) {
+			return c.readV1()
+		}
+	case v2Signature[0]:
+		if signature, err := c.reader.Peek(len(v2Signature)); err == nil && bytes.Equal(signature, v2Signature) {
+			return c.readV2()
+		}
+	}
+	if c.resolver.requireHeader {
+		return ErrMissingProxyHeader
+	}
+	return nil
+}
+
+// readV1 reads a version 1 header, e.g. "PROXY TCP4 192.0.2.1 192.0.2.2 56324 443\r\n".
+func (c *proxyConn) readV1() error {
+	var line []byte
+	for len(line) < v1MaxLength {
+		b, err := c.reader.ReadByte()
+		if err != nil {
+			return err
+		}
+		line = append(line, b)
+		if b == '\n' {
+			break
+		}
+	}
+	header, ok := strings.CutSuffix(string(line), "\r\n")
+	if !ok {
+		return ErrInvalidProxyHeader
+	}
+
+	fields := strings.Split(header, " ")
+	if len(fields) < 2 {
+		return ErrInvalidProxyHeader
+	}
+	switch fields[1] {
+	case "UNKNOWN":
+		// the proxy doesn't know the addresses, the rest is ignored
+		return nil
+	case "TCP4", "TCP6":
+	default:
+		return ErrInvalidProxyHeader
+	}
+	if len(fields) != 6 {
+		return ErrInvalidProxyHeader
+	}
+	src, err1 := parseAddrPort(fields[2], fields[4])
+	dst, err2 := parseAddrPort(fields[3], fields[5])
+	if err1 != nil || err2 != nil || src.Addr().Is4() != (fields[1] == "TCP4") {
+		return ErrInvalidProxyHeader
+	}
+	c.remote, c.local = net.TCPAddrFromAddrPort(src), net.TCPAddrFromAddrPort(dst)
+	return nil
+}
+
+func parseAddrPort(ip, port string) (netip.AddrPort, error) {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return netip.AddrPort{}, err
+	}
+	p, err := strconv.ParseUint(port, 10, 16)
+	if err != nil {
+		return netip.AddrPort{}, err
+	}
+	return netip.AddrPortFrom(addr, uint16(p)), nil
+}
+
+// readV2 reads a version 2 header: the signature, the version and command,
+// the address family and transport protocol, the length of the addresses,
+// and the addresses followed by optional TLVs, which are ignored.
+func (c *proxyConn) readV2() error {
+	header := make([]byte, len(v2Signature)+4)
+	if _, err := io.ReadFull(c.reader, header); err != nil {
+		return err
+	}
+	verCmd, family := header[12], header[13]
+	length := int(binary.BigEndian.Uint16(header[14:]))
+	if verCmd>>4 != 2 {
+		return ErrInvalidProxyHeader
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.reader, payload); err != nil {
+		return err
+	}
+
+	switch verCmd & 0x0f {
+	case 0x0:
+		// LOCAL, e.g. a health check of the proxy itself
+		return nil
+	case 0x1:
+	default:
+		return ErrInvalidProxyHeader
+	}
+
+	var ipLen int
+	switch family >> 4 {
+	case 0x1:
+		ipLen = net.IPv4len
+	case 0x2:
+		ipLen = net.IPv6len
+	default:
+		// AF_UNSPEC or AF_UNIX, the addresses are kept
+		return nil
+	}
+	if len(payload) < 2*ipLen+4 {
+		return ErrInvalidProxyHeader
+	}
+	src, _ := netip.AddrFromSlice(payload[:ipLen])
+	dst, _ := netip.AddrFromSlice(payload[ipLen : 2*ipLen])
+	srcPort := binary.BigEndian.Uint16(payload[2*ipLen:])
+	dstPort := binary.BigEndian.Uint16(payload[2*ipLen+2:])
+
+	switch family & 0x0f {
+	case 0x1:
+		c.remote = net.TCPAddrFromAddrPort(netip.AddrPortFrom(src, srcPort))
+		c.local = net.TCPAddrFromAddrPort(netip.AddrPortFrom(dst, dstPort))
+	case 0x2:
+		c.remote = net.UDPAddrFromAddrPort(netip.AddrPortFrom(src, srcPort))
+		c.local = net.UDPAddrFromAddrPort(netip.AddrPortFrom(dst, dstPort))
+	default:
+		return ErrInvalidProxyHeader
+	}
+	return nil
+}
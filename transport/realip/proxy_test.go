@@ -0,0 +1,163 @@
+package realip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+// serve accepts a connection sending data, and returns its addresses and
+// what the server read from it.
+func serve(t *testing.T, r *Resolver, data []byte) (remote, local net.Addr, read []byte, err error) {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	go func() {
+		conn, err := net.Dial("tcp", l.Addr().String())
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		_, _ = conn.Write(data)
+	}()
+
+	conn, err := r.Listener(l).Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	remote, local = conn.RemoteAddr(), conn.LocalAddr()
+	read, err = io.ReadAll(conn)
+	return remote, local, read, err
+}
+
+func v2Header(cmd, family byte, addrs []byte) []byte {
+	header := append([]byte{}, v2Signature...)
+	header = append(header, 0x20|cmd, family)
+	header = binary.BigEndian.AppendUint16(header, uint16(len(addrs)))
+	return append(header, addrs...)
+}
+
+func TestListener(t *testing.T) {
+	trusted, err := NewResolver(TrustedProxies("127.0.0.1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	v4 := append(netip.MustParseAddr("198.51.100.1").AsSlice(), netip.MustParseAddr("192.0.2.1").AsSlice()...)
+	v4 = binary.BigEndian.AppendUint16(v4, 56324)
+	v4 = binary.BigEndian.AppendUint16(v4, 443)
+	v6 := append(netip.MustParseAddr("2001:db8::1").AsSlice(), netip.MustParseAddr("2001:db8::2").AsSlice()...)
+	v6 = binary.BigEndian.AppendUint16(v6, 56324)
+	v6 = binary.BigEndian.AppendUint16(v6, 443)
+	// a TLV following the addresses
+	v6 = append(v6, 0x01, 0x00, 0x02, 'h', '2')
+
+	for _, tc := range []struct {
+		name   string
+		header []byte
+		remote string
+		local  string
+	}{
+		{"v1 tcp4", []byte("PROXY TCP4 198.51.100.1 192.0.2.1 56324 443\r\n"), "198.51.100.1:56324", "192.0.2.1:443"},
+		{"v1 tcp6", []byte("PROXY TCP6 2001:db8::1 2001:db8::2 56324 443\r\n"), "[2001:db8::1]:56324", "[2001:db8::2]:443"},
+		{"v1 unknown", []byte("PROXY UNKNOWN\r\n"), "", ""},
+		{"v2 tcp4", v2Header(0x1, 0x11, v4), "198.51.100.1:56324", "192.0.2.1:443"},
+		{"v2 tcp6", v2Header(0x1, 0x21, v6), "[2001:db8::1]:56324", "[2001:db8::2]:443"},
+		{"v2 local", v2Header(0x0, 0x00, nil), "", ""},
+		{"no header", nil, "", ""},
+	} {
+		remote, local, read, err := serve(t, trusted, append(tc.header, "GET / HTTP/1.1\r\n"...))
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if string(read) != "GET / HTTP/1.1\r\n" {
+			t.Fatalf("%s: unexpected data %q", tc.name, read)
+		}
+		gotRemote, gotLocal := remote.String(), local.String()
+		if tc.remote == "" {
+			// the addresses of the connection are kept
+			tc.remote, tc.local = "127.0.0.1", "127.0.0.1"
+			gotRemote, _, _ = net.SplitHostPort(gotRemote)
+			gotLocal, _, _ = net.SplitHostPort(gotLocal)
+		}
+		if gotRemote != tc.remote || gotLocal != tc.local {
+			t.Fatalf("%s: unexpected addresses %s, %s", tc.name, remote, local)
+		}
+	}
+}
+
+func TestListenerErrors(t *testing.T) {
+	trusted, err := NewResolver(TrustedProxies("127.0.0.1"), RequireProxyHeader())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		name string
+		data []byte
+		err  error
+	}{
+		{"missing", []byte("GET / HTTP/1.1\r\n"), ErrMissingProxyHeader},
+		{"invalid v1", []byte("PROXY TCP4 198.51.100.1 56324 443\r\n"), ErrInvalidProxyHeader},
+		{"mismatched family", []byte("PROXY TCP4 2001:db8::1 2001:db8::2 56324 443\r\n"), ErrInvalidProxyHeader},
+		{"too long v1", append([]byte("PROXY TCP4 "), bytes.Repeat([]byte{'1'}, 200)...), ErrInvalidProxyHeader},
+		{"invalid v2 version", append(bytes.Clone(v2Signature), 0x11, 0x11, 0x00, 0x00), ErrInvalidProxyHeader},
+		{"short v2 addresses", v2Header(0x1, 0x11, []byte{1, 2, 3}), ErrInvalidProxyHeader},
+	} {
+		_, _, _, err := serve(t, trusted, tc.data)
+		if !errors.Is(err, tc.err) {
+			t.Fatalf("%s: unexpected error %v", tc.name, err)
+		}
+	}
+}
+
+func TestListenerUntrusted(t *testing.T) {
+	r, err := NewResolver(TrustedProxies("10.0.0.0/8"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := []byte("PROXY TCP4 198.51.100.1 192.0.2.1 56324 443\r\n")
+	remote, _, read, err := serve(t, r, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the header of an untrusted peer is left to the server
+	if !bytes.Equal(read, data) || remote.(*net.TCPAddr).IP.String() != "127.0.0.1" {
+		t.Fatalf("unexpected connection from %s: %q", remote, read)
+	}
+}
+
+func TestListenerTimeout(t *testing.T) {
+	r, err := NewResolver(TrustedProxies("127.0.0.1"), ProxyHeaderTimeout(50*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	client, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	conn, err := r.Listener(l).Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	var netErr net.Error
+	if _, err := conn.Read(make([]byte, 1)); !errors.As(err, &netErr) || !netErr.Timeout() {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
@@ -0,0 +1,228 @@
+// Package realip resolves the IP of the clients of servers running behind
+// proxies or load balancers.
+//
+// The headers set by proxies, e.g. X-Forwarded-For, are only trusted when
+// the request comes from a trusted proxy, and their addresses are walked
+// from the right, the last hop, to the first one which isn't a trusted proxy:
+//
+//	r, err := realip.NewResolver(realip.TrustedProxies("10.0.0.0/8"))
+//	ip := r.FromRequest(req)
+//
+// Only X-Forwarded-For is read by default. The trusted proxies must set or
+// overwrite the headers read, or append to X-Forwarded-For, otherwise
+// clients spoof their IP by sending them.
+//
+// Listener accepts the PROXY protocol of load balancers working at the
+// transport layer, e.g. HAProxy or AWS NLB.
+package realip
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"time"
+)
+
+const (
+	HeaderForwarded     = "Forwarded"
+	HeaderXForwardedFor = "X-Forwarded-For"
+	HeaderXRealIP       = "X-Real-IP"
+)
+
+type Option func(*options)
+
+type options struct {
+	trusted       []string
+	headers       []string
+	headerTimeout time.Duration
+	requireHeader bool
+}
+
+// TrustedProxies with the addresses of the trusted proxies, as CIDR ranges,
+// e.g. "10.0.0.0/8", or IPs. No proxy is trusted by default.
+func TrustedProxies(cidrs ...string) Option {
+	return func(o *options) {
+		o.trusted = append(o.trusted, cidrs...)
+	}
+}
+
+// Headers with the headers carrying the client IP, checked in order,
+// X-Forwarded-For by default. Only read the headers the trusted proxies set
+// or overwrite, e.g. Forwarded or X-Real-IP, which clients send too.
+func Headers(names ...string) Option {
+	return func(o *options) {
+		o.headers = names
+	}
+}
+
+// ProxyHeaderTimeout with the time the Listener waits for the PROXY protocol
+// header of a connection, 10 seconds by default. Zero means no timeout.
+func ProxyHeaderTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.headerTimeout = d
+	}
+}
+
+// RequireProxyHeader closes the connections of trusted proxies sent without
+// a PROXY protocol header by the Listener, which are served as they are
+// by default.
+func RequireProxyHeader() Option {
+	return func(o *options) {
+		o.requireHeader = true
+	}
+}
+
+// Resolver resolves the IP of clients.
+type Resolver struct {
+	trusted       []netip.Prefix
+	headers       []string
+	headerTimeout time.Duration
+	requireHeader bool
+}
+
+// NewResolver creates a Resolver. It returns an error if a trusted proxy
+// is neither a CIDR range nor an IP.
+func NewResolver(opts ...Option) (*Resolver, error) {
+	o := &options{
+		headers:       []string{HeaderXForwardedFor},
+		headerTimeout: 10 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	r := &Resolver{
+		headers:       o.headers,
+		headerTimeout: o.headerTimeout,
+		requireHeader: o.requireHeader,
+	}
+	for _, cidr := range o.trusted {
+		prefix, err := parsePrefix(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("realip: invalid trusted proxy %q: %w", cidr, err)
+		}
+		r.trusted = append(r.trusted, prefix)
+	}
+	return r, nil
+}
+
+func parsePrefix(cidr string) (netip.Prefix, error) {
+	if strings.Contains(cidr, "/") {
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		return prefix.Masked(), nil
+	}
+	addr, err := netip.ParseAddr(cidr)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// Trusted reports whether addr is a trusted proxy.
+func (r *Resolver) Trusted(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, prefix := range r.trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// Resolve returns the IP of the client of a request sent from remoteAddr,
+// "host:port" or "host", with the values of its headers returned by values.
+// It returns an invalid netip.Addr if remoteAddr isn't an IP.
+func (r *Resolver) Resolve(remoteAddr string, values func(name string) []string) netip.Addr {
+	remote, ok := parseAddr(remoteAddr)
+	if !ok || !r.Trusted(remote) {
+		return remote
+	}
+	for _, name := range r.headers {
+		var hops []string
+		if strings.EqualFold(name, HeaderForwarded) {
+			hops = forwardedFor(values(name))
+		} else {
+			hops = splitList(values(name))
+		}
+		if client, ok := r.client(hops); ok {
+			return client
+		}
+	}
+	return remote
+}
+
+// FromRequest returns the IP of the client of an HTTP request.
+func (r *Resolver) FromRequest(req *http.Request) netip.Addr {
+	return r.Resolve(req.RemoteAddr, req.Header.Values)
+}
+
+// client returns the first hop from the right which isn't a trusted proxy,
+// or the leftmost one if all of them are. It returns false if there is no
+// hop or a hop to walk isn't an IP, e.g. the obfuscated "unknown".
+func (r *Resolver) client(hops []string) (netip.Addr, bool) {
+	var addr netip.Addr
+	for i := len(hops) - 1; i >= 0; i-- {
+		var ok bool
+		if addr, ok = parseAddr(hops[i]); !ok {
+			return netip.Addr{}, false
+		}
+		if !r.Trusted(addr) {
+			return addr, true
+		}
+	}
+	return addr, addr.IsValid()
+}
+
+// parseAddr parses an IP, optionally with a port and in brackets,
+// e.g. "192.0.2.1", "192.0.2.1:80", "[2001:db8::1]:80" or "2001:db8::1".
+func parseAddr(s string) (netip.Addr, bool) {
+	s = strings.TrimSpace(s)
+	if addr, err := netip.ParseAddr(s); err == nil {
+		return addr.Unmap().WithZone(""), true
+	}
+	if addrPort, err := netip.ParseAddrPort(s); err == nil {
+		return addrPort.Addr().Unmap().WithZone(""), true
+	}
+	if strings.HasPrefix(s, "[") && strings.HasSuffix(s, "]") {
+		if addr, err := netip.ParseAddr(s[1 : len(s)-1]); err == nil {
+			return addr.Unmap().WithZone(""), true
+		}
+	}
+	return netip.Addr{}, false
+}
+
+// addrOf returns the IP of a net.Addr.
+func addrOf(addr net.Addr) (netip.Addr, bool) {
+	switch addr := addr.(type) {
+	case *net.TCPAddr:
+		ip, ok := netip.AddrFromSlice(addr.IP)
+		return ip.Unmap(), ok
+	case *net.UDPAddr:
+		ip, ok := netip.AddrFromSlice(addr.IP)
+		return ip.Unmap(), ok
+	case nil:
+		return netip.Addr{}, false
+	}
+	return parseAddr(addr.String())
+}
+
+type addrKey struct{}
+
+// NewContext returns a new Context that carries the IP of the client.
+func NewContext(ctx context.Context, addr netip.Addr) context.Context {
+	return context.WithValue(ctx, addrKey{}, addr)
+}
+
+// FromContext returns the IP of the client put into ctx, e.g. by the HTTP
+// server or the gRPC interceptors.
+func FromContext(ctx context.Context) (netip.Addr, bool) {
+	addr, ok := ctx.Value(addrKey{}).(netip.Addr)
+	return addr, ok && addr.IsValid()
+}
@@ -0,0 +1,115 @@
+package realip
+
+import (
+	"context"
+	"net/http"
+	"net/netip"
+	"testing"
+)
+
+func TestNewResolver(t *testing.T) {
+	if _, err := NewResolver(TrustedProxies("10.0.0.0/8", "192.0.2.1", "2001:db8::/32")); err != nil {
+		t.Fatal(err)
+	}
+	for _, cidr := range []string{"10.0.0.0/33", "not-an-ip", ""} {
+		if _, err := NewResolver(TrustedProxies(cidr)); err == nil {
+			t.Fatalf("%q: expected an error", cidr)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	r, err := NewResolver(TrustedProxies("10.0.0.0/8", "2001:db8::/32"), Headers(HeaderForwarded, HeaderXForwardedFor, HeaderXRealIP))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name   string
+		remote string
+		header http.Header
+		want   string
+	}{
+		{"no header", "203.0.113.1:1234", nil, "203.0.113.1"},
+		{"untrusted remote", "203.0.113.1:1234", http.Header{"X-Forwarded-For": {"198.51.100.1"}}, "203.0.113.1"},
+		{"x-forwarded-for", "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"198.51.100.1"}}, "198.51.100.1"},
+		{"spoofed hop", "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"1.1.1.1, 198.51.100.1, 10.0.0.2"}}, "198.51.100.1"},
+		{"several values", "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"198.51.100.1", "10.0.0.2"}}, "198.51.100.1"},
+		{"all trusted", "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"10.0.0.3, 10.0.0.2"}}, "10.0.0.3"},
+		{"invalid hop", "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"198.51.100.1, garbage"}}, "10.0.0.1"},
+		{"ignored invalid hop", "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"garbage, 198.51.100.1"}}, "198.51.100.1"},
+		{"x-real-ip", "10.0.0.1:1234", http.Header{"X-Real-Ip": {"198.51.100.1"}}, "198.51.100.1"},
+		{"forwarded", "10.0.0.1:1234", http.Header{"Forwarded": {`for=198.51.100.1;proto=https, for="10.0.0.2:80"`}}, "198.51.100.1"},
+		{"forwarded ipv6", "[2001:db8::1]:1234", http.Header{"Forwarded": {`For="[2001:db8:cafe::17]:4711"`}}, "2001:db8:cafe::17"},
+		{"forwarded unknown", "10.0.0.1:1234", http.Header{"Forwarded": {"for=unknown"}}, "10.0.0.1"},
+		{"forwarded first", "10.0.0.1:1234", http.Header{"Forwarded": {"for=198.51.100.1"}, "X-Forwarded-For": {"198.51.100.2"}}, "198.51.100.1"},
+		{"ipv4-mapped", "[::ffff:10.0.0.1]:1234", http.Header{"X-Forwarded-For": {"::ffff:198.51.100.1"}}, "198.51.100.1"},
+		{"no port", "10.0.0.1", http.Header{"X-Forwarded-For": {"198.51.100.1:8080"}}, "198.51.100.1"},
+	} {
+		req := &http.Request{RemoteAddr: tc.remote, Header: tc.header}
+		if req.Header == nil {
+			req.Header = http.Header{}
+		}
+		if got := r.FromRequest(req); got.String() != tc.want {
+			t.Fatalf("%s: got %s, want %s", tc.name, got, tc.want)
+		}
+	}
+
+	if got := r.Resolve("pipe", nil); got.IsValid() {
+		t.Fatalf("unexpected IP %s", got)
+	}
+}
+
+func TestHeaders(t *testing.T) {
+	r, err := NewResolver(TrustedProxies("10.0.0.0/8"), Headers("CF-Connecting-IP"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &http.Request{RemoteAddr: "10.0.0.1:1234", Header: http.Header{
+		"Cf-Connecting-Ip": {"198.51.100.1"},
+		"X-Forwarded-For":  {"198.51.100.2"},
+	}}
+	if got := r.FromRequest(req); got.String() != "198.51.100.1" {
+		t.Fatalf("unexpected IP %s", got)
+	}
+}
+
+func TestSpoofedHeaders(t *testing.T) {
+	r, err := NewResolver(TrustedProxies("10.0.0.0/8"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the proxy appends to X-Forwarded-For, but passes the other headers sent
+	// by the client.
+	req := &http.Request{RemoteAddr: "10.0.0.1:1234", Header: http.Header{
+		"Forwarded":       {"for=1.1.1.1"},
+		"X-Real-Ip":       {"1.1.1.1"},
+		"X-Forwarded-For": {"1.1.1.1, 198.51.100.1"},
+	}}
+	if got := r.FromRequest(req); got.String() != "198.51.100.1" {
+		t.Fatalf("spoofed IP %s", got)
+	}
+}
+
+func TestForwardedFor(t *testing.T) {
+	hops := forwardedFor([]string{`for="_gazonk", For="[2001:db8:cafe::17]:4711"`, `proto=http;by=203.0.113.43, for=192.0.2.60;host="a,b"`})
+	want := []string{"_gazonk", "[2001:db8:cafe::17]:4711", "", "192.0.2.60"}
+	if len(hops) != len(want) {
+		t.Fatalf("unexpected hops %q", hops)
+	}
+	for i := range want {
+		if hops[i] != want[i] {
+			t.Fatalf("unexpected hops %q", hops)
+		}
+	}
+}
+
+func TestContext(t *testing.T) {
+	if _, ok := FromContext(context.Background()); ok {
+		t.Fatal("unexpected IP")
+	}
+	ip := netip.MustParseAddr("198.51.100.1")
+	if got, ok := FromContext(NewContext(context.Background(), ip)); !ok || got != ip {
+		t.Fatalf("unexpected IP %s", got)
+	}
+}
@@ -77,3 +77,42 @@ func TestAESGCM(t *testing.T) {
 	}
 	fmt.Printf("Decrypted: %s\n", decrypted)
 }
+
+func TestKeyring(t *testing.T) {
+	oldKey, newKey := generateKey("old-key"), generateKey("new-key")
+	old, err := NewKeyring(oldKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := old.Seal([]byte("hello"), []byte("session"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rotated, err := NewKeyring(newKey, oldKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rotated.IsPrimary(sealed) || !old.IsPrimary(sealed) {
+		t.Fatal("unexpected primary key")
+	}
+	plaintext, err := rotated.Open(sealed, []byte("session"))
+	if err != nil || string(plaintext) != "hello" {
+		t.Fatalf("unexpected plaintext %q, error %v", plaintext, err)
+	}
+	if _, err := rotated.Open(sealed, []byte("other")); err != ErrInvalidCiphertext {
+		t.Fatalf("additional data not authenticated: %v", err)
+	}
+	sealed[len(sealed)-1] ^= 1
+	if _, err := rotated.Open(sealed, []byte("session")); err != ErrInvalidCiphertext {
+		t.Fatalf("tampered ciphertext opened: %v", err)
+	}
+
+	sealed, _ = rotated.Seal([]byte("hello"), nil)
+	if _, err := old.Open(sealed, nil); err != ErrUnknownKey {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if _, err := NewKeyring([]byte("short")); err == nil {
+		t.Fatal("invalid key accepted")
+	}
+}
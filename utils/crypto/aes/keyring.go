@@ -0,0 +1,102 @@
+package aes
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"io"
+)
+
+var (
+	ErrNoKeys            = errors.New("aes: keyring has no keys")
+	ErrInvalidCiphertext = errors.New("aes: invalid ciphertext")
+	ErrUnknownKey        = errors.New("aes: ciphertext sealed by an unknown key")
+)
+
+// keyIDSize is the size of the key ID prefixing sealed data.
+const keyIDSize = 4
+
+type keyringKey struct {
+	id   []byte
+	aead cipher.AEAD
+}
+
+// Keyring seals data with AES-GCM under its primary key and opens data sealed
+// under any of its keys, which allows keys to be rotated: make the new key
+// primary and keep the old ones until the data they sealed has expired.
+type Keyring struct {
+	keys []keyringKey
+}
+
+// NewKeyring creates a Keyring sealing with primary. Keys must be 16, 24 or
+// 32 bytes long, selecting AES-128, AES-192 or AES-256.
+func NewKeyring(primary []byte, previous ...[]byte) (*Keyring, error) {
+	k := &Keyring{}
+	for _, key := range append([][]byte{primary}, previous...) {
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		k.keys = append(k.keys, keyringKey{id: keyID(key), aead: aead})
+	}
+	return k, nil
+}
+
+// keyID identifies a key by the first bytes of its hash.
+func keyID(key []byte) []byte {
+	sum := sha256.Sum256(key)
+	return sum[:keyIDSize]
+}
+
+// Seal encrypts and authenticates plaintext and additionalData under the
+// primary key. The result is the key ID, the nonce and the ciphertext.
+func (k *Keyring) Seal(plaintext, additionalData []byte) ([]byte, error) {
+	if len(k.keys) == 0 {
+		return nil, ErrNoKeys
+	}
+	key := k.keys[0]
+	nonceSize := key.aead.NonceSize()
+	out := make([]byte, keyIDSize+nonceSize, keyIDSize+nonceSize+len(plaintext)+key.aead.Overhead())
+	copy(out, key.id)
+	nonce := out[keyIDSize:]
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return key.aead.Seal(out, nonce, plaintext, additionalData), nil
+}
+
+// Open decrypts data sealed by Seal under any key of the keyring.
+func (k *Keyring) Open(sealed, additionalData []byte) ([]byte, error) {
+	if len(sealed) < keyIDSize {
+		return nil, ErrInvalidCiphertext
+	}
+	for _, key := range k.keys {
+		if !bytes.Equal(key.id, sealed[:keyIDSize]) {
+			continue
+		}
+		data := sealed[keyIDSize:]
+		if len(data) < key.aead.NonceSize() {
+			return nil, ErrInvalidCiphertext
+		}
+		nonce, ciphertext := data[:key.aead.NonceSize()], data[key.aead.NonceSize():]
+		plaintext, err := key.aead.Open(nil, nonce, ciphertext, additionalData)
+		if err != nil {
+			return nil, ErrInvalidCiphertext
+		}
+		return plaintext, nil
+	}
+	return nil, ErrUnknownKey
+}
+
+// IsPrimary reports whether sealed was sealed under the primary key, data
+// sealed under an older key should be sealed again.
+func (k *Keyring) IsPrimary(sealed []byte) bool {
+	return len(k.keys) > 0 && len(sealed) >= keyIDSize && bytes.Equal(k.keys[0].id, sealed[:keyIDSize])
+}
@@ -1,23 +1,49 @@
 package argon2
 
 import (
-	"bytes"
 	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
 	"runtime"
+	"strings"
 
 	"golang.org/x/crypto/argon2"
 )
 
+var (
+	ErrInvalidHash         = errors.New("argon2: invalid hash")
+	ErrIncompatibleVersion = errors.New("argon2: incompatible version")
+	ErrUnsupportedHash     = errors.New("argon2: unsupported hash")
+)
+
+// Params are the cost parameters of argon2id. They are encoded in the hashes,
+// so that changing them doesn't invalidate the existing hashes.
+type Params struct {
+	// Memory in KiB.
+	Memory uint32
+	// Time is the number of passes over the memory.
+	Time uint32
+	// Threads is the degree of parallelism.
+	Threads uint8
+	// SaltLen is the length of the random salt in bytes.
+	SaltLen uint32
+	// KeyLen is the length of the hash in bytes.
+	KeyLen uint32
+}
+
+// DefaultParams are the parameters of HashPassword.
+var DefaultParams = Params{Memory: 64 * 1024, Time: 3, Threads: 4, SaltLen: 16, KeyLen: 32}
+
 const (
-	memory  = 64 * 1024
-	time    = 3
-	keyLen  = 32
-	saltLen = 16
+	legacySaltLen = 16
+	legacyKeyLen  = 32
 )
 
-var threads = uint8(runtime.NumCPU())
+var b64 = base64.RawStdEncoding
 
-func generateSalt(length int) ([]byte, error) {
+func generateSalt(length uint32) ([]byte, error) {
 	salt := make([]byte, length)
 	if _, err := rand.Read(salt); err != nil {
 		return nil, err
@@ -25,20 +51,112 @@ func generateSalt(length int) ([]byte, error) {
 	return salt, nil
 }
 
+// Hash hashes password with argon2id, and returns it in the PHC string
+// format: $argon2id$v=19$m=65536,t=3,p=4$<salt>$<hash>.
+func Hash(password []byte, params Params) (string, error) {
+	salt, err := generateSalt(params.SaltLen)
+	if err != nil {
+		return "", err
+	}
+	key := argon2.IDKey(password, salt, params.Time, params.Memory, params.Threads, params.KeyLen)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, params.Memory, params.Time, params.Threads, b64.EncodeToString(salt), b64.EncodeToString(key)), nil
+}
+
+// HashPassword hashes password with the DefaultParams, see Hash.
 func HashPassword(password []byte) ([]byte, error) {
-	salt, err := generateSalt(saltLen)
+	hash, err := Hash(password, DefaultParams)
 	if err != nil {
 		return nil, err
 	}
-
-	hash := argon2.IDKey(password, salt, time, memory, threads, keyLen)
-	hashWithSalt := append(salt, hash...)
-	return hashWithSalt, nil
+	return []byte(hash), nil
 }
 
+// VerifyPassword reports whether password matches hash, which is a PHC
+// string or a hash of the former raw salt||hash format.
 func VerifyPassword(password, hash []byte) bool {
-	salt := hash[:saltLen]
-	expectedHash := hash[saltLen:]
-	newHash := argon2.IDKey([]byte(password), salt, time, memory, threads, keyLen)
-	return bytes.Equal(newHash, expectedHash)
+	ok, err := Verify(password, string(hash))
+	return ok && err == nil
+}
+
+// Verify reports whether password matches hash, in constant time. hash is
+// an argon2 PHC string, a hash of the former raw salt||hash format, or of one
+// of the schemes of the legacy verifiers, e.g. Bcrypt, for migrating hashes;
+// NeedsRehash reports the hashes to replace once the password is verified.
+func Verify(password []byte, hash string, legacy ...Verifier) (bool, error) {
+	if strings.HasPrefix(hash, "$argon2") {
+		return verify(password, hash)
+	}
+	for _, v := range legacy {
+		if v.Match(hash) {
+			return v.Verify(password, hash)
+		}
+	}
+	if len(hash) == legacySaltLen+legacyKeyLen {
+		return verifyLegacy(password, []byte(hash)), nil
+	}
+	return false, ErrUnsupportedHash
+}
+
+func verify(password []byte, hash string) (bool, error) {
+	variant, params, salt, key, err := decode(hash)
+	if err != nil {
+		return false, err
+	}
+	var other []byte
+	switch variant {
+	case "argon2id":
+		other = argon2.IDKey(password, salt, params.Time, params.Memory, params.Threads, params.KeyLen)
+	case "argon2i":
+		other = argon2.Key(password, salt, params.Time, params.Memory, params.Threads, params.KeyLen)
+	default:
+		return false, ErrUnsupportedHash
+	}
+	return subtle.ConstantTimeCompare(key, other) == 1, nil
+}
+
+// verifyLegacy verifies the former raw salt||hash format, which didn't
+// encode its parameters: the threads were the number of CPUs of the host.
+func verifyLegacy(password, hash []byte) bool {
+	salt, key := hash[:legacySaltLen], hash[legacySaltLen:]
+	other := argon2.IDKey(password, salt, 3, 64*1024, uint8(runtime.NumCPU()), legacyKeyLen)
+	return subtle.ConstantTimeCompare(key, other) == 1
+}
+
+// decode parses an argon2 PHC string.
+func decode(hash string) (variant string, params Params, salt, key []byte, err error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[0] != "" {
+		return "", params, nil, nil, ErrInvalidHash
+	}
+	variant = parts[1]
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return "", params, nil, nil, ErrInvalidHash
+	}
+	if version != argon2.Version {
+		return "", params, nil, nil, ErrIncompatibleVersion
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Time, &params.Threads); err != nil {
+		return "", params, nil, nil, ErrInvalidHash
+	}
+	if params.Time == 0 || params.Threads == 0 {
+		return "", params, nil, nil, ErrInvalidHash
+	}
+	if salt, err = b64.DecodeString(parts[4]); err != nil {
+		return "", params, nil, nil, ErrInvalidHash
+	}
+	if key, err = b64.DecodeString(parts[5]); err != nil || len(key) == 0 {
+		return "", params, nil, nil, ErrInvalidHash
+	}
+	params.SaltLen, params.KeyLen = uint32(len(salt)), uint32(len(key))
+	return variant, params, salt, key, nil
+}
+
+// NeedsRehash reports whether hash isn't an argon2id hash with params, and
+// should be replaced by a new hash of the password after a successful login.
+func NeedsRehash(hash string, params Params) bool {
+	variant, current, _, _, err := decode(hash)
+	return err != nil || variant != "argon2id" || current != params
 }
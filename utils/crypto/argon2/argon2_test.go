@@ -2,7 +2,13 @@ package argon2
 
 import (
 	"fmt"
+	"runtime"
+	"strings"
 	"testing"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/scrypt"
 )
 
 func TestArgon2(t *testing.T) {
@@ -23,3 +29,67 @@ func TestArgon2(t *testing.T) {
 		fmt.Println("Invalid password!")
 	}
 }
+
+var testParams = Params{Memory: 1024, Time: 1, Threads: 2, SaltLen: 16, KeyLen: 32}
+
+func TestHash(t *testing.T) {
+	hash, err := Hash([]byte("secret"), testParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=1,p=2$") {
+		t.Fatalf("unexpected hash %s", hash)
+	}
+	if ok, err := Verify([]byte("secret"), hash); !ok || err != nil {
+		t.Fatalf("password rejected: %v", err)
+	}
+	if ok, _ := Verify([]byte("other"), hash); ok {
+		t.Fatal("wrong password accepted")
+	}
+	if NeedsRehash(hash, testParams) {
+		t.Fatal("hash with the same params needs a rehash")
+	}
+	if !NeedsRehash(hash, DefaultParams) {
+		t.Fatal("hash with other params doesn't need a rehash")
+	}
+
+	for _, invalid := range []string{
+		"$argon2id$v=19$m=1024,t=1$c2FsdA$aGFzaA",
+		"$argon2id$v=16$m=1024,t=1,p=2$c2FsdA$aGFzaA",
+		"$argon2id$v=19$m=1024,t=1,p=2$c2FsdA$",
+		"plain",
+	} {
+		if ok, err := Verify([]byte("secret"), invalid); ok || err == nil {
+			t.Fatalf("invalid hash %q accepted", invalid)
+		}
+	}
+}
+
+func TestVerifyLegacy(t *testing.T) {
+	salt := []byte("0123456789abcdef")
+	raw := append(salt, argon2.IDKey([]byte("secret"), salt, 3, 64*1024, uint8(runtime.NumCPU()), 32)...)
+	if !VerifyPassword([]byte("secret"), raw) {
+		t.Fatal("raw hash rejected")
+	}
+	if !NeedsRehash(string(raw), DefaultParams) {
+		t.Fatal("raw hash doesn't need a rehash")
+	}
+
+	bcryptHash, _ := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	scryptKey, _ := scrypt.Key([]byte("secret"), salt, 1<<10, 8, 1, 32)
+	scryptHash := "$scrypt$ln=10,r=8,p=1$" + b64.EncodeToString(salt) + "$" + b64.EncodeToString(scryptKey)
+	for _, hash := range []string{string(bcryptHash), scryptHash} {
+		if _, err := Verify([]byte("secret"), hash); err != ErrUnsupportedHash {
+			t.Fatalf("legacy hash verified without verifier: %v", err)
+		}
+		if ok, err := Verify([]byte("secret"), hash, Bcrypt, Scrypt); !ok || err != nil {
+			t.Fatalf("legacy hash %s rejected: %v", hash, err)
+		}
+		if ok, _ := Verify([]byte("other"), hash, Bcrypt, Scrypt); ok {
+			t.Fatalf("wrong password accepted by %s", hash)
+		}
+		if !NeedsRehash(hash, DefaultParams) {
+			t.Fatal("legacy hash doesn't need a rehash")
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package argon2
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Verifier verifies passwords against the hashes of another scheme, so that
+// users can log in while their hashes are migrated to argon2id.
+type Verifier interface {
+	// Match reports whether hash is of the scheme of the verifier.
+	Match(hash string) bool
+	// Verify reports whether password matches hash.
+	Verify(password []byte, hash string) (bool, error)
+}
+
+var (
+	// Bcrypt verifies bcrypt hashes, e.g. $2a$10$<salt and hash>.
+	Bcrypt Verifier = bcryptVerifier{}
+	// Scrypt verifies scrypt hashes in the PHC string format
+	// $scrypt$ln=<log2 N>,r=<r>,p=<p>$<salt>$<hash>.
+	Scrypt Verifier = scryptVerifier{}
+)
+
+type bcryptVerifier struct{}
+
+func (bcryptVerifier) Match(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$")
+}
+
+func (bcryptVerifier) Verify(password []byte, hash string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(hash), password)
+	switch err {
+	case nil:
+		return true, nil
+	case bcrypt.ErrMismatchedHashAndPassword:
+		return false, nil
+	default:
+		return false, ErrInvalidHash
+	}
+}
+
+type scryptVerifier struct{}
+
+func (scryptVerifier) Match(hash string) bool {
+	return strings.HasPrefix(hash, "$scrypt$")
+}
+
+func (scryptVerifier) Verify(password []byte, hash string) (bool, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 5 {
+		return false, ErrInvalidHash
+	}
+	var ln, r, p int
+	if _, err := fmt.Sscanf(parts[2], "ln=%d,r=%d,p=%d", &ln, &r, &p); err != nil || ln <= 0 || ln >= 32 {
+		return false, ErrInvalidHash
+	}
+	salt, err := b64.DecodeString(parts[3])
+	if err != nil {
+		return false, ErrInvalidHash
+	}
+	key, err := b64.DecodeString(parts[4])
+	if err != nil || len(key) == 0 {
+		return false, ErrInvalidHash
+	}
+	other, err := scrypt.Key(password, salt, 1<<ln, r, p, len(key))
+	if err != nil {
+		return false, ErrInvalidHash
+	}
+	return subtle.ConstantTimeCompare(key, other) == 1, nil
+}